@@ -14,11 +14,19 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	urlmonitorv1 "github.com/kuskoman/url-datadog-monitor/pkg/api/v1"
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
 	"github.com/kuskoman/url-datadog-monitor/pkg/controllers"
 	"github.com/kuskoman/url-datadog-monitor/pkg/exporter"
+	"github.com/kuskoman/url-datadog-monitor/pkg/monitor"
+	"github.com/kuskoman/url-datadog-monitor/pkg/notifier"
+	"github.com/kuskoman/url-datadog-monitor/pkg/state"
+	"github.com/kuskoman/url-datadog-monitor/pkg/statuspage"
 	"github.com/kuskoman/url-datadog-monitor/pkg/version"
+	"github.com/kuskoman/url-datadog-monitor/pkg/webhooks"
 )
 
 var scheme = runtime.NewScheme()
@@ -34,6 +42,21 @@ func main() {
 	enableLeaderElection := flag.Bool("leader-elect", false, "Enable leader election for controller manager")
 	dogstatsdHost := flag.String("dogstatsd-host", "127.0.0.1", "Datadog Agent host")
 	dogstatsdPort := flag.Int("dogstatsd-port", 8125, "Datadog Agent port")
+	enableIngressDiscovery := flag.Bool("enable-ingress-discovery", false, "Create URLMonitors for annotated Ingresses and HTTPRoutes")
+	enableServiceDiscovery := flag.Bool("enable-service-discovery", false, "Create URLMonitors for annotated Services")
+	clusterDomain := flag.String("cluster-domain", controllers.DefaultClusterDomain, "DNS domain of the cluster used in discovered Service names")
+	enableWebhooks := flag.Bool("enable-webhooks", false, "Serve the URLMonitor and ClusterURLMonitor defaulting and validating admission webhooks")
+	webhookPort := flag.Int("webhook-port", 9443, "The port the admission webhook server binds to")
+	webhookCertDir := flag.String("webhook-cert-dir", "/tmp/k8s-webhook-server/serving-certs", "Directory containing the webhook serving certificate (tls.crt and tls.key)")
+	maxMonitorsPerNamespace := flag.Int("max-monitors-per-namespace", 0, "Default maximum number of URLMonitors per namespace enforced by the webhook (0 means unlimited)")
+	minInterval := flag.Int("min-interval", 0, "Default minimum URLMonitor check interval in seconds enforced by the webhook")
+	stateConfigMap := flag.String("state-configmap", "", "Name of a ConfigMap persisting certificate serials, content hashes and SLO counts across restarts (disabled when empty)")
+	stateNamespace := flag.String("state-namespace", os.Getenv("POD_NAMESPACE"), "Namespace of the state ConfigMap (defaults to $POD_NAMESPACE)")
+	statusPageAddr := flag.String("status-page-bind-address", "", "The address the HTML status page binds to (disabled when empty)")
+	statusPageTitle := flag.String("status-page-title", config.DefaultStatusPageTitle, "Title of the status page")
+	statusPageGroupBy := flag.String("status-page-group-by", "", "Label the status page groups monitors by")
+	statusPageShowURLs := flag.Bool("status-page-show-urls", false, "List the URL of every monitor on the status page")
+	notifiersFile := flag.String("notifiers-file", "", "YAML file with a notifiers list posting monitor state transitions to webhooks (disabled when empty)")
 	flag.Parse()
 
 	setupLog := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
@@ -67,6 +90,10 @@ func main() {
 		HealthProbeBindAddress: *probeAddr,
 		LeaderElection:         *enableLeaderElection,
 		LeaderElectionID:       "url-datadog-monitor-operator",
+		WebhookServer: webhook.NewServer(webhook.Options{
+			Port:    *webhookPort,
+			CertDir: *webhookCertDir,
+		}),
 	})
 	if err != nil {
 		setupLog.Error("Unable to start manager", slog.Any("error", err))
@@ -75,6 +102,32 @@ func main() {
 
 	eventRecorder := mgr.GetEventRecorderFor("url-datadog-monitor")
 
+	var stateStore state.Store
+	if *stateConfigMap != "" {
+		if *stateNamespace == "" {
+			setupLog.Error("The state ConfigMap needs --state-namespace or $POD_NAMESPACE")
+			os.Exit(1)
+		}
+		stateStore = state.NewConfigMapStore(mgr.GetClient(), mgr.GetAPIReader(), *stateNamespace, *stateConfigMap)
+	}
+
+	var statuses *monitor.StatusTracker
+	if *statusPageAddr != "" {
+		statuses = monitor.NewStatusTracker(config.DefaultHistorySize)
+	}
+
+	var dispatcher *notifier.Dispatcher
+	if *notifiersFile != "" {
+		notifiers, err := config.LoadNotifiers(*notifiersFile)
+		if err == nil {
+			dispatcher, err = notifier.NewDispatcher(notifiers, setupLog)
+		}
+		if err != nil {
+			setupLog.Error("Failed to set up notifiers", slog.Any("error", err))
+			os.Exit(1)
+		}
+	}
+
 	reconciler := controllers.NewURLMonitorReconciler(
 		mgr.GetClient(),
 		mgr.GetScheme(),
@@ -82,12 +135,138 @@ func main() {
 		setupLog,
 		eventRecorder,
 	)
+	reconciler.APIReader = mgr.GetAPIReader()
+	reconciler.StateStore = stateStore
+	reconciler.Statuses = statuses
+	reconciler.Notifier = dispatcher
 
 	if err = reconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error("Unable to create controller", slog.String("controller", "URLMonitor"), slog.Any("error", err))
 		os.Exit(1)
 	}
 
+	clusterReconciler := controllers.NewClusterURLMonitorReconciler(
+		mgr.GetClient(),
+		mgr.GetScheme(),
+		dogstatsd,
+		setupLog,
+		eventRecorder,
+	)
+	clusterReconciler.APIReader = mgr.GetAPIReader()
+	clusterReconciler.StateStore = stateStore
+	clusterReconciler.Statuses = statuses
+	clusterReconciler.Notifier = dispatcher
+
+	if err = clusterReconciler.SetupWithManager(mgr); err != nil {
+		setupLog.Error("Unable to create controller", slog.String("controller", "ClusterURLMonitor"), slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	groupReconciler := controllers.NewURLMonitorGroupReconciler(
+		mgr.GetClient(),
+		mgr.GetScheme(),
+		setupLog,
+		eventRecorder,
+	)
+
+	if err = groupReconciler.SetupWithManager(mgr); err != nil {
+		setupLog.Error("Unable to create controller", slog.String("controller", "URLMonitorGroup"), slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	if *enableWebhooks {
+		policy := webhooks.Policy{
+			MaxMonitorsPerNamespace: *maxMonitorsPerNamespace,
+			MinInterval:             *minInterval,
+		}
+
+		urlMonitorWebhook := webhooks.NewURLMonitorWebhook(mgr.GetClient(), policy)
+		urlMonitorWebhook.APIReader = mgr.GetAPIReader()
+		if err = urlMonitorWebhook.SetupWithManager(mgr); err != nil {
+			setupLog.Error("Unable to create webhook", slog.String("webhook", "URLMonitor"), slog.Any("error", err))
+			os.Exit(1)
+		}
+
+		clusterWebhook := webhooks.NewClusterURLMonitorWebhook(mgr.GetClient(), policy)
+		clusterWebhook.APIReader = mgr.GetAPIReader()
+		if err = clusterWebhook.SetupWithManager(mgr); err != nil {
+			setupLog.Error("Unable to create webhook", slog.String("webhook", "ClusterURLMonitor"), slog.Any("error", err))
+			os.Exit(1)
+		}
+	}
+
+	if *enableIngressDiscovery {
+		ingressReconciler := controllers.NewIngressDiscoveryReconciler(
+			mgr.GetClient(),
+			mgr.GetScheme(),
+			setupLog,
+			eventRecorder,
+		)
+
+		if err = ingressReconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error("Unable to create controller", slog.String("controller", "IngressDiscovery"), slog.Any("error", err))
+			os.Exit(1)
+		}
+
+		if controllers.HTTPRouteAvailable(mgr.GetRESTMapper()) {
+			routeReconciler := controllers.NewHTTPRouteDiscoveryReconciler(
+				mgr.GetClient(),
+				mgr.GetScheme(),
+				setupLog,
+				eventRecorder,
+			)
+
+			if err = routeReconciler.SetupWithManager(mgr); err != nil {
+				setupLog.Error("Unable to create controller", slog.String("controller", "HTTPRouteDiscovery"), slog.Any("error", err))
+				os.Exit(1)
+			}
+		} else {
+			setupLog.Info("Gateway API HTTPRoute CRD not found, HTTPRoute discovery disabled")
+		}
+	}
+
+	if *enableServiceDiscovery {
+		serviceReconciler := controllers.NewServiceDiscoveryReconciler(
+			mgr.GetClient(),
+			mgr.GetScheme(),
+			setupLog,
+			eventRecorder,
+		)
+		serviceReconciler.ClusterDomain = *clusterDomain
+
+		if err = serviceReconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error("Unable to create controller", slog.String("controller", "ServiceDiscovery"), slog.Any("error", err))
+			os.Exit(1)
+		}
+	}
+
+	if statuses != nil {
+		// Monitors are only checked by the leader. Every replica serves the page, so a Service
+		// in front of them always reaches one, and the others report they are on standby.
+		elected := mgr.Elected()
+		page := statuspage.NewGenerator(statuses, statuspage.Options{
+			Title:    *statusPageTitle,
+			GroupBy:  *statusPageGroupBy,
+			ShowURLs: *statusPageShowURLs,
+			Standby: func() bool {
+				select {
+				case <-elected:
+					return false
+				default:
+					return true
+				}
+			},
+		})
+		err := mgr.Add(everyReplica{manager.RunnableFunc(func(ctx context.Context) error {
+			setupLog.Info("Starting status page", slog.String("address", *statusPageAddr))
+			return page.ListenAndServe(ctx, *statusPageAddr)
+		})})
+		if err != nil {
+			setupLog.Error("Unable to set up status page", slog.Any("error", err))
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error("Unable to set up health check", slog.Any("error", err))
 		os.Exit(1)
@@ -102,4 +281,17 @@ func main() {
 		setupLog.Error("Problem running manager", slog.Any("error", err))
 		os.Exit(1)
 	}
+	if dispatcher != nil {
+		dispatcher.Close()
+	}
+}
+
+// everyReplica is a runnable started on every replica rather than on the leader only
+type everyReplica struct {
+	manager.RunnableFunc
+}
+
+// NeedLeaderElection reports that the runnable doesn't wait for leader election
+func (everyReplica) NeedLeaderElection() bool {
+	return false
 }
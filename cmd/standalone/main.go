@@ -7,10 +7,14 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/kuskoman/url-datadog-monitor/pkg/config"
 	"github.com/kuskoman/url-datadog-monitor/pkg/exporter"
 	"github.com/kuskoman/url-datadog-monitor/pkg/monitor"
+	"github.com/kuskoman/url-datadog-monitor/pkg/notifier"
+	"github.com/kuskoman/url-datadog-monitor/pkg/statusapi"
+	"github.com/kuskoman/url-datadog-monitor/pkg/statuspage"
 	"github.com/kuskoman/url-datadog-monitor/pkg/version"
 )
 
@@ -54,7 +58,50 @@ func main() {
 	logger.Info("Starting URL monitor service",
 		slog.Int("target_count", len(cfg.Targets)))
 
-	monitor.Targets(ctx, cfg, dogstatsd)
+	runner := monitor.NewRunner(dogstatsd, monitor.NewJSONLogger())
+	if len(cfg.Notifiers) > 0 {
+		runner.Notifier, err = notifier.NewDispatcher(cfg.Notifiers, logger)
+		if err != nil {
+			logger.Error("Failed to set up notifiers", slog.Any("error", err))
+			os.Exit(1)
+		}
+	}
+	scheduler := monitor.NewScheduler(cfg, runner)
+	if cfg.API.Listen != "" || cfg.StatusPage != nil {
+		runner.Statuses = monitor.NewStatusTracker(cfg.API.HistorySize)
+	}
+
+	var page *statuspage.Generator
+	if cfg.StatusPage != nil {
+		page = statuspage.NewGenerator(runner.Statuses, statuspage.Options{
+			Title:    cfg.StatusPage.Title,
+			GroupBy:  cfg.StatusPage.GroupBy,
+			ShowURLs: cfg.StatusPage.ShowURLs,
+		})
+		if cfg.StatusPage.Output != "" {
+			go page.Run(ctx, cfg.StatusPage.Output, time.Duration(cfg.StatusPage.Interval)*time.Second, logger)
+		} else if cfg.API.Listen == "" {
+			logger.Warn("The status page is neither served nor written, set api.listen or status_page.output")
+		}
+	}
+
+	if cfg.API.Listen != "" {
+		server := statusapi.NewServer(scheduler, runner.Statuses, logger)
+		if page != nil {
+			server.StatusPage = page
+		}
+		go func() {
+			if err := server.ListenAndServe(ctx, cfg.API.Listen); err != nil {
+				logger.Error("Status API failed", slog.Any("error", err))
+				cancel()
+			}
+		}()
+	}
+
+	scheduler.Run(ctx)
+	if runner.Notifier != nil {
+		runner.Notifier.Close()
+	}
 
 	logger.Info("URL monitor service shutdown complete")
 }
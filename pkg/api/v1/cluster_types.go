@@ -0,0 +1,50 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.spec.url`
+// +kubebuilder:printcolumn:name="Interval",type=integer,JSONPath=`.spec.interval`
+// +kubebuilder:printcolumn:name="Last Check",type=string,JSONPath=`.status.lastCheckTime`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.status`
+// +kubebuilder:validation:XValidation:rule="self.spec.timeout < self.spec.interval",message="Timeout must be less than interval"
+// +kubebuilder:validation:XValidation:rule="!has(self.spec.checkCert) || !has(self.spec.verifyCert) || !self.spec.url.startsWith('http://')",message="Certificate validation doesn't apply to plain HTTP URLs"
+// +kubebuilder:validation:XValidation:rule="!has(self.spec.latencyWarningMs) || !has(self.spec.latencyCriticalMs) || self.spec.latencyWarningMs < self.spec.latencyCriticalMs",message="latencyWarningMs must be below latencyCriticalMs"
+
+// ClusterURLMonitor is the Schema for the cluster-scoped clusterurlmonitors API.
+// It shares its spec and status with URLMonitor and is meant for platform-owned
+// endpoints that don't belong to any namespace.
+type ClusterURLMonitor struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   URLMonitorSpec   `json:"spec,omitempty"`
+	Status URLMonitorStatus `json:"status,omitempty"`
+}
+
+// MonitorSpec returns the monitoring spec of the resource
+func (m *ClusterURLMonitor) MonitorSpec() *URLMonitorSpec {
+	return &m.Spec
+}
+
+// MonitorStatus returns the monitoring status of the resource
+func (m *ClusterURLMonitor) MonitorStatus() *URLMonitorStatus {
+	return &m.Status
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterURLMonitorList contains a list of ClusterURLMonitor
+type ClusterURLMonitorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterURLMonitor `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterURLMonitor{}, &ClusterURLMonitorList{})
+}
@@ -0,0 +1,85 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Monitors",type=integer,JSONPath=`.status.monitors`
+// +kubebuilder:printcolumn:name="Up",type=integer,JSONPath=`.status.up`
+// +kubebuilder:printcolumn:name="Down",type=integer,JSONPath=`.status.down`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.status`
+// +kubebuilder:validation:XValidation:rule="self.spec.template.timeout < self.spec.template.interval",message="Timeout must be less than interval"
+// +kubebuilder:validation:XValidation:rule="!has(self.spec.template.latencyWarningMs) || !has(self.spec.template.latencyCriticalMs) || self.spec.template.latencyWarningMs < self.spec.template.latencyCriticalMs",message="latencyWarningMs must be below latencyCriticalMs"
+
+// URLMonitorGroup is the Schema for the urlmonitorgroups API.
+// It generates and owns one URLMonitor for every combination of its parameters.
+type URLMonitorGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   URLMonitorGroupSpec   `json:"spec,omitempty"`
+	Status URLMonitorGroupStatus `json:"status,omitempty"`
+}
+
+// URLMonitorGroupSpec defines the desired state of URLMonitorGroup
+type URLMonitorGroupSpec struct {
+	// Template for the generated URLMonitors.
+	// Placeholders of the form ${name} in the URL, header values and label values
+	// are replaced with the values of the matching parameter.
+	// +kubebuilder:validation:Required
+	Template URLMonitorSpec `json:"template"`
+
+	// Hosts substituted for the ${host} placeholder
+	// +optional
+	Hosts []string `json:"hosts,omitempty"`
+
+	// Paths substituted for the ${path} placeholder
+	// +optional
+	Paths []string `json:"paths,omitempty"`
+
+	// Additional parameters substituted for ${name} placeholders.
+	// A URLMonitor is generated for every combination of hosts, paths and matrix values.
+	// +optional
+	Matrix map[string][]string `json:"matrix,omitempty"`
+}
+
+// URLMonitorGroupStatus defines the observed state of URLMonitorGroup
+type URLMonitorGroupStatus struct {
+	// Number of URLMonitors generated by the group
+	Monitors int `json:"monitors,omitempty"`
+
+	// Number of generated URLMonitors that are up
+	Up int `json:"up,omitempty"`
+
+	// Number of generated URLMonitors that are down or failed to be checked
+	Down int `json:"down,omitempty"`
+
+	// Number of generated URLMonitors that are up but slower than their latency warning or
+	// critical threshold
+	Degraded int `json:"degraded,omitempty"`
+
+	// Aggregated status of the group (Healthy, Degraded, Down or Pending)
+	Status string `json:"status,omitempty"`
+
+	// Names of the generated URLMonitors that are not up
+	// +optional
+	FailingMonitors []string `json:"failingMonitors,omitempty"`
+
+	// Last time the aggregated status changed
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// URLMonitorGroupList contains a list of URLMonitorGroup
+type URLMonitorGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []URLMonitorGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&URLMonitorGroup{}, &URLMonitorGroupList{})
+}
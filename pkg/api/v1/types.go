@@ -11,7 +11,8 @@ import (
 // +kubebuilder:printcolumn:name="Last Check",type=string,JSONPath=`.status.lastCheckTime`
 // +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.status`
 // +kubebuilder:validation:XValidation:rule="self.spec.timeout < self.spec.interval",message="Timeout must be less than interval"
-// +kubebuilder:validation:XValidation:rule="!has(self.spec.checkCert) || !has(self.spec.verifyCert) || self.spec.url.startsWith('https://')",message="Certificate validation only applies to HTTPS URLs"
+// +kubebuilder:validation:XValidation:rule="!has(self.spec.checkCert) || !has(self.spec.verifyCert) || !self.spec.url.startsWith('http://')",message="Certificate validation doesn't apply to plain HTTP URLs"
+// +kubebuilder:validation:XValidation:rule="!has(self.spec.latencyWarningMs) || !has(self.spec.latencyCriticalMs) || self.spec.latencyWarningMs < self.spec.latencyCriticalMs",message="latencyWarningMs must be below latencyCriticalMs"
 
 // URLMonitor is the Schema for the urlmonitors API
 type URLMonitor struct {
@@ -22,11 +23,22 @@ type URLMonitor struct {
 	Status URLMonitorStatus `json:"status,omitempty"`
 }
 
+// MonitorSpec returns the monitoring spec of the resource
+func (m *URLMonitor) MonitorSpec() *URLMonitorSpec {
+	return &m.Spec
+}
+
+// MonitorStatus returns the monitoring status of the resource
+func (m *URLMonitor) MonitorStatus() *URLMonitorStatus {
+	return &m.Status
+}
+
 // URLMonitorSpec defines the desired state of URLMonitor
 type URLMonitorSpec struct {
-	// URL to monitor
+	// URL to monitor. Besides http and https, tls://host:port checks a direct TLS
+	// endpoint and smtp, imap, ldap and postgres URLs check the certificate after STARTTLS
 	// +kubebuilder:validation:Required
-	// +kubebuilder:validation:Pattern=`^https?://.*`
+	// +kubebuilder:validation:Pattern=`^(https?|tls|smtp|imap|ldap|postgres)://.*`
 	URL string `json:"url"`
 
 	// HTTP method to use for the request
@@ -63,6 +75,204 @@ type URLMonitorSpec struct {
 	// +optional
 	// +kubebuilder:default=false
 	VerifyCert *bool `json:"verifyCert,omitempty"`
+
+	// HTTP status codes considered healthy (any 2xx status when empty)
+	// +optional
+	// +kubebuilder:validation:items:Minimum=100
+	// +kubebuilder:validation:items:Maximum=599
+	ExpectedStatus []int `json:"expectedStatus,omitempty"`
+
+	// TLS versions and cipher suites the endpoint may negotiate
+	// +optional
+	TLSPolicy *TLSPolicy `json:"tlsPolicy,omitempty"`
+
+	// Hex encoded SHA-256 fingerprints of the leaf certificate or its public key (SPKI).
+	// The certificate check fails when the leaf matches none of them
+	// +optional
+	// +kubebuilder:validation:items:Pattern=`^([0-9a-fA-F]{2}:?){31}[0-9a-fA-F]{2}$`
+	CertFingerprints []string `json:"certFingerprints,omitempty"`
+
+	// Whether to query the OCSP responder or CRL when the endpoint doesn't staple an OCSP response
+	// +optional
+	CheckRevocation *bool `json:"checkRevocation,omitempty"`
+
+	// Secret holding PEM encoded CA certificates trusted instead of the system trust store
+	// +optional
+	CASecret *SecretKeyReference `json:"caSecret,omitempty"`
+
+	// Server name sent in SNI and verified against the certificate instead of the URL host
+	// +optional
+	ServerName string `json:"serverName,omitempty"`
+
+	// DNS names the certificate must be valid for, wildcard certificates included
+	// +optional
+	ExpectedDNSNames []string `json:"expectedDNSNames,omitempty"`
+
+	// Addresses connected to instead of resolving host:port pairs, like curl --resolve
+	// +optional
+	Resolve map[string]string `json:"resolve,omitempty"`
+
+	// IP address of the DNS server resolving the target host, with an optional port
+	// +optional
+	DNSServer string `json:"dnsServer,omitempty"`
+
+	// IP family the URL is checked over. "both" checks IPv4 and IPv6 separately
+	// and tags availability metrics with ip_family
+	// +optional
+	// +kubebuilder:validation:Enum=any;ipv4;ipv6;both
+	IPFamily string `json:"ipFamily,omitempty"`
+
+	// Proxy to connect through: an http, https or socks5 URL without credentials, "env" to
+	// follow the HTTPS_PROXY and NO_PROXY variables of the operator, or "direct"
+	// +optional
+	Proxy string `json:"proxy,omitempty"`
+
+	// Maximum number of redirects followed, 0 evaluates the first response (10 when unset)
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=50
+	FollowRedirects *int `json:"followRedirects,omitempty"`
+
+	// URL the redirects must end at for the URL to be up
+	// +optional
+	// +kubebuilder:validation:Pattern=`^https?://.+`
+	ExpectedFinalURL string `json:"expectedFinalURL,omitempty"`
+
+	// Whether to check the certificate of every HTTPS host the URL redirects through
+	// +optional
+	CheckRedirectCertificates bool `json:"checkRedirectCertificates,omitempty"`
+
+	// HTTP protocol the URL must be served over: h1, h2 (negotiated with ALPN), h2c
+	// (HTTP/2 without TLS) or h3 (HTTP/3 over QUIC). Any protocol is accepted when unset
+	// +optional
+	// +kubebuilder:validation:Enum=h1;h2;h2c;h3
+	Protocol string `json:"protocol,omitempty"`
+
+	// Assertions on the response headers, the URL is down when one fails
+	// +optional
+	HeaderAssertions []HeaderAssertion `json:"headerAssertions,omitempty"`
+
+	// Whether to score the security headers and cookie flags of the response
+	// +optional
+	SecurityAudit bool `json:"securityAudit,omitempty"`
+
+	// Whether to hash the response body and report when it changes between checks
+	// +optional
+	DetectChanges bool `json:"detectChanges,omitempty"`
+
+	// Regular expressions matching parts of the body, like timestamps or nonces, that are
+	// left out of the content hash
+	// +optional
+	ChangeIgnoreRegexes []string `json:"changeIgnoreRegexes,omitempty"`
+
+	// Response time in milliseconds above which the URL is reported as degraded
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	LatencyWarningMs int `json:"latencyWarningMs,omitempty"`
+
+	// Response time in milliseconds above which the URL is reported as down, while url.up
+	// still reflects whether the check succeeded
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	LatencyCriticalMs int `json:"latencyCriticalMs,omitempty"`
+
+	// Service level objective tracked over the checks of the URL
+	// +optional
+	SLO *SLO `json:"slo,omitempty"`
+}
+
+// SLO is an objective on the percentage of good checks over a rolling window. Failed checks and
+// checks slower than latencyCriticalMs are bad.
+type SLO struct {
+	// Percentage of checks that must be good, like "99.9" (as string to avoid float compatibility issues)
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	Objective string `json:"objective"`
+
+	// Rolling window of the objective in days, like "30d", or as a duration, like "12h"
+	// +kubebuilder:default="30d"
+	// +optional
+	Window string `json:"window,omitempty"`
+
+	// Multi-window burn rate alerts, 1h/5m above 14.4 and 6h/30m above 6 when empty
+	// +optional
+	BurnRateAlerts []BurnRateAlert `json:"burnRateAlerts,omitempty"`
+}
+
+// BurnRateAlert fires when the error budget burns faster than the threshold over both windows
+type BurnRateAlert struct {
+	// Long window of the alert, like "1h"
+	// +kubebuilder:validation:Required
+	LongWindow string `json:"longWindow"`
+
+	// Short window of the alert, like "5m", confirming the burn is still ongoing
+	// +kubebuilder:validation:Required
+	ShortWindow string `json:"shortWindow"`
+
+	// Burn rate above which the alert fires, 1 spending exactly the budget over the SLO window
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	Threshold string `json:"threshold"`
+}
+
+// HeaderAssertion checks a response header. Exactly one of present, absent, equals and regex is set
+type HeaderAssertion struct {
+	// Name of the response header, matched case insensitively
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Header string `json:"header"`
+
+	// Whether the header must be sent
+	// +optional
+	Present bool `json:"present,omitempty"`
+
+	// Whether the header must not be sent
+	// +optional
+	Absent bool `json:"absent,omitempty"`
+
+	// Value one of the header values must equal
+	// +optional
+	Equals string `json:"equals,omitempty"`
+
+	// Regular expression one of the header values must match
+	// +optional
+	Regex string `json:"regex,omitempty"`
+}
+
+// DefaultCASecretKey is the Secret key holding CA certificates when a caSecret doesn't name one
+const DefaultCASecretKey = "ca.crt"
+
+// SecretKeyReference selects a key of a Secret
+type SecretKeyReference struct {
+	// Name of the Secret
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Namespace of the Secret. URLMonitors may only reference Secrets in their own namespace,
+	// ClusterURLMonitors must set it
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Key of the Secret holding the PEM data
+	// +kubebuilder:default="ca.crt"
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// TLSPolicy describes the acceptable TLS versions and cipher suites of an endpoint
+type TLSPolicy struct {
+	// Lowest acceptable TLS version
+	// +optional
+	// +kubebuilder:validation:Enum="1.0";"1.1";"1.2";"1.3"
+	MinVersion string `json:"minVersion,omitempty"`
+
+	// Acceptable cipher suites by IANA name (any secure suite when empty)
+	// +optional
+	Ciphers []string `json:"ciphers,omitempty"`
+
+	// Whether to check that versions below minVersion are rejected by the endpoint
+	// +optional
+	ProbeLegacy bool `json:"probeLegacy,omitempty"`
 }
 
 // URLMonitorStatus defines the observed state of URLMonitor
@@ -70,17 +280,38 @@ type URLMonitorStatus struct {
 	// Last time the URL was checked
 	LastCheckTime metav1.Time `json:"lastCheckTime,omitempty"`
 
-	// Status of the URL (up or down)
+	// Status of the URL: Up, Degraded when slower than latencyWarningMs, Critical when slower
+	// than latencyCriticalMs, Down or Error
 	Status string `json:"status,omitempty"`
 
+	// Message explains an Error status caused by the spec, such as an invalid SLO
+	Message string `json:"message,omitempty"`
+
 	// HTTP status code from the last check
 	StatusCode int `json:"statusCode,omitempty"`
 
 	// Response time in milliseconds
 	ResponseTime int64 `json:"responseTime,omitempty"`
 
+	// Number of redirects followed during the last check
+	Redirects int `json:"redirects,omitempty"`
+
+	// URL of the response evaluated by the last check, after redirects
+	FinalURL string `json:"finalURL,omitempty"`
+
+	// Hash of the normalized response body, only set when detecting changes
+	ContentHash string `json:"contentHash,omitempty"`
+
 	// Certificate information (if HTTPS and certificate checking is enabled)
 	Certificate *CertificateStatus `json:"certificate,omitempty"`
+
+	// SLO attainment and the check counts it is computed from, only set with an slo
+	SLO *SLOStatus `json:"slo,omitempty"`
+
+	// Conditions describe aspects of the monitored endpoint, such as weak certificates
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // CertificateStatus contains information about the SSL certificate
@@ -95,12 +326,88 @@ type CertificateStatus struct {
 	// Issuer of the certificate
 	Issuer string `json:"issuer,omitempty"`
 
+	// Serial number of the leaf certificate, used to detect rotations
+	SerialNumber string `json:"serialNumber,omitempty"`
+
 	// Expiration date of the certificate
 	NotAfter metav1.Time `json:"notAfter,omitempty"`
 
 	// Days until the certificate expires (as string to avoid float compatibility issues)
 	// Format is a string representation of a float for cross-language compatibility
 	DaysUntilExpiry string `json:"daysUntilExpiry,omitempty"`
+
+	// Days until the first certificate of the presented chain expires, intermediates included
+	ChainDaysUntilExpiry string `json:"chainDaysUntilExpiry,omitempty"`
+
+	// Whether any certificate of the chain uses a weak key or signature algorithm
+	Weak bool `json:"weak,omitempty"`
+
+	// Negotiated TLS version
+	TLSVersion string `json:"tlsVersion,omitempty"`
+
+	// Negotiated cipher suite
+	CipherSuite string `json:"cipherSuite,omitempty"`
+
+	// Ways the endpoint breaks the TLS policy, empty when compliant
+	PolicyViolations []string `json:"policyViolations,omitempty"`
+
+	// Whether the leaf matched one of the pinned fingerprints (only set when pins are configured)
+	PinMatch *bool `json:"pinMatch,omitempty"`
+
+	// Whether the endpoint stapled an OCSP response to the handshake
+	OCSPStapled bool `json:"ocspStapled,omitempty"`
+
+	// Revocation status of the leaf certificate (good, revoked, unknown or unchecked)
+	RevocationStatus string `json:"revocationStatus,omitempty"`
+
+	// Time the leaf certificate was revoked
+	RevokedAt *metav1.Time `json:"revokedAt,omitempty"`
+
+	// Certificates presented by the server, leaf first
+	Chain []ChainCertificateStatus `json:"chain,omitempty"`
+}
+
+// ChainCertificateStatus describes one certificate of the presented chain
+type ChainCertificateStatus struct {
+	// Subject of the certificate
+	Subject string `json:"subject,omitempty"`
+
+	// Issuer of the certificate
+	Issuer string `json:"issuer,omitempty"`
+
+	// Expiration date of the certificate
+	NotAfter metav1.Time `json:"notAfter,omitempty"`
+
+	// Public key algorithm (RSA, ECDSA or Ed25519)
+	KeyType string `json:"keyType,omitempty"`
+
+	// Public key size in bits
+	KeyBits int `json:"keyBits,omitempty"`
+
+	// Algorithm used to sign the certificate
+	SignatureAlgorithm string `json:"signatureAlgorithm,omitempty"`
+
+	// Hex encoded SHA-256 fingerprint of the certificate
+	FingerprintSHA256 string `json:"fingerprintSHA256,omitempty"`
+
+	// Hex encoded SHA-256 fingerprint of the public key info
+	SPKIFingerprintSHA256 string `json:"spkiFingerprintSHA256,omitempty"`
+}
+
+// SLOStatus reports the state of the service level objective of a URL. Percentages and burn
+// rates are string representations of floats for cross-language compatibility.
+type SLOStatus struct {
+	// Percentage of good checks over the SLO window
+	Attainment string `json:"attainment,omitempty"`
+
+	// Percentage of the error budget left, negative once exhausted
+	ErrorBudgetRemaining string `json:"errorBudgetRemaining,omitempty"`
+
+	// Burn rates by alert window
+	BurnRates map[string]string `json:"burnRates,omitempty"`
+
+	// Burn rate alerts currently firing, like "1h/5m"
+	FiringAlerts []string `json:"firingAlerts,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,30 @@
+package certcheck
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// NewCertPool builds a certificate pool from PEM encoded CA certificates
+func NewCertPool(pemData []byte) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemData) {
+		return nil, fmt.Errorf("no PEM encoded certificates found")
+	}
+	return pool, nil
+}
+
+// LoadCertPool reads PEM encoded CA certificates from a file into a certificate pool
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	pemData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read CA file: %w", err)
+	}
+
+	pool, err := NewCertPool(pemData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA file %s: %w", path, err)
+	}
+	return pool, nil
+}
@@ -0,0 +1,36 @@
+package certcheck
+
+import (
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewCertPool(t *testing.T) {
+	root, _, _ := newTestChain(t, time.Now().Add(365*24*time.Hour))
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.cert.Raw})
+
+	if _, err := NewCertPool(pemData); err != nil {
+		t.Errorf("Expected a valid pool, got %v", err)
+	}
+	if _, err := NewCertPool([]byte("not a certificate")); err == nil {
+		t.Errorf("Expected an error for data without certificates")
+	}
+}
+
+func TestLoadCertPool(t *testing.T) {
+	root, _, _ := newTestChain(t, time.Now().Add(365*24*time.Hour))
+	path := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.cert.Raw}), 0o600); err != nil {
+		t.Fatalf("Failed to write CA file: %v", err)
+	}
+
+	if _, err := LoadCertPool(path); err != nil {
+		t.Errorf("Expected a valid pool, got %v", err)
+	}
+	if _, err := LoadCertPool(filepath.Join(t.TempDir(), "missing.crt")); err == nil {
+		t.Errorf("Expected an error for a missing file")
+	}
+}
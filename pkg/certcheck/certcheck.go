@@ -1,21 +1,48 @@
 package certcheck
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"fmt"
 	"log/slog"
 	"net"
-	"net/url"
 	"strings"
 	"time"
 )
 
 const (
-	SchemeHTTPS    = "https"
+	SchemeHTTPS      = "https"
 	DefaultHTTPSPort = "443"
 )
 
+const (
+	KeyTypeRSA     = "RSA"
+	KeyTypeECDSA   = "ECDSA"
+	KeyTypeEd25519 = "Ed25519"
+	KeyTypeUnknown = "unknown"
+	MinRSAKeyBits  = 2048
+)
+
+// ChainCertificate describes one certificate of the chain presented by a server
+type ChainCertificate struct {
+	Subject               string
+	Issuer                string
+	SerialNumber          string
+	NotBefore             time.Time
+	NotAfter              time.Time
+	KeyType               string
+	KeyBits               int
+	SignatureAlgorithm    string
+	FingerprintSHA256     string
+	SPKIFingerprintSHA256 string
+}
+
 // CertificateDetails holds information about an SSL certificate
 type CertificateDetails struct {
 	Subject      string
@@ -26,39 +53,135 @@ type CertificateDetails struct {
 	DNSNames     []string
 	IsValid      bool
 	Error        error
+	// Chain holds every certificate presented by the server, leaf first
+	Chain []ChainCertificate
+	// WeakReasons lists why certificates of the chain are considered weak
+	WeakReasons []string
+	// TLSVersion and CipherSuite describe the negotiated connection
+	TLSVersion  string
+	CipherSuite string
+	// PolicyViolations lists how the server breaks the TLS policy, if one was given
+	PolicyViolations []string
+	// PinMatch reports whether the leaf matched one of the pinned fingerprints, if any were given
+	PinMatch bool
+	// MissingDNSNames lists the expected DNS names the leaf isn't valid for, if any were given
+	MissingDNSNames []string
+	// OCSPStapled reports whether the server stapled an OCSP response to the handshake
+	OCSPStapled bool
+	// Revocation holds the revocation status of the leaf
+	Revocation RevocationResult
+}
+
+// IsRevoked reports whether the leaf certificate was found to be revoked
+func (d *CertificateDetails) IsRevoked() bool {
+	return d.Revocation.Status == RevocationRevoked
+}
+
+// Options configure how a certificate is retrieved and evaluated
+type Options struct {
+	// VerifyChain verifies the chain against the system trust store
+	VerifyChain bool
+	// Timeout bounds every connection made by the check, no limit when zero
+	Timeout time.Duration
+	// TLSPolicy is evaluated against the negotiated connection when set
+	TLSPolicy *TLSPolicy
+	// Pins are SHA-256 fingerprints of the leaf certificate or its public key.
+	// When set, a leaf matching none of them fails the check
+	Pins []string
+	// CheckRevocation queries the OCSP responder, or the CRL distribution point,
+	// when the server doesn't staple a usable OCSP response
+	CheckRevocation bool
+	// RootCAs replaces the system trust store when verifying the chain
+	RootCAs *x509.CertPool
+	// ServerName is sent in SNI and verified against the certificate instead of the URL host
+	ServerName string
+	// ExpectedDNSNames lists names the leaf must be valid for, wildcards included
+	ExpectedDNSNames []string
+	// Dial connects to the server instead of a plain net.Dialer when set
+	Dial DialFunc
+}
+
+// DialFunc connects to an address, like net.Dialer.DialContext
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// IsWeak reports whether any certificate of the chain uses a weak key or signature
+func (d *CertificateDetails) IsWeak() bool {
+	return len(d.WeakReasons) > 0
+}
+
+// ChainNotAfter returns the earliest expiry of all certificates in the chain
+func (d *CertificateDetails) ChainNotAfter() time.Time {
+	notAfter := d.NotAfter
+	for _, cert := range d.Chain {
+		if cert.NotAfter.Before(notAfter) {
+			notAfter = cert.NotAfter
+		}
+	}
+	return notAfter
 }
 
 // CheckCertificate retrieves and validates the SSL certificate for a given URL
 func CheckCertificate(rawURL string, verifyChain bool) (*CertificateDetails, error) {
-	parsedURL, err := url.Parse(rawURL)
+	return CheckCertificateWithOptions(rawURL, Options{VerifyChain: verifyChain})
+}
+
+// CheckCertificateWithOptions retrieves and validates the SSL certificate for a given URL.
+// Besides https, it accepts tls://host:port for direct TLS and smtp, imap, ldap and
+// postgres URLs, which are upgraded with the protocol's STARTTLS negotiation.
+func CheckCertificateWithOptions(rawURL string, opts Options) (*CertificateDetails, error) {
+	scheme, host, address, err := tlsEndpoint(rawURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse URL: %w", err)
+		return nil, err
+	}
+	if opts.ServerName != "" {
+		host = opts.ServerName
 	}
 
-	host := parsedURL.Host
-	var port string
-
-	if strings.Contains(host, ":") {
-		var portErr error
-		host, port, portErr = net.SplitHostPort(parsedURL.Host)
-		if portErr != nil {
-			return nil, fmt.Errorf("failed to split host and port: %w", portErr)
-		}
-	} else if parsedURL.Scheme == SchemeHTTPS {
-		port = DefaultHTTPSPort
-	} else {
-		return nil, fmt.Errorf("not an HTTPS URL, cannot check certificate")
+	config := &tls.Config{
+		ServerName:         host,
+		RootCAs:            opts.RootCAs,
+		InsecureSkipVerify: !opts.VerifyChain,
+	}
+	if opts.TLSPolicy.offersEverySuite() {
+		// Offer every suite and version so the server's own preference is what gets reported
+		config.MinVersion = tls.VersionTLS10
+		config.CipherSuites = allCipherSuites()
 	}
 
-	conn, err := tls.Dial("tcp", fmt.Sprintf("%s:%s", host, port), &tls.Config{
-		InsecureSkipVerify: !verifyChain,
-	})
+	conn, err := dialTLS(scheme, address, config, opts.Dial, opts.Timeout)
 	if err != nil {
-		return nil, fmt.Errorf("TLS connection failed: %w", err)
+		return nil, err
 	}
 	defer conn.Close()
 
-	cert := conn.ConnectionState().PeerCertificates[0]
+	return inspectConnection(conn.ConnectionState(), scheme, host, address, opts)
+}
+
+// CheckConnectionState validates the certificate of a connection already established
+// to the URL, such as the one that served an HTTP request, without connecting again.
+// It falls back to a new connection when state is nil or when the TLS policy needs a
+// handshake offering every cipher suite, which regular clients don't.
+func CheckConnectionState(rawURL string, state *tls.ConnectionState, opts Options) (*CertificateDetails, error) {
+	if state == nil || len(state.PeerCertificates) == 0 || opts.TLSPolicy.offersEverySuite() {
+		return CheckCertificateWithOptions(rawURL, opts)
+	}
+
+	scheme, host, address, err := tlsEndpoint(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if opts.ServerName != "" {
+		host = opts.ServerName
+	}
+	return inspectConnection(*state, scheme, host, address, opts)
+}
+
+// inspectConnection extracts and validates the certificate details of a TLS connection
+func inspectConnection(state tls.ConnectionState, scheme, host, address string, opts Options) (*CertificateDetails, error) {
+	verifyChain := opts.VerifyChain
+
+	peerCertificates := state.PeerCertificates
+	cert := peerCertificates[0]
 	details := &CertificateDetails{
 		Subject:      cert.Subject.CommonName,
 		Issuer:       cert.Issuer.CommonName,
@@ -69,27 +192,82 @@ func CheckCertificate(rawURL string, verifyChain bool) (*CertificateDetails, err
 		IsValid:      true,
 	}
 
-	err = cert.VerifyHostname(host)
-	if err != nil {
+	for _, chainCert := range peerCertificates {
+		details.Chain = append(details.Chain, DescribeCertificate(chainCert))
+		details.WeakReasons = append(details.WeakReasons, WeakReasons(chainCert)...)
+	}
+
+	details.TLSVersion = tls.VersionName(state.Version)
+	details.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+	if opts.TLSPolicy != nil {
+		details.PolicyViolations = opts.TLSPolicy.Violations(state)
+		if opts.TLSPolicy.ProbeLegacy {
+			details.PolicyViolations = append(details.PolicyViolations,
+				opts.TLSPolicy.ProbeLegacyVersions(scheme, address, host, opts.Dial, opts.Timeout)...)
+		}
+	}
+
+	var issuer *x509.Certificate
+	if len(peerCertificates) > 1 {
+		issuer = peerCertificates[1]
+	}
+	details.OCSPStapled = len(state.OCSPResponse) > 0
+	details.Revocation = checkRevocation(cert, issuer, state.OCSPResponse, opts.CheckRevocation, opts.Timeout)
+	if details.IsRevoked() {
 		details.IsValid = false
-		details.Error = fmt.Errorf("hostname verification failed: %w", err)
+		details.Error = fmt.Errorf("certificate was revoked at %s", details.Revocation.RevokedAt.Format(time.RFC3339))
 		return details, details.Error
 	}
 
-	if verifyChain {
-		roots, err := x509.SystemCertPool()
-		if err != nil {
+	if len(opts.Pins) > 0 {
+		details.PinMatch = MatchesPin(cert, opts.Pins)
+		if !details.PinMatch {
 			details.IsValid = false
-			details.Error = fmt.Errorf("failed to load system cert pool: %w", err)
+			details.Error = fmt.Errorf("certificate does not match any pinned fingerprint")
 			return details, details.Error
 		}
+	}
+
+	if err := cert.VerifyHostname(host); err != nil {
+		details.IsValid = false
+		details.Error = fmt.Errorf("hostname verification failed: %w", err)
+		return details, details.Error
+	}
+
+	for _, name := range opts.ExpectedDNSNames {
+		if cert.VerifyHostname(name) != nil {
+			details.MissingDNSNames = append(details.MissingDNSNames, name)
+		}
+	}
+	if len(details.MissingDNSNames) > 0 {
+		details.IsValid = false
+		details.Error = fmt.Errorf("certificate is not valid for expected names: %s", strings.Join(details.MissingDNSNames, ", "))
+		return details, details.Error
+	}
+
+	if verifyChain {
+		roots := opts.RootCAs
+		if roots == nil {
+			var err error
+			roots, err = x509.SystemCertPool()
+			if err != nil {
+				details.IsValid = false
+				details.Error = fmt.Errorf("failed to load system cert pool: %w", err)
+				return details, details.Error
+			}
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, intermediate := range peerCertificates[1:] {
+			intermediates.AddCert(intermediate)
+		}
 
 		opts := x509.VerifyOptions{
-			DNSName: host,
-			Roots:   roots,
+			DNSName:       host,
+			Roots:         roots,
+			Intermediates: intermediates,
 		}
-		_, err = cert.Verify(opts)
-		if err != nil {
+		if _, err := cert.Verify(opts); err != nil {
 			details.IsValid = false
 			details.Error = fmt.Errorf("certificate chain verification failed: %w", err)
 			return details, details.Error
@@ -108,9 +286,69 @@ func CheckCertificate(rawURL string, verifyChain bool) (*CertificateDetails, err
 	return details, nil
 }
 
+// DescribeCertificate extracts the chain details reported for a certificate
+func DescribeCertificate(cert *x509.Certificate) ChainCertificate {
+	keyType, keyBits := publicKeyInfo(cert)
+	fingerprint := sha256.Sum256(cert.Raw)
+
+	return ChainCertificate{
+		Subject:               cert.Subject.CommonName,
+		Issuer:                cert.Issuer.CommonName,
+		SerialNumber:          cert.SerialNumber.String(),
+		NotBefore:             cert.NotBefore,
+		NotAfter:              cert.NotAfter,
+		KeyType:               keyType,
+		KeyBits:               keyBits,
+		SignatureAlgorithm:    cert.SignatureAlgorithm.String(),
+		FingerprintSHA256:     hex.EncodeToString(fingerprint[:]),
+		SPKIFingerprintSHA256: SPKIFingerprint(cert),
+	}
+}
+
+// WeakReasons returns the reasons a certificate is considered weak: RSA keys
+// shorter than MinRSAKeyBits, DSA keys and MD5 or SHA-1 based signatures.
+// Signatures of self-signed certificates are ignored since roots are trusted
+// by their presence in the trust store rather than by their signature.
+func WeakReasons(cert *x509.Certificate) []string {
+	var reasons []string
+
+	keyType, keyBits := publicKeyInfo(cert)
+	switch {
+	case keyType == KeyTypeRSA && keyBits < MinRSAKeyBits:
+		reasons = append(reasons, fmt.Sprintf("%q uses a %d-bit RSA key", cert.Subject.CommonName, keyBits))
+	case cert.PublicKeyAlgorithm == x509.DSA:
+		reasons = append(reasons, fmt.Sprintf("%q uses a DSA key", cert.Subject.CommonName))
+	}
+
+	selfSigned := cert.CheckSignatureFrom(cert) == nil
+	if !selfSigned {
+		switch cert.SignatureAlgorithm {
+		case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+			reasons = append(reasons, fmt.Sprintf("%q is signed with %s", cert.Subject.CommonName, cert.SignatureAlgorithm))
+		}
+	}
+
+	return reasons
+}
+
+// publicKeyInfo returns the type and size in bits of the certificate's public key
+func publicKeyInfo(cert *x509.Certificate) (string, int) {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return KeyTypeRSA, key.N.BitLen()
+	case *ecdsa.PublicKey:
+		return KeyTypeECDSA, key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return KeyTypeEd25519, 256
+	default:
+		return KeyTypeUnknown, 0
+	}
+}
+
 // LogCertificateInfo logs information about an SSL certificate
 func LogCertificateInfo(logger *slog.Logger, url string, cert *CertificateDetails) {
 	expiryDays := time.Until(cert.NotAfter).Hours() / 24
+	chainExpiryDays := time.Until(cert.ChainNotAfter()).Hours() / 24
 	
 	if cert.IsValid {
 		logger.Info("Certificate is valid",
@@ -118,6 +356,11 @@ func LogCertificateInfo(logger *slog.Logger, url string, cert *CertificateDetail
 			slog.String("subject", cert.Subject),
 			slog.String("issuer", cert.Issuer),
 			slog.Float64("days_until_expiry", expiryDays),
+			slog.Float64("chain_days_until_expiry", chainExpiryDays),
+			slog.Int("chain_length", len(cert.Chain)),
+			slog.String("tls_version", cert.TLSVersion),
+			slog.String("cipher_suite", cert.CipherSuite),
+			slog.String("revocation_status", cert.Revocation.Status),
 			slog.Time("expires", cert.NotAfter),
 			slog.Time("valid_from", cert.NotBefore))
 	} else {
@@ -126,8 +369,36 @@ func LogCertificateInfo(logger *slog.Logger, url string, cert *CertificateDetail
 			slog.String("subject", cert.Subject),
 			slog.String("issuer", cert.Issuer),
 			slog.Float64("days_until_expiry", expiryDays),
+			slog.Float64("chain_days_until_expiry", chainExpiryDays),
+			slog.Int("chain_length", len(cert.Chain)),
+			slog.String("tls_version", cert.TLSVersion),
+			slog.String("cipher_suite", cert.CipherSuite),
+			slog.String("revocation_status", cert.Revocation.Status),
 			slog.Time("expires", cert.NotAfter),
 			slog.Time("valid_from", cert.NotBefore),
 			slog.Any("error", cert.Error))
 	}
+
+	if cert.Revocation.Error != nil {
+		logger.Warn("Certificate revocation status unknown",
+			slog.String("url", url),
+			slog.String("subject", cert.Subject),
+			slog.Bool("ocsp_stapled", cert.OCSPStapled),
+			slog.Any("error", cert.Revocation.Error))
+	}
+
+	if len(cert.PolicyViolations) > 0 {
+		logger.Warn("TLS policy violated",
+			slog.String("url", url),
+			slog.String("tls_version", cert.TLSVersion),
+			slog.String("cipher_suite", cert.CipherSuite),
+			slog.Any("violations", cert.PolicyViolations))
+	}
+
+	if cert.IsWeak() {
+		logger.Warn("Certificate chain uses weak cryptography",
+			slog.String("url", url),
+			slog.String("subject", cert.Subject),
+			slog.Any("reasons", cert.WeakReasons))
+	}
 }
\ No newline at end of file
@@ -1,6 +1,22 @@
 package certcheck
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -40,3 +56,332 @@ func TestCheckRealCertificate(t *testing.T) {
 		t.Errorf("Expected certificate to be valid, got invalid with error: %v", cert.Error)
 	}
 }
+
+// testCertificate is a certificate together with its private key
+type testCertificate struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+// newTestCertificate creates a certificate from template signed by parent, or self-signed when parent is nil
+func newTestCertificate(t *testing.T, template *x509.Certificate, key crypto.Signer, parent *testCertificate) *testCertificate {
+	t.Helper()
+
+	if template.SerialNumber == nil {
+		template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	}
+	if template.NotBefore.IsZero() {
+		template.NotBefore = time.Now().Add(-time.Hour)
+	}
+	if template.NotAfter.IsZero() {
+		template.NotAfter = time.Now().Add(90 * 24 * time.Hour)
+	}
+
+	signerCert, signerKey := template, key
+	if parent != nil {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, key.Public(), signerKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+
+	return &testCertificate{cert: cert, key: key}
+}
+
+// newTestKey generates an ECDSA P-256 key
+func newTestKey(t *testing.T) crypto.Signer {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	return key
+}
+
+// newTestChain creates a root CA, an intermediate CA expiring at intermediateNotAfter and a leaf for 127.0.0.1
+func newTestChain(t *testing.T, intermediateNotAfter time.Time) (root, intermediate, leaf *testCertificate) {
+	t.Helper()
+
+	root = newTestCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Test Root CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		NotAfter:              time.Now().Add(10 * 365 * 24 * time.Hour),
+	}, newTestKey(t), nil)
+
+	intermediate = newTestCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Test Intermediate CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		NotAfter:              intermediateNotAfter,
+	}, newTestKey(t), root)
+
+	leaf = newTestLeaf(t, intermediate, func(*x509.Certificate) {})
+
+	return root, intermediate, leaf
+}
+
+// newTestLeaf creates a leaf certificate for 127.0.0.1 issued by issuer, customized by configure
+func newTestLeaf(t *testing.T, issuer *testCertificate, configure func(*x509.Certificate)) *testCertificate {
+	t.Helper()
+
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	configure(template)
+
+	return newTestCertificate(t, template, newTestKey(t), issuer)
+}
+
+// newTestTLSServer starts an HTTPS server presenting the given chain, leaf first
+func newTestTLSServer(t *testing.T, chain ...*testCertificate) *httptest.Server {
+	t.Helper()
+
+	return newTestTLSServerWithConfig(t, func(*tls.Config) {}, chain...)
+}
+
+// newTestTLSCertificate bundles a chain, leaf first, into a certificate a TLS server can present
+func newTestTLSCertificate(chain ...*testCertificate) tls.Certificate {
+	tlsCert := tls.Certificate{PrivateKey: chain[0].key, Leaf: chain[0].cert}
+	for _, cert := range chain {
+		tlsCert.Certificate = append(tlsCert.Certificate, cert.cert.Raw)
+	}
+	return tlsCert
+}
+
+// newTestTLSServerWithConfig starts an HTTPS server presenting the given chain with extra TLS settings
+func newTestTLSServerWithConfig(t *testing.T, configure func(*tls.Config), chain ...*testCertificate) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{newTestTLSCertificate(chain...)}}
+	configure(server.TLS)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestCheckCertificate_Chain(t *testing.T) {
+	intermediateNotAfter := time.Now().Add(5 * 24 * time.Hour).Truncate(time.Second)
+	_, intermediate, leaf := newTestChain(t, intermediateNotAfter)
+	server := newTestTLSServer(t, leaf, intermediate)
+
+	details, err := CheckCertificate(server.URL, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(details.Chain) != 2 {
+		t.Fatalf("Expected 2 certificates in the chain, got %d", len(details.Chain))
+	}
+
+	if details.Chain[1].Subject != "Test Intermediate CA" {
+		t.Errorf("Expected intermediate subject 'Test Intermediate CA', got %q", details.Chain[1].Subject)
+	}
+	if details.Chain[1].Issuer != "Test Root CA" {
+		t.Errorf("Expected intermediate issuer 'Test Root CA', got %q", details.Chain[1].Issuer)
+	}
+
+	leafFingerprint := sha256.Sum256(leaf.cert.Raw)
+	if details.Chain[0].FingerprintSHA256 != hex.EncodeToString(leafFingerprint[:]) {
+		t.Errorf("Expected leaf fingerprint %x, got %s", leafFingerprint, details.Chain[0].FingerprintSHA256)
+	}
+
+	if details.Chain[0].KeyType != KeyTypeECDSA || details.Chain[0].KeyBits != 256 {
+		t.Errorf("Expected ECDSA 256-bit key, got %s %d-bit", details.Chain[0].KeyType, details.Chain[0].KeyBits)
+	}
+	if details.Chain[0].SignatureAlgorithm != x509.ECDSAWithSHA256.String() {
+		t.Errorf("Expected signature algorithm %s, got %s", x509.ECDSAWithSHA256, details.Chain[0].SignatureAlgorithm)
+	}
+
+	if !details.ChainNotAfter().Equal(intermediateNotAfter) {
+		t.Errorf("Expected chain expiry %v, got %v", intermediateNotAfter, details.ChainNotAfter())
+	}
+	if !details.NotAfter.After(details.ChainNotAfter()) {
+		t.Errorf("Expected leaf to expire after the intermediate")
+	}
+
+	if details.IsWeak() {
+		t.Errorf("Expected strong chain, got weak reasons %v", details.WeakReasons)
+	}
+}
+
+func TestCheckCertificate_WeakRSAKey(t *testing.T) {
+	_, intermediate, _ := newTestChain(t, time.Now().Add(365*24*time.Hour))
+
+	weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+
+	leaf := newTestCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, weakKey, intermediate)
+	server := newTestTLSServer(t, leaf, intermediate)
+
+	details, err := CheckCertificate(server.URL, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !details.IsWeak() {
+		t.Fatalf("Expected 1024-bit RSA key to be reported as weak")
+	}
+	if !details.IsValid {
+		t.Errorf("Expected weak certificate to still be valid, got error: %v", details.Error)
+	}
+	if details.Chain[0].KeyType != KeyTypeRSA || details.Chain[0].KeyBits != 1024 {
+		t.Errorf("Expected RSA 1024-bit key, got %s %d-bit", details.Chain[0].KeyType, details.Chain[0].KeyBits)
+	}
+}
+
+func TestWeakReasons_SHA1Signature(t *testing.T) {
+	_, intermediate, leaf := newTestChain(t, time.Now().Add(365*24*time.Hour))
+
+	sha1Leaf := *leaf.cert
+	sha1Leaf.SignatureAlgorithm = x509.SHA1WithRSA
+
+	reasons := WeakReasons(&sha1Leaf)
+	if len(reasons) != 1 || !strings.Contains(reasons[0], "SHA1-RSA") {
+		t.Errorf("Expected a single SHA-1 signature reason, got %v", reasons)
+	}
+
+	if reasons := WeakReasons(intermediate.cert); len(reasons) != 0 {
+		t.Errorf("Expected no weak reasons for the intermediate, got %v", reasons)
+	}
+}
+
+func TestCheckConnectionState(t *testing.T) {
+	root, intermediate, leaf := newTestChain(t, time.Now().Add(365*24*time.Hour))
+
+	var connections atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{newTestTLSCertificate(leaf, intermediate)}}
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	details, err := CheckConnectionState(server.URL, resp.TLS, Options{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if details.SerialNumber != leaf.cert.SerialNumber.String() || len(details.Chain) != 2 {
+		t.Errorf("Expected the details of the served chain, got %+v", details)
+	}
+	if got := connections.Load(); got != 1 {
+		t.Errorf("Expected the request's connection to be reused, got %d connections", got)
+	}
+
+	if _, err := CheckConnectionState(server.URL, nil, Options{}); err != nil {
+		t.Fatalf("Expected the fallback dial to succeed, got %v", err)
+	}
+	if got := connections.Load(); got != 2 {
+		t.Errorf("Expected a fallback connection without a connection state, got %d connections", got)
+	}
+
+	policy, _ := NewTLSPolicy("1.2", []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"}, false)
+	if _, err := CheckConnectionState(server.URL, resp.TLS, Options{TLSPolicy: policy}); err != nil {
+		t.Fatalf("Expected the fallback dial to succeed, got %v", err)
+	}
+	if got := connections.Load(); got != 3 {
+		t.Errorf("Expected a fallback connection for a cipher suite policy, got %d connections", got)
+	}
+}
+
+func TestCheckCertificateWithOptions_RootCAs(t *testing.T) {
+	root, intermediate, leaf := newTestChain(t, time.Now().Add(365*24*time.Hour))
+	server := newTestTLSServer(t, leaf, intermediate)
+
+	if _, err := CheckCertificateWithOptions(server.URL, Options{VerifyChain: true}); err == nil {
+		t.Errorf("Expected verification against the system trust store to fail")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+	details, err := CheckCertificateWithOptions(server.URL, Options{VerifyChain: true, RootCAs: roots})
+	if err != nil || !details.IsValid {
+		t.Errorf("Expected verification against the custom CA to succeed, got %v", err)
+	}
+}
+
+func TestCheckCertificateWithOptions_ServerName(t *testing.T) {
+	root, intermediate, _ := newTestChain(t, time.Now().Add(365*24*time.Hour))
+	leaf := newTestLeaf(t, intermediate, func(template *x509.Certificate) {
+		template.Subject = pkix.Name{CommonName: "www.example.com"}
+		template.IPAddresses = nil
+		template.DNSNames = []string{"www.example.com", "*.api.example.com"}
+	})
+
+	var serverNames []string
+	server := newTestTLSServerWithConfig(t, func(cfg *tls.Config) {
+		cfg.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			serverNames = append(serverNames, hello.ServerName)
+			return nil, nil
+		}
+	}, leaf, intermediate)
+
+	if _, err := CheckCertificate(server.URL, false); err == nil {
+		t.Errorf("Expected hostname verification of 127.0.0.1 to fail")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+	details, err := CheckCertificateWithOptions(server.URL, Options{
+		VerifyChain:      true,
+		RootCAs:          roots,
+		ServerName:       "www.example.com",
+		ExpectedDNSNames: []string{"www.example.com", "v1.api.example.com"},
+	})
+	if err != nil || !details.IsValid {
+		t.Fatalf("Expected the certificate to be valid for the server name, got %v", err)
+	}
+	if serverNames[len(serverNames)-1] != "www.example.com" {
+		t.Errorf("Expected SNI www.example.com, got %q", serverNames[len(serverNames)-1])
+	}
+
+	details, err = CheckCertificateWithOptions(server.URL, Options{
+		ServerName:       "www.example.com",
+		ExpectedDNSNames: []string{"example.com", "www.example.com", "a.b.api.example.com"},
+	})
+	if err == nil || details.IsValid {
+		t.Errorf("Expected uncovered names to fail the check")
+	}
+	if len(details.MissingDNSNames) != 2 || details.MissingDNSNames[0] != "example.com" || details.MissingDNSNames[1] != "a.b.api.example.com" {
+		t.Errorf("Expected example.com and a.b.api.example.com to be missing, got %v", details.MissingDNSNames)
+	}
+}
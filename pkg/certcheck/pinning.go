@@ -0,0 +1,49 @@
+package certcheck
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// NormalizeFingerprint lower-cases a hex SHA-256 fingerprint and strips colon separators
+func NormalizeFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(fingerprint), ":", ""))
+}
+
+// ValidateFingerprint checks that a pin is a hex encoded SHA-256 digest
+func ValidateFingerprint(fingerprint string) error {
+	normalized := NormalizeFingerprint(fingerprint)
+	if len(normalized) != sha256.Size*2 {
+		return fmt.Errorf("fingerprint %q must be %d hex characters", fingerprint, sha256.Size*2)
+	}
+	if _, err := hex.DecodeString(normalized); err != nil {
+		return fmt.Errorf("fingerprint %q is not hex encoded", fingerprint)
+	}
+	return nil
+}
+
+// SPKIFingerprint returns the hex encoded SHA-256 digest of the certificate's public key info
+func SPKIFingerprint(cert *x509.Certificate) string {
+	fingerprint := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return hex.EncodeToString(fingerprint[:])
+}
+
+// MatchesPin reports whether the certificate or its public key matches one of the pins.
+// Pinning the public key survives renewals that reuse the key, pinning the
+// certificate requires updating the pin on every renewal.
+func MatchesPin(cert *x509.Certificate, pins []string) bool {
+	certFingerprint := sha256.Sum256(cert.Raw)
+	certPin := hex.EncodeToString(certFingerprint[:])
+	spkiPin := SPKIFingerprint(cert)
+
+	for _, pin := range pins {
+		normalized := NormalizeFingerprint(pin)
+		if normalized == certPin || normalized == spkiPin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package certcheck
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchesPin(t *testing.T) {
+	_, _, leaf := newTestChain(t, time.Now().Add(365*24*time.Hour))
+
+	certFingerprint := sha256.Sum256(leaf.cert.Raw)
+	certPin := hex.EncodeToString(certFingerprint[:])
+
+	var colonPin []string
+	for i := 0; i < len(certPin); i += 2 {
+		colonPin = append(colonPin, strings.ToUpper(certPin[i:i+2]))
+	}
+
+	for name, pin := range map[string]string{
+		"certificate":       certPin,
+		"public key":        SPKIFingerprint(leaf.cert),
+		"colon upper-cased": strings.Join(colonPin, ":"),
+	} {
+		if err := ValidateFingerprint(pin); err != nil {
+			t.Errorf("Expected %s pin to be valid, got %v", name, err)
+		}
+		if !MatchesPin(leaf.cert, []string{pin}) {
+			t.Errorf("Expected %s pin to match", name)
+		}
+	}
+
+	if MatchesPin(leaf.cert, []string{strings.Repeat("0", 64)}) {
+		t.Errorf("Expected unrelated pin not to match")
+	}
+	if err := ValidateFingerprint("abc"); err == nil {
+		t.Errorf("Expected short fingerprint to be invalid")
+	}
+}
+
+func TestCheckCertificateWithOptions_PinMismatch(t *testing.T) {
+	_, intermediate, leaf := newTestChain(t, time.Now().Add(365*24*time.Hour))
+	server := newTestTLSServer(t, leaf, intermediate)
+
+	details, err := CheckCertificateWithOptions(server.URL, Options{Pins: []string{SPKIFingerprint(intermediate.cert)}})
+	if err == nil {
+		t.Fatalf("Expected pinning an intermediate key to fail the check")
+	}
+	if details == nil || details.IsValid || details.PinMatch {
+		t.Errorf("Expected invalid certificate without a pin match, got %+v", details)
+	}
+
+	details, err = CheckCertificateWithOptions(server.URL, Options{Pins: []string{SPKIFingerprint(leaf.cert)}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !details.PinMatch {
+		t.Errorf("Expected the leaf key pin to match")
+	}
+}
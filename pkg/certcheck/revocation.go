@@ -0,0 +1,176 @@
+package certcheck
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	RevocationGood      = "good"
+	RevocationRevoked   = "revoked"
+	RevocationUnknown   = "unknown"
+	RevocationUnchecked = "unchecked"
+
+	RevocationSourceStaple = "staple"
+	RevocationSourceOCSP   = "ocsp"
+	RevocationSourceCRL    = "crl"
+
+	ocspRequestContentType = "application/ocsp-request"
+	maxRevocationBodyBytes = 10 << 20
+)
+
+// RevocationResult describes the revocation status of a certificate and where it came from
+type RevocationResult struct {
+	Status    string
+	Source    string
+	RevokedAt time.Time
+	// Error explains why the status couldn't be determined
+	Error error
+}
+
+// checkRevocation determines the revocation status of a leaf, preferring the stapled OCSP
+// response and falling back to the OCSP responder and CRL when online checks are enabled
+func checkRevocation(leaf, issuer *x509.Certificate, staple []byte, online bool, timeout time.Duration) RevocationResult {
+	if len(staple) == 0 && !online {
+		return RevocationResult{Status: RevocationUnchecked}
+	}
+	if issuer == nil {
+		return RevocationResult{Status: RevocationUnknown, Error: fmt.Errorf("issuer certificate not presented, can't validate revocation responses")}
+	}
+
+	var stapleErr error
+	if len(staple) > 0 {
+		result, err := parseOCSPResponse(staple, leaf, issuer)
+		if err == nil {
+			result.Source = RevocationSourceStaple
+			return result
+		}
+		stapleErr = fmt.Errorf("invalid stapled OCSP response: %w", err)
+	}
+
+	if !online {
+		return RevocationResult{Status: RevocationUnknown, Error: stapleErr}
+	}
+
+	client := &http.Client{Timeout: timeout}
+
+	var ocspErr error
+	if len(leaf.OCSPServer) > 0 {
+		result, err := queryOCSP(client, leaf.OCSPServer[0], leaf, issuer)
+		if err == nil {
+			result.Source = RevocationSourceOCSP
+			return result
+		}
+		ocspErr = err
+	}
+
+	if len(leaf.CRLDistributionPoints) > 0 {
+		result, err := queryCRL(client, leaf.CRLDistributionPoints[0], leaf, issuer)
+		if err == nil {
+			result.Source = RevocationSourceCRL
+			return result
+		}
+		return RevocationResult{Status: RevocationUnknown, Error: err}
+	}
+
+	if ocspErr != nil {
+		return RevocationResult{Status: RevocationUnknown, Error: ocspErr}
+	}
+	return RevocationResult{Status: RevocationUnknown, Error: fmt.Errorf("certificate lists neither an OCSP responder nor a CRL distribution point")}
+}
+
+// parseOCSPResponse validates an OCSP response for the leaf against its issuer
+func parseOCSPResponse(raw []byte, leaf, issuer *x509.Certificate) (RevocationResult, error) {
+	response, err := ocsp.ParseResponseForCert(raw, leaf, issuer)
+	if err != nil {
+		return RevocationResult{}, err
+	}
+
+	now := time.Now()
+	if !response.NextUpdate.IsZero() && now.After(response.NextUpdate) {
+		return RevocationResult{}, fmt.Errorf("OCSP response expired at %s", response.NextUpdate.Format(time.RFC3339))
+	}
+	if response.ThisUpdate.After(now) {
+		return RevocationResult{}, fmt.Errorf("OCSP response is not valid before %s", response.ThisUpdate.Format(time.RFC3339))
+	}
+
+	switch response.Status {
+	case ocsp.Good:
+		return RevocationResult{Status: RevocationGood}, nil
+	case ocsp.Revoked:
+		return RevocationResult{Status: RevocationRevoked, RevokedAt: response.RevokedAt}, nil
+	default:
+		return RevocationResult{Status: RevocationUnknown, Error: fmt.Errorf("OCSP responder doesn't know the certificate")}, nil
+	}
+}
+
+// queryOCSP asks the OCSP responder for the status of the leaf
+func queryOCSP(client *http.Client, server string, leaf, issuer *x509.Certificate) (RevocationResult, error) {
+	request, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return RevocationResult{}, fmt.Errorf("failed to create OCSP request: %w", err)
+	}
+
+	resp, err := client.Post(server, ocspRequestContentType, bytes.NewReader(request))
+	if err != nil {
+		return RevocationResult{}, fmt.Errorf("OCSP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return RevocationResult{}, fmt.Errorf("OCSP responder returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRevocationBodyBytes))
+	if err != nil {
+		return RevocationResult{}, fmt.Errorf("failed to read OCSP response: %w", err)
+	}
+
+	result, err := parseOCSPResponse(body, leaf, issuer)
+	if err != nil {
+		return RevocationResult{}, fmt.Errorf("invalid OCSP response: %w", err)
+	}
+	return result, nil
+}
+
+// queryCRL downloads the CRL of the issuer and looks the leaf up in it
+func queryCRL(client *http.Client, distributionPoint string, leaf, issuer *x509.Certificate) (RevocationResult, error) {
+	resp, err := client.Get(distributionPoint)
+	if err != nil {
+		return RevocationResult{}, fmt.Errorf("CRL download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return RevocationResult{}, fmt.Errorf("CRL distribution point returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRevocationBodyBytes))
+	if err != nil {
+		return RevocationResult{}, fmt.Errorf("failed to read CRL: %w", err)
+	}
+
+	crl, err := x509.ParseRevocationList(body)
+	if err != nil {
+		return RevocationResult{}, fmt.Errorf("invalid CRL: %w", err)
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return RevocationResult{}, fmt.Errorf("CRL is not signed by the issuer: %w", err)
+	}
+	if !crl.NextUpdate.IsZero() && time.Now().After(crl.NextUpdate) {
+		return RevocationResult{}, fmt.Errorf("CRL expired at %s", crl.NextUpdate.Format(time.RFC3339))
+	}
+
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(leaf.SerialNumber) == 0 {
+			return RevocationResult{Status: RevocationRevoked, RevokedAt: entry.RevocationTime}, nil
+		}
+	}
+	return RevocationResult{Status: RevocationGood}, nil
+}
@@ -0,0 +1,189 @@
+package certcheck
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// newTestOCSPResponse creates an OCSP response for leaf signed directly by its issuer
+func newTestOCSPResponse(t *testing.T, leaf, issuer *testCertificate, status int, revokedAt time.Time) []byte {
+	t.Helper()
+
+	response, err := ocsp.CreateResponse(issuer.cert, issuer.cert, ocsp.Response{
+		Status:       status,
+		SerialNumber: leaf.cert.SerialNumber,
+		ThisUpdate:   time.Now().Add(-time.Hour),
+		NextUpdate:   time.Now().Add(time.Hour),
+		RevokedAt:    revokedAt,
+	}, issuer.key)
+	if err != nil {
+		t.Fatalf("Failed to create OCSP response: %v", err)
+	}
+	return response
+}
+
+// newTestOCSPResponder starts an OCSP responder answering every request with status
+func newTestOCSPResponder(t *testing.T, issuer *testCertificate, status int) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		request, err := ocsp.ParseRequest(body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		response, err := ocsp.CreateResponse(issuer.cert, issuer.cert, ocsp.Response{
+			Status:       status,
+			SerialNumber: request.SerialNumber,
+			ThisUpdate:   time.Now().Add(-time.Hour),
+			NextUpdate:   time.Now().Add(time.Hour),
+			RevokedAt:    time.Now().Add(-time.Minute),
+		}, issuer.key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(response)
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
+
+func TestCheckCertificate_StapledOCSP(t *testing.T) {
+	for name, tc := range map[string]struct {
+		status   int
+		expected string
+		valid    bool
+	}{
+		"good":    {status: ocsp.Good, expected: RevocationGood, valid: true},
+		"revoked": {status: ocsp.Revoked, expected: RevocationRevoked, valid: false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, intermediate, leaf := newTestChain(t, time.Now().Add(365*24*time.Hour))
+			staple := newTestOCSPResponse(t, leaf, intermediate, tc.status, time.Now().Add(-time.Minute))
+			server := newTestTLSServerWithConfig(t, func(cfg *tls.Config) {
+				cfg.Certificates[0].OCSPStaple = staple
+			}, leaf, intermediate)
+
+			details, _ := CheckCertificate(server.URL, false)
+			if details == nil {
+				t.Fatalf("Expected certificate details")
+			}
+
+			if !details.OCSPStapled {
+				t.Errorf("Expected the OCSP response to be reported as stapled")
+			}
+			if details.Revocation.Status != tc.expected || details.Revocation.Source != RevocationSourceStaple {
+				t.Errorf("Expected %s status from the staple, got %s from %s", tc.expected, details.Revocation.Status, details.Revocation.Source)
+			}
+			if details.IsValid != tc.valid {
+				t.Errorf("Expected valid to be %v, got %v", tc.valid, details.IsValid)
+			}
+		})
+	}
+}
+
+func TestCheckCertificate_StapledOCSPFromAnotherIssuer(t *testing.T) {
+	_, intermediate, leaf := newTestChain(t, time.Now().Add(365*24*time.Hour))
+	_, otherIntermediate, _ := newTestChain(t, time.Now().Add(365*24*time.Hour))
+	staple := newTestOCSPResponse(t, leaf, otherIntermediate, ocsp.Revoked, time.Now())
+	server := newTestTLSServerWithConfig(t, func(cfg *tls.Config) {
+		cfg.Certificates[0].OCSPStaple = staple
+	}, leaf, intermediate)
+
+	details, err := CheckCertificate(server.URL, false)
+	if err != nil {
+		t.Fatalf("Expected an unverifiable staple not to fail the check, got %v", err)
+	}
+	if details.Revocation.Status != RevocationUnknown || details.Revocation.Error == nil {
+		t.Errorf("Expected unknown status with an error, got %+v", details.Revocation)
+	}
+}
+
+func TestCheckCertificateWithOptions_OCSPResponder(t *testing.T) {
+	_, intermediate, _ := newTestChain(t, time.Now().Add(365*24*time.Hour))
+	responder := newTestOCSPResponder(t, intermediate, ocsp.Revoked)
+	leaf := newTestLeaf(t, intermediate, func(template *x509.Certificate) {
+		template.OCSPServer = []string{responder}
+	})
+	server := newTestTLSServer(t, leaf, intermediate)
+
+	details, err := CheckCertificate(server.URL, false)
+	if err != nil || details.Revocation.Status != RevocationUnchecked {
+		t.Errorf("Expected revocation to stay unchecked without online checks, got %+v (%v)", details.Revocation, err)
+	}
+
+	details, err = CheckCertificateWithOptions(server.URL, Options{CheckRevocation: true, Timeout: 5 * time.Second})
+	if err == nil {
+		t.Fatalf("Expected a revoked certificate to fail the check")
+	}
+	if !details.IsRevoked() || details.Revocation.Source != RevocationSourceOCSP {
+		t.Errorf("Expected revoked status from the OCSP responder, got %+v", details.Revocation)
+	}
+}
+
+func TestCheckCertificateWithOptions_CRL(t *testing.T) {
+	_, intermediate, _ := newTestChain(t, time.Now().Add(365*24*time.Hour))
+
+	var crl []byte
+	crlServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(crl)
+	}))
+	t.Cleanup(crlServer.Close)
+
+	leaf := newTestLeaf(t, intermediate, func(template *x509.Certificate) {
+		template.CRLDistributionPoints = []string{crlServer.URL}
+	})
+	server := newTestTLSServer(t, leaf, intermediate)
+
+	var err error
+	crl, err = x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now().Add(-time.Hour),
+		NextUpdate: time.Now().Add(time.Hour),
+	}, intermediate.cert, intermediate.key)
+	if err != nil {
+		t.Fatalf("Failed to create CRL: %v", err)
+	}
+
+	details, err := CheckCertificateWithOptions(server.URL, Options{CheckRevocation: true, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if details.Revocation.Status != RevocationGood || details.Revocation.Source != RevocationSourceCRL {
+		t.Errorf("Expected good status from the CRL, got %+v", details.Revocation)
+	}
+
+	crl, err = x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(2),
+		ThisUpdate: time.Now().Add(-time.Hour),
+		NextUpdate: time.Now().Add(time.Hour),
+		RevokedCertificateEntries: []x509.RevocationListEntry{
+			{SerialNumber: leaf.cert.SerialNumber, RevocationTime: time.Now().Add(-time.Minute)},
+		},
+	}, intermediate.cert, intermediate.key)
+	if err != nil {
+		t.Fatalf("Failed to create CRL: %v", err)
+	}
+
+	details, _ = CheckCertificateWithOptions(server.URL, Options{CheckRevocation: true, Timeout: 5 * time.Second})
+	if !details.IsRevoked() {
+		t.Errorf("Expected the leaf to be revoked by the CRL, got %+v", details.Revocation)
+	}
+}
@@ -0,0 +1,330 @@
+package certcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	SchemeTLS      = "tls"
+	SchemeSMTP     = "smtp"
+	SchemeIMAP     = "imap"
+	SchemeLDAP     = "ldap"
+	SchemePostgres = "postgres"
+
+	// starttlsClientName is the name announced to SMTP servers in EHLO
+	starttlsClientName = "url-datadog-monitor"
+	// ldapStartTLSOID identifies the LDAP StartTLS extended operation (RFC 4511)
+	ldapStartTLSOID = "1.3.6.1.4.1.1466.20037"
+	// postgresSSLRequestCode is the protocol code of the PostgreSQL SSLRequest message
+	postgresSSLRequestCode = 80877103
+	// maxLDAPResponseBytes bounds the size of the StartTLS response read from the server
+	maxLDAPResponseBytes = 64 << 10
+)
+
+// defaultPorts maps the schemes supporting certificate checks to their default port.
+// Direct TLS has no well-known port, so tls:// URLs must specify one.
+var defaultPorts = map[string]string{
+	SchemeHTTPS:    DefaultHTTPSPort,
+	SchemeTLS:      "",
+	SchemeSMTP:     "25",
+	SchemeIMAP:     "143",
+	SchemeLDAP:     "389",
+	SchemePostgres: "5432",
+}
+
+// IsTLSURL reports whether the URL uses a scheme whose certificate can be checked
+func IsTLSURL(rawURL string) bool {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	_, ok := defaultPorts[strings.ToLower(parsedURL.Scheme)]
+	return ok
+}
+
+// Handshake connects to a non-HTTP endpoint, negotiates STARTTLS where the scheme
+// requires it and completes a TLS handshake without verifying the certificate.
+// Only the ServerName, Dial and Timeout options are used. The returned connection
+// state can be inspected with CheckConnectionState.
+func Handshake(rawURL string, opts Options) (*tls.ConnectionState, error) {
+	scheme, host, address, err := tlsEndpoint(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if opts.ServerName != "" {
+		host = opts.ServerName
+	}
+
+	conn, err := dialTLS(scheme, address, &tls.Config{ServerName: host, InsecureSkipVerify: true}, opts.Dial, opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	state := conn.ConnectionState()
+	return &state, nil
+}
+
+// tlsEndpoint returns the lower-cased scheme, host and dial address of a URL
+// using one of the schemes supporting certificate checks
+func tlsEndpoint(rawURL string) (scheme, host, address string, err error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to parse URL: %w", err)
+	}
+
+	scheme = strings.ToLower(parsedURL.Scheme)
+	port, ok := defaultPorts[scheme]
+	if !ok {
+		return "", "", "", fmt.Errorf("unsupported scheme %q, cannot check certificate", parsedURL.Scheme)
+	}
+	if parsedURL.Port() != "" {
+		port = parsedURL.Port()
+	}
+	if port == "" {
+		return "", "", "", fmt.Errorf("%s URLs must specify a port", scheme)
+	}
+
+	host = parsedURL.Hostname()
+	return scheme, host, net.JoinHostPort(host, port), nil
+}
+
+// dialTLS connects to the address, upgrades the connection with STARTTLS when the
+// scheme is a plaintext protocol and completes the TLS handshake. The timeout
+// bounds the whole exchange, no limit when zero.
+func dialTLS(scheme, address string, config *tls.Config, dial DialFunc, timeout time.Duration) (*tls.Conn, error) {
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: timeout}).DialContext
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	rawConn, err := dial(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("TLS connection failed: %w", err)
+	}
+
+	if timeout > 0 {
+		_ = rawConn.SetDeadline(time.Now().Add(timeout))
+	}
+
+	if err := negotiateSTARTTLS(rawConn, scheme); err != nil {
+		rawConn.Close()
+		return nil, fmt.Errorf("STARTTLS negotiation failed: %w", err)
+	}
+
+	conn := tls.Client(rawConn, config)
+	if err := conn.Handshake(); err != nil {
+		rawConn.Close()
+		return nil, fmt.Errorf("TLS connection failed: %w", err)
+	}
+
+	_ = rawConn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// negotiateSTARTTLS asks the server to switch a plaintext connection to TLS.
+// It returns without doing anything for schemes that start with a handshake.
+func negotiateSTARTTLS(conn net.Conn, scheme string) error {
+	switch scheme {
+	case SchemeSMTP:
+		return startSMTP(conn)
+	case SchemeIMAP:
+		return startIMAP(conn)
+	case SchemeLDAP:
+		return startLDAP(conn)
+	case SchemePostgres:
+		return startPostgres(conn)
+	default:
+		return nil
+	}
+}
+
+// startSMTP negotiates STARTTLS as described in RFC 3207
+func startSMTP(conn net.Conn) error {
+	text := textproto.NewConn(conn)
+
+	if _, _, err := text.ReadResponse(220); err != nil {
+		return fmt.Errorf("unexpected SMTP greeting: %w", err)
+	}
+
+	if err := text.PrintfLine("EHLO %s", starttlsClientName); err != nil {
+		return err
+	}
+	_, extensions, err := text.ReadResponse(250)
+	if err != nil {
+		return fmt.Errorf("EHLO rejected: %w", err)
+	}
+	if !hasSMTPExtension(extensions, "STARTTLS") {
+		return fmt.Errorf("server doesn't advertise STARTTLS")
+	}
+
+	if err := text.PrintfLine("STARTTLS"); err != nil {
+		return err
+	}
+	if _, _, err := text.ReadResponse(220); err != nil {
+		return fmt.Errorf("STARTTLS rejected: %w", err)
+	}
+	return nil
+}
+
+// hasSMTPExtension reports whether an EHLO response lists the extension
+func hasSMTPExtension(extensions, name string) bool {
+	for _, line := range strings.Split(extensions, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && strings.EqualFold(fields[0], name) {
+			return true
+		}
+	}
+	return false
+}
+
+// startIMAP negotiates STARTTLS as described in RFC 3501
+func startIMAP(conn net.Conn) error {
+	text := textproto.NewConn(conn)
+
+	greeting, err := text.ReadLine()
+	if err != nil {
+		return fmt.Errorf("failed to read IMAP greeting: %w", err)
+	}
+	if !strings.HasPrefix(greeting, "* OK") {
+		return fmt.Errorf("unexpected IMAP greeting %q", greeting)
+	}
+
+	const tag = "a1"
+	if err := text.PrintfLine("%s STARTTLS", tag); err != nil {
+		return err
+	}
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return fmt.Errorf("failed to read STARTTLS response: %w", err)
+		}
+		if !strings.HasPrefix(line, tag+" ") {
+			// Untagged responses may precede the tagged completion
+			continue
+		}
+		if !strings.HasPrefix(line, tag+" OK") {
+			return fmt.Errorf("STARTTLS rejected: %q", line)
+		}
+		return nil
+	}
+}
+
+// ldapMessage is an LDAPMessage envelope carrying a single protocol operation
+type ldapMessage struct {
+	MessageID int
+	Operation asn1.RawValue
+}
+
+// startLDAP sends the StartTLS extended operation described in RFC 4511
+func startLDAP(conn net.Conn) error {
+	// ExtendedRequest ::= [APPLICATION 23] SEQUENCE { requestName [0] LDAPOID }
+	requestName, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: []byte(ldapStartTLSOID)})
+	if err != nil {
+		return err
+	}
+	request, err := asn1.Marshal(ldapMessage{
+		MessageID: 1,
+		Operation: asn1.RawValue{Class: asn1.ClassApplication, Tag: 23, IsCompound: true, Bytes: requestName},
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	raw, err := readBERElement(conn)
+	if err != nil {
+		return fmt.Errorf("failed to read StartTLS response: %w", err)
+	}
+
+	var response ldapMessage
+	if _, err := asn1.Unmarshal(raw, &response); err != nil {
+		return fmt.Errorf("invalid StartTLS response: %w", err)
+	}
+	// ExtendedResponse ::= [APPLICATION 24] SEQUENCE { resultCode ENUMERATED, ... }
+	if response.Operation.Class != asn1.ClassApplication || response.Operation.Tag != 24 {
+		return fmt.Errorf("unexpected LDAP operation %d in StartTLS response", response.Operation.Tag)
+	}
+
+	var resultCode asn1.Enumerated
+	if _, err := asn1.Unmarshal(response.Operation.Bytes, &resultCode); err != nil {
+		return fmt.Errorf("invalid StartTLS result: %w", err)
+	}
+	if resultCode != 0 {
+		return fmt.Errorf("StartTLS rejected with LDAP result code %d", resultCode)
+	}
+	return nil
+}
+
+// readBERElement reads a single BER encoded element, header included
+func readBERElement(r io.Reader) ([]byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	length := int(header[1])
+	if length&0x80 != 0 {
+		lengthBytes := length & 0x7f
+		if lengthBytes == 0 || lengthBytes > 4 {
+			return nil, fmt.Errorf("unsupported BER length encoding")
+		}
+		encoded := make([]byte, lengthBytes)
+		if _, err := io.ReadFull(r, encoded); err != nil {
+			return nil, err
+		}
+		header = append(header, encoded...)
+
+		length = 0
+		for _, b := range encoded {
+			length = length<<8 | int(b)
+		}
+	}
+	if length > maxLDAPResponseBytes {
+		return nil, fmt.Errorf("response of %d bytes is too large", length)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return append(header, body...), nil
+}
+
+// startPostgres sends an SSLRequest as described in the PostgreSQL frontend/backend protocol
+func startPostgres(conn net.Conn) error {
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], postgresSSLRequestCode)
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	answer := make([]byte, 1)
+	if _, err := io.ReadFull(conn, answer); err != nil {
+		return fmt.Errorf("failed to read SSLRequest answer: %w", err)
+	}
+	if answer[0] != 'S' {
+		return fmt.Errorf("server doesn't accept SSL connections")
+	}
+	return nil
+}
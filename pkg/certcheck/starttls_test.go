@@ -0,0 +1,210 @@
+package certcheck
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestSTARTTLSServer starts a TCP server that runs negotiate on every connection
+// and then completes a TLS handshake presenting the given chain. It returns the address.
+func newTestSTARTTLSServer(t *testing.T, negotiate func(conn net.Conn, r *bufio.Reader) error, chain ...*testCertificate) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	config := &tls.Config{Certificates: []tls.Certificate{newTestTLSCertificate(chain...)}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+				if err := negotiate(conn, bufio.NewReader(conn)); err != nil {
+					return
+				}
+				_ = tls.Server(conn, config).Handshake()
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// smtpServer answers EHLO with the given extensions and accepts STARTTLS
+func smtpServer(extensions ...string) func(net.Conn, *bufio.Reader) error {
+	return func(conn net.Conn, r *bufio.Reader) error {
+		fmt.Fprint(conn, "220 mail.test ESMTP\r\n")
+		if _, err := r.ReadString('\n'); err != nil {
+			return err
+		}
+
+		response := "250-mail.test\r\n"
+		for _, extension := range extensions {
+			response += "250-" + extension + "\r\n"
+		}
+		fmt.Fprint(conn, response+"250 8BITMIME\r\n")
+
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(line) != "STARTTLS" {
+			fmt.Fprint(conn, "502 Command not implemented\r\n")
+			return fmt.Errorf("unexpected command %q", line)
+		}
+		fmt.Fprint(conn, "220 Ready to start TLS\r\n")
+		return nil
+	}
+}
+
+func imapServer(conn net.Conn, r *bufio.Reader) error {
+	fmt.Fprint(conn, "* OK IMAP4rev1 ready\r\n")
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+
+	tag, command, _ := strings.Cut(strings.TrimSpace(line), " ")
+	if command != "STARTTLS" {
+		fmt.Fprintf(conn, "%s BAD unexpected command\r\n", tag)
+		return fmt.Errorf("unexpected command %q", line)
+	}
+	fmt.Fprintf(conn, "* CAPABILITY IMAP4rev1\r\n%s OK Begin TLS negotiation now\r\n", tag)
+	return nil
+}
+
+// ldapServer answers the StartTLS extended request with the given result code
+func ldapServer(resultCode int) func(net.Conn, *bufio.Reader) error {
+	return func(conn net.Conn, r *bufio.Reader) error {
+		request, err := readBERElement(r)
+		if err != nil {
+			return err
+		}
+		if !bytes.Contains(request, []byte(ldapStartTLSOID)) {
+			return fmt.Errorf("not a StartTLS request")
+		}
+
+		result, _ := asn1.Marshal(asn1.Enumerated(resultCode))
+		// Empty matchedDN and diagnosticMessage
+		result = append(result, 0x04, 0x00, 0x04, 0x00)
+		response, _ := asn1.Marshal(ldapMessage{
+			MessageID: 1,
+			Operation: asn1.RawValue{Class: asn1.ClassApplication, Tag: 24, IsCompound: true, Bytes: result},
+		})
+		_, err = conn.Write(response)
+		if resultCode != 0 {
+			return fmt.Errorf("StartTLS refused")
+		}
+		return err
+	}
+}
+
+// postgresServer answers the SSLRequest with the given byte
+func postgresServer(answer byte) func(net.Conn, *bufio.Reader) error {
+	return func(conn net.Conn, r *bufio.Reader) error {
+		request := make([]byte, 8)
+		if _, err := io.ReadFull(r, request); err != nil {
+			return err
+		}
+		if binary.BigEndian.Uint32(request[4:]) != postgresSSLRequestCode {
+			return fmt.Errorf("not an SSLRequest")
+		}
+
+		_, err := conn.Write([]byte{answer})
+		if answer != 'S' {
+			return fmt.Errorf("SSL refused")
+		}
+		return err
+	}
+}
+
+func TestCheckCertificate_STARTTLS(t *testing.T) {
+	for name, tc := range map[string]struct {
+		scheme    string
+		negotiate func(net.Conn, *bufio.Reader) error
+		wantErr   bool
+	}{
+		"direct TLS":            {scheme: SchemeTLS, negotiate: func(net.Conn, *bufio.Reader) error { return nil }},
+		"SMTP":                  {scheme: SchemeSMTP, negotiate: smtpServer("PIPELINING", "STARTTLS")},
+		"SMTP without STARTTLS": {scheme: SchemeSMTP, negotiate: smtpServer("PIPELINING"), wantErr: true},
+		"IMAP":                  {scheme: SchemeIMAP, negotiate: imapServer},
+		"LDAP":                  {scheme: SchemeLDAP, negotiate: ldapServer(0)},
+		"LDAP refused":          {scheme: SchemeLDAP, negotiate: ldapServer(2), wantErr: true},
+		"Postgres":              {scheme: SchemePostgres, negotiate: postgresServer('S')},
+		"Postgres without SSL":  {scheme: SchemePostgres, negotiate: postgresServer('N'), wantErr: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, intermediate, leaf := newTestChain(t, time.Now().Add(365*24*time.Hour))
+			address := newTestSTARTTLSServer(t, tc.negotiate, leaf, intermediate)
+			rawURL := tc.scheme + "://" + address
+
+			details, err := CheckCertificateWithOptions(rawURL, Options{Timeout: 5 * time.Second})
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Expected STARTTLS negotiation to fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if !details.IsValid || details.SerialNumber != leaf.cert.SerialNumber.String() {
+				t.Errorf("Expected the valid leaf certificate, got %+v", details)
+			}
+			if len(details.Chain) != 2 {
+				t.Errorf("Expected 2 certificates in the chain, got %d", len(details.Chain))
+			}
+
+			state, err := Handshake(rawURL, Options{Timeout: 5 * time.Second})
+			if err != nil {
+				t.Fatalf("Expected handshake to succeed, got %v", err)
+			}
+			if len(state.PeerCertificates) != 2 {
+				t.Errorf("Expected the handshake to return the presented chain, got %d certificates", len(state.PeerCertificates))
+			}
+		})
+	}
+}
+
+func TestCheckCertificate_TLSRequiresPort(t *testing.T) {
+	if _, err := CheckCertificate("tls://127.0.0.1", false); err == nil || !strings.Contains(err.Error(), "port") {
+		t.Errorf("Expected a missing port error, got %v", err)
+	}
+}
+
+func TestIsTLSURL(t *testing.T) {
+	for rawURL, expected := range map[string]bool{
+		"https://example.com":           true,
+		"HTTPS://example.com":           true,
+		"tls://example.com:993":         true,
+		"smtp://mail.example.com":       true,
+		"imap://mail.example.com":       true,
+		"ldap://ldap.example.com":       true,
+		"postgres://db.example.com":     true,
+		"http://example.com":            false,
+		"ftp://example.com":             false,
+		"://missing-scheme.example.com": false,
+	} {
+		if got := IsTLSURL(rawURL); got != expected {
+			t.Errorf("Expected IsTLSURL(%q) to be %v, got %v", rawURL, expected, got)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package certcheck
+
+import (
+	"crypto/tls"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// tlsVersions maps the version names accepted in policies to their protocol values
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSPolicy restricts the TLS versions and cipher suites a server may negotiate
+type TLSPolicy struct {
+	// MinVersion is the lowest acceptable protocol version, any version when zero
+	MinVersion uint16
+	// CipherSuites lists the acceptable cipher suites. When empty, every suite
+	// except the ones Go considers insecure is acceptable
+	CipherSuites []uint16
+	// ProbeLegacy makes the check open extra connections offering only the
+	// versions below MinVersion, to detect whether the server still accepts them
+	ProbeLegacy bool
+}
+
+// ParseTLSVersion converts a version name such as "1.2" into its protocol value
+func ParseTLSVersion(name string) (uint16, error) {
+	version, ok := tlsVersions[strings.TrimSpace(strings.TrimPrefix(strings.ToUpper(name), "TLS"))]
+	if !ok {
+		return 0, fmt.Errorf("unknown TLS version %q, expected one of 1.0, 1.1, 1.2 or 1.3", name)
+	}
+	return version, nil
+}
+
+// ParseCipherSuites converts IANA cipher suite names into their identifiers
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	known := make(map[string]uint16)
+	for _, suite := range allCipherSuiteInfos() {
+		known[suite.Name] = suite.ID
+	}
+
+	var ids []uint16
+	for _, name := range names {
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// NewTLSPolicy builds a TLSPolicy from a version name and cipher suite names
+func NewTLSPolicy(minVersion string, cipherSuites []string, probeLegacy bool) (*TLSPolicy, error) {
+	policy := &TLSPolicy{ProbeLegacy: probeLegacy}
+
+	if minVersion != "" {
+		version, err := ParseTLSVersion(minVersion)
+		if err != nil {
+			return nil, err
+		}
+		policy.MinVersion = version
+	}
+
+	suites, err := ParseCipherSuites(cipherSuites)
+	if err != nil {
+		return nil, err
+	}
+	policy.CipherSuites = suites
+
+	return policy, nil
+}
+
+// Violations returns how a negotiated connection breaks the policy
+func (p *TLSPolicy) Violations(state tls.ConnectionState) []string {
+	var violations []string
+
+	if p.MinVersion != 0 && state.Version < p.MinVersion {
+		violations = append(violations, fmt.Sprintf("negotiated %s, policy requires at least %s",
+			tls.VersionName(state.Version), tls.VersionName(p.MinVersion)))
+	}
+
+	cipherSuite := tls.CipherSuiteName(state.CipherSuite)
+	if len(p.CipherSuites) > 0 {
+		if !slices.Contains(p.CipherSuites, state.CipherSuite) {
+			violations = append(violations, fmt.Sprintf("negotiated cipher suite %s is not allowed by policy", cipherSuite))
+		}
+	} else if isInsecureCipherSuite(state.CipherSuite) {
+		violations = append(violations, fmt.Sprintf("negotiated insecure cipher suite %s", cipherSuite))
+	}
+
+	return violations
+}
+
+// offersEverySuite reports whether checking the policy needs a handshake offering every
+// version and cipher suite Go implements, so the server's own preference gets negotiated
+func (p *TLSPolicy) offersEverySuite() bool {
+	return p != nil && (len(p.CipherSuites) > 0 || p.MinVersion < tls.VersionTLS12)
+}
+
+// ProbeLegacyVersions connects once for every version below the policy minimum
+// and returns a violation for each version the server accepts. The scheme selects
+// the STARTTLS negotiation preceding every handshake, if any, and dial connects
+// to the address instead of a plain net.Dialer when set.
+func (p *TLSPolicy) ProbeLegacyVersions(scheme, address, serverName string, dial DialFunc, timeout time.Duration) []string {
+	var violations []string
+
+	for _, version := range []uint16{tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12} {
+		if version >= p.MinVersion {
+			break
+		}
+		if acceptsVersion(scheme, address, serverName, version, dial, timeout) {
+			violations = append(violations, fmt.Sprintf("server accepts %s", tls.VersionName(version)))
+		}
+	}
+
+	return violations
+}
+
+// acceptsVersion reports whether a handshake restricted to a single version succeeds
+func acceptsVersion(scheme, address, serverName string, version uint16, dial DialFunc, timeout time.Duration) bool {
+	var cipherSuites []uint16
+	for _, suite := range allCipherSuiteInfos() {
+		if slices.Contains(suite.SupportedVersions, version) {
+			cipherSuites = append(cipherSuites, suite.ID)
+		}
+	}
+
+	conn, err := dialTLS(scheme, address, &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+		MinVersion:         version,
+		MaxVersion:         version,
+		CipherSuites:       cipherSuites,
+	}, dial, timeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// allCipherSuiteInfos returns every cipher suite implemented by Go, insecure ones included
+func allCipherSuiteInfos() []*tls.CipherSuite {
+	return append(tls.CipherSuites(), tls.InsecureCipherSuites()...)
+}
+
+// allCipherSuites returns the identifiers of every cipher suite implemented by Go
+func allCipherSuites() []uint16 {
+	var ids []uint16
+	for _, suite := range allCipherSuiteInfos() {
+		ids = append(ids, suite.ID)
+	}
+	return ids
+}
+
+// isInsecureCipherSuite reports whether Go lists the cipher suite as insecure
+func isInsecureCipherSuite(id uint16) bool {
+	for _, suite := range tls.InsecureCipherSuites() {
+		if suite.ID == id {
+			return true
+		}
+	}
+	return false
+}
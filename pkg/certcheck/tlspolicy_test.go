@@ -0,0 +1,101 @@
+package certcheck
+
+import (
+	"crypto/tls"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTLSVersion(t *testing.T) {
+	for name, expected := range map[string]uint16{"1.0": tls.VersionTLS10, "TLS1.2": tls.VersionTLS12, "tls 1.3": tls.VersionTLS13} {
+		version, err := ParseTLSVersion(name)
+		if err != nil {
+			t.Errorf("Expected %q to parse, got %v", name, err)
+		}
+		if version != expected {
+			t.Errorf("Expected %q to parse to %x, got %x", name, expected, version)
+		}
+	}
+
+	if _, err := ParseTLSVersion("2.0"); err == nil {
+		t.Errorf("Expected an error for an unknown version")
+	}
+}
+
+func TestTLSPolicy_Violations(t *testing.T) {
+	policy, err := NewTLSPolicy("1.2", nil, false)
+	if err != nil {
+		t.Fatalf("Failed to build policy: %v", err)
+	}
+
+	violations := policy.Violations(tls.ConnectionState{Version: tls.VersionTLS11, CipherSuite: tls.TLS_RSA_WITH_RC4_128_SHA})
+	if len(violations) != 2 {
+		t.Fatalf("Expected version and insecure cipher violations, got %v", violations)
+	}
+
+	violations = policy.Violations(tls.ConnectionState{Version: tls.VersionTLS13, CipherSuite: tls.TLS_AES_128_GCM_SHA256})
+	if len(violations) != 0 {
+		t.Errorf("Expected no violations, got %v", violations)
+	}
+
+	policy, err = NewTLSPolicy("", []string{"TLS_AES_256_GCM_SHA384"}, false)
+	if err != nil {
+		t.Fatalf("Failed to build policy: %v", err)
+	}
+	violations = policy.Violations(tls.ConnectionState{Version: tls.VersionTLS13, CipherSuite: tls.TLS_AES_128_GCM_SHA256})
+	if len(violations) != 1 || !strings.Contains(violations[0], "TLS_AES_128_GCM_SHA256") {
+		t.Errorf("Expected a disallowed cipher violation, got %v", violations)
+	}
+}
+
+func TestCheckCertificateWithOptions_NegotiatedVersion(t *testing.T) {
+	_, intermediate, leaf := newTestChain(t, time.Now().Add(365*24*time.Hour))
+	server := newTestTLSServerWithConfig(t, func(cfg *tls.Config) {
+		cfg.MaxVersion = tls.VersionTLS12
+	}, leaf, intermediate)
+
+	policy, err := NewTLSPolicy("1.3", nil, false)
+	if err != nil {
+		t.Fatalf("Failed to build policy: %v", err)
+	}
+
+	details, err := CheckCertificateWithOptions(server.URL, Options{Timeout: 5 * time.Second, TLSPolicy: policy})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if details.TLSVersion != "TLS 1.2" {
+		t.Errorf("Expected TLS 1.2 to be negotiated, got %s", details.TLSVersion)
+	}
+	if details.CipherSuite == "" {
+		t.Errorf("Expected the negotiated cipher suite to be recorded")
+	}
+	if len(details.PolicyViolations) != 1 {
+		t.Errorf("Expected a single minimum version violation, got %v", details.PolicyViolations)
+	}
+}
+
+func TestCheckCertificateWithOptions_ProbeLegacy(t *testing.T) {
+	_, intermediate, leaf := newTestChain(t, time.Now().Add(365*24*time.Hour))
+	server := newTestTLSServerWithConfig(t, func(cfg *tls.Config) {
+		cfg.MinVersion = tls.VersionTLS11
+	}, leaf, intermediate)
+
+	policy, err := NewTLSPolicy("1.2", nil, true)
+	if err != nil {
+		t.Fatalf("Failed to build policy: %v", err)
+	}
+
+	details, err := CheckCertificateWithOptions(server.URL, Options{Timeout: 5 * time.Second, TLSPolicy: policy})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if details.TLSVersion != "TLS 1.3" {
+		t.Errorf("Expected TLS 1.3 to be negotiated, got %s", details.TLSVersion)
+	}
+	if len(details.PolicyViolations) != 1 || details.PolicyViolations[0] != "server accepts TLS 1.1" {
+		t.Errorf("Expected only TLS 1.1 to be reported as accepted, got %v", details.PolicyViolations)
+	}
+}
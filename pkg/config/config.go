@@ -2,9 +2,18 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/certcheck"
+	"github.com/kuskoman/url-datadog-monitor/pkg/dialer"
 )
 
 const (
@@ -13,6 +22,28 @@ const (
 	DefaultTimeout         = 10
 	DefaultDogStatsDHost   = "127.0.0.1"
 	DefaultDogStatsDPort   = 8125
+	// DefaultMaxRedirects matches the number of redirects net/http follows by default
+	DefaultMaxRedirects = 10
+	// DefaultHistorySize is the number of checks per target the status API keeps by default
+	DefaultHistorySize = 100
+	// DefaultStatusPageTitle is the heading of status pages without a title
+	DefaultStatusPageTitle = "Status"
+	// DefaultStatusPageInterval is how often, in seconds, the status page file is rewritten by default
+	DefaultStatusPageInterval = 60
+	// DefaultNotifierRetries is how many times a failed notification is retried by default
+	DefaultNotifierRetries = 3
+	// DefaultNotifierTimeout is the timeout of a notification attempt in seconds by default
+	DefaultNotifierTimeout = 10
+	// DefaultSLOWindow is the rolling window of SLOs without one
+	DefaultSLOWindow = "30d"
+	// MaxSLOWindow is the longest supported SLO window
+	MaxSLOWindow = 90 * 24 * time.Hour
+
+	// HTTP protocols a target can be forced to use
+	ProtocolH1  = "h1"
+	ProtocolH2  = "h2"
+	ProtocolH2C = "h2c"
+	ProtocolH3  = "h3"
 )
 
 // Target represents a URL to monitor
@@ -26,17 +57,148 @@ type Target struct {
 	Timeout    int               `yaml:"timeout"`
 	CheckCert  *bool             `yaml:"check_cert"`
 	VerifyCert *bool             `yaml:"verify_cert"`
+	// ExpectedStatus lists the status codes considered healthy, any 2xx code when empty
+	ExpectedStatus []int `yaml:"expected_status"`
+	// TLSPolicy restricts the TLS versions and cipher suites the target may negotiate
+	TLSPolicy *TLSPolicy `yaml:"tls_policy"`
+	// CertFingerprints pins the leaf certificate or its public key by hex SHA-256 fingerprint
+	CertFingerprints []string `yaml:"cert_fingerprints"`
+	// CheckRevocation queries the OCSP responder or CRL when no OCSP response is stapled
+	CheckRevocation *bool `yaml:"check_revocation"`
+	// CAFile is a PEM bundle of CA certificates trusted instead of the system trust store
+	CAFile string `yaml:"ca_file"`
+	// CAData holds PEM encoded CA certificates, filled by the operator from a Secret
+	CAData []byte `yaml:"-"`
+	// ServerName is sent in SNI and verified against the certificate instead of the URL host
+	ServerName string `yaml:"server_name"`
+	// ExpectedDNSNames lists names the certificate must be valid for
+	ExpectedDNSNames []string `yaml:"expected_dns_names"`
+	// Resolve maps host:port pairs to the address connected to instead, like curl --resolve
+	Resolve map[string]string `yaml:"resolve"`
+	// DNSServer is the address of the DNS server resolving the target instead of the system resolver
+	DNSServer string `yaml:"dns_server"`
+	// IPFamily selects the IP family the target is checked over: any, ipv4, ipv6 or both
+	IPFamily string `yaml:"ip_family"`
+	// Proxy is the http, https or socks5 URL of the proxy to connect through,
+	// "env" to follow HTTPS_PROXY and NO_PROXY or "direct" to bypass a default proxy
+	Proxy string `yaml:"proxy"`
+	// FollowRedirects limits the redirects followed, DefaultMaxRedirects when unset
+	FollowRedirects *RedirectLimit `yaml:"follow_redirects"`
+	// ExpectedFinalURL is the URL the redirects must end at for the target to be up
+	ExpectedFinalURL string `yaml:"expected_final_url"`
+	// CheckRedirectCerts checks the certificate of every HTTPS host the target redirects through
+	CheckRedirectCerts bool `yaml:"check_redirect_certs"`
+	// Protocol forces the HTTP protocol: h1, h2, h2c (HTTP/2 without TLS) or h3, negotiated when empty
+	Protocol string `yaml:"protocol"`
+	// HeaderAssertions are checked against the response headers, the target is down when one fails
+	HeaderAssertions []HeaderAssertion `yaml:"header_assertions"`
+	// SecurityAudit scores the security headers and cookie flags of the response
+	SecurityAudit *bool `yaml:"security_audit"`
+	// DetectChanges hashes the response body and reports when it changes between checks
+	DetectChanges bool `yaml:"detect_changes"`
+	// ChangeIgnoreRegexes match parts of the body, like timestamps or nonces, left out of the hash
+	ChangeIgnoreRegexes []string `yaml:"change_ignore_regexes"`
+	// LatencyWarningMs is the response time above which a check is degraded, 0 disables it
+	LatencyWarningMs int `yaml:"latency_warning_ms"`
+	// LatencyCriticalMs is the response time above which a check is critical, 0 disables it
+	LatencyCriticalMs int `yaml:"latency_critical_ms"`
+	// SLO is the availability objective tracked for the target
+	SLO *SLO `yaml:"slo"`
+}
+
+// SLO is an availability objective: the percentage of good checks over a rolling window
+type SLO struct {
+	// Objective is the percentage of checks that must be good, like 99.9
+	Objective float64 `yaml:"objective"`
+	// Window is the rolling window, in days like "30d" or as a Go duration, DefaultSLOWindow when empty
+	Window string `yaml:"window"`
+	// BurnRateAlerts raise events when the error budget burns too fast,
+	// DefaultBurnRateAlerts when empty
+	BurnRateAlerts []BurnRateAlert `yaml:"burn_rate_alerts"`
+}
+
+// BurnRateAlert fires when the burn rate exceeds the threshold over both windows
+type BurnRateAlert struct {
+	LongWindow  string  `yaml:"long_window"`
+	ShortWindow string  `yaml:"short_window"`
+	Threshold   float64 `yaml:"threshold"`
+}
+
+// DefaultBurnRateAlerts are the fast and slow burn alerts recommended by the SRE workbook for
+// a 30 day window, spending 2% of the budget in an hour or 5% in six hours
+var DefaultBurnRateAlerts = []BurnRateAlert{
+	{LongWindow: "1h", ShortWindow: "5m", Threshold: 14.4},
+	{LongWindow: "6h", ShortWindow: "30m", Threshold: 6},
+}
+
+// HeaderAssertion checks a response header. Exactly one of Present, Absent, Equals and Regex is set.
+type HeaderAssertion struct {
+	// Header is the name of the response header, matched case insensitively
+	Header string `yaml:"header"`
+	// Present requires the header to be sent
+	Present bool `yaml:"present"`
+	// Absent requires the header not to be sent
+	Absent bool `yaml:"absent"`
+	// Equals requires a value of the header to be exactly this string
+	Equals string `yaml:"equals"`
+	// Regex requires a value of the header to match this regular expression
+	Regex string `yaml:"regex"`
+}
+
+// RedirectLimit is the maximum number of redirects followed. In YAML it is either a
+// number of redirects or a bool, true following up to DefaultMaxRedirects and false none.
+type RedirectLimit int
+
+// UnmarshalYAML accepts a bool or a non-negative number of redirects
+func (l *RedirectLimit) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var follow bool
+	if err := unmarshal(&follow); err == nil {
+		*l = 0
+		if follow {
+			*l = DefaultMaxRedirects
+		}
+		return nil
+	}
+
+	var redirects int
+	if err := unmarshal(&redirects); err != nil || redirects < 0 {
+		return fmt.Errorf("follow_redirects must be a bool or a non-negative number of redirects")
+	}
+	*l = RedirectLimit(redirects)
+	return nil
+}
+
+// TLSPolicy describes the acceptable TLS versions and cipher suites of a target
+type TLSPolicy struct {
+	// MinVersion is the lowest acceptable TLS version: "1.0", "1.1", "1.2" or "1.3"
+	MinVersion string `yaml:"min_version"`
+	// Ciphers lists the acceptable cipher suites by IANA name, any secure suite when empty
+	Ciphers []string `yaml:"ciphers"`
+	// ProbeLegacy checks whether the target still accepts versions below MinVersion
+	ProbeLegacy bool `yaml:"probe_legacy"`
 }
 
 // Defaults represents global default settings for all targets
 type Defaults struct {
-	Method      string            `yaml:"method"`
-	Interval    int               `yaml:"interval"`
-	Timeout     int               `yaml:"timeout"`
-	Headers     map[string]string `yaml:"headers"`
-	Labels      map[string]string `yaml:"labels"`
-	CheckCert   bool              `yaml:"check_cert"`
-	VerifyCert  bool              `yaml:"verify_cert"`
+	Method          string            `yaml:"method"`
+	Interval        int               `yaml:"interval"`
+	Timeout         int               `yaml:"timeout"`
+	Headers         map[string]string `yaml:"headers"`
+	Labels          map[string]string `yaml:"labels"`
+	CheckCert       bool              `yaml:"check_cert"`
+	VerifyCert      bool              `yaml:"verify_cert"`
+	ExpectedStatus  []int             `yaml:"expected_status"`
+	TLSPolicy       *TLSPolicy        `yaml:"tls_policy"`
+	CheckRevocation bool              `yaml:"check_revocation"`
+	CAFile          string            `yaml:"ca_file"`
+	DNSServer       string            `yaml:"dns_server"`
+	IPFamily        string            `yaml:"ip_family"`
+	Proxy           string            `yaml:"proxy"`
+	FollowRedirects *RedirectLimit    `yaml:"follow_redirects"`
+	SecurityAudit   bool              `yaml:"security_audit"`
+
+	LatencyWarningMs  int `yaml:"latency_warning_ms"`
+	LatencyCriticalMs int `yaml:"latency_critical_ms"`
 }
 
 // Config represents the structure of config.yaml
@@ -47,6 +209,134 @@ type Config struct {
 		Host string `yaml:"host"`
 		Port int    `yaml:"port"`
 	} `yaml:"datadog"`
+	// StateFile keeps the check schedule, certificate serials, content hashes and SLO counts
+	// across restarts when set
+	StateFile string `yaml:"state_file"`
+	API       API    `yaml:"api"`
+	// StatusPage enables the HTML status page when set
+	StatusPage *StatusPage `yaml:"status_page"`
+	Notifiers  []Notifier  `yaml:"notifiers"`
+}
+
+// API configures the HTTP status API of the standalone service
+type API struct {
+	// Listen is the address the API listens on, like ":8080", the API is disabled when empty
+	Listen string `yaml:"listen"`
+	// HistorySize is the number of checks kept per target, DefaultHistorySize when 0
+	HistorySize int `yaml:"history_size"`
+}
+
+// StatusPage configures the HTML status page of the standalone service, served by the API at
+// /status and, with an output, written to a file
+type StatusPage struct {
+	Title string `yaml:"title"`
+	// GroupBy is the label targets are grouped by, all targets are listed together when empty
+	GroupBy string `yaml:"group_by"`
+	// ShowURLs lists the URL of every target, off by default since the page may be public
+	ShowURLs bool `yaml:"show_urls"`
+	// Output is a file the page is written to every interval, only served by the API when empty
+	Output string `yaml:"output"`
+	// Interval is how often the file is rewritten, in seconds
+	Interval int `yaml:"interval"`
+}
+
+// Notifier events
+const (
+	NotifierEventDown         = "down"
+	NotifierEventRecovered    = "recovered"
+	NotifierEventCertExpiring = "cert_expiring"
+	NotifierEventCertInvalid  = "cert_invalid"
+)
+
+// NotifierEvents are the events a notifier can be sent
+var NotifierEvents = []string{NotifierEventDown, NotifierEventRecovered, NotifierEventCertExpiring, NotifierEventCertInvalid}
+
+// Notifier posts a JSON payload to a webhook when a target changes state
+type Notifier struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	// Events are the events sent to the webhook, all of them when empty
+	Events []string `yaml:"events"`
+	// Labels restricts the notifier to targets with all of these labels
+	Labels  map[string]string `yaml:"labels"`
+	Headers map[string]string `yaml:"headers"`
+	// Template renders the JSON payload, the transition itself is sent when empty
+	Template string `yaml:"template"`
+	// Secret signs payloads with HMAC-SHA256 when set
+	Secret string `yaml:"secret"`
+	// SecretEnv names an environment variable holding the secret
+	SecretEnv string `yaml:"secret_env"`
+	// Retries is how many times a failed notification is retried
+	Retries *int `yaml:"retries"`
+	// Timeout of each attempt in seconds
+	Timeout int `yaml:"timeout"`
+}
+
+// notifiersFile is the structure of a file holding only notifiers
+type notifiersFile struct {
+	Notifiers []Notifier `yaml:"notifiers"`
+}
+
+// LoadNotifiers reads the notifiers of a YAML file with a top-level notifiers list, such as the
+// operator's notifiers file
+func LoadNotifiers(path string) ([]Notifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read notifiers file: %w", err)
+	}
+
+	var file notifiersFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("could not parse notifiers file: %w", err)
+	}
+	if err := defaultNotifiers(file.Notifiers); err != nil {
+		return nil, err
+	}
+	return file.Notifiers, nil
+}
+
+// defaultNotifiers fills in the defaults of notifiers and validates them
+func defaultNotifiers(notifiers []Notifier) error {
+	for i := range notifiers {
+		n := &notifiers[i]
+		if n.Name == "" {
+			n.Name = fmt.Sprintf("notifier-%d", i)
+		}
+		if n.Retries == nil {
+			retries := DefaultNotifierRetries
+			n.Retries = &retries
+		}
+		if n.Timeout == 0 {
+			n.Timeout = DefaultNotifierTimeout
+		}
+		if err := ValidateNotifier(*n); err != nil {
+			return fmt.Errorf("notifier %s is invalid: %w", n.Name, err)
+		}
+	}
+	return nil
+}
+
+// ValidateNotifier checks that the webhook is an absolute HTTP(S) URL, that the events exist
+// and that retries and the timeout aren't negative
+func ValidateNotifier(n Notifier) error {
+	if n.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	if err := ValidateFinalURL(n.URL); err != nil {
+		return err
+	}
+	for _, event := range n.Events {
+		if !slices.Contains(NotifierEvents, event) {
+			return fmt.Errorf("unknown event %q, expected one of %s", event, strings.Join(NotifierEvents, ", "))
+		}
+	}
+	if n.Retries != nil && *n.Retries < 0 {
+		return fmt.Errorf("retries must not be negative")
+	}
+	if n.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	return nil
 }
 
 // Load reads the YAML config file and unmarshals it into a Config struct.
@@ -125,6 +415,99 @@ func Load(path string) (*Config, error) {
 			}
 		}
 		
+		if len(cfg.Targets[i].ExpectedStatus) == 0 {
+			cfg.Targets[i].ExpectedStatus = cfg.Defaults.ExpectedStatus
+		}
+		
+		if cfg.Targets[i].TLSPolicy == nil {
+			cfg.Targets[i].TLSPolicy = cfg.Defaults.TLSPolicy
+		}
+		if policy := cfg.Targets[i].TLSPolicy; policy != nil {
+			if _, err := certcheck.NewTLSPolicy(policy.MinVersion, policy.Ciphers, policy.ProbeLegacy); err != nil {
+				return nil, fmt.Errorf("target %d has an invalid tls_policy: %w", i, err)
+			}
+		}
+		
+		for _, fingerprint := range cfg.Targets[i].CertFingerprints {
+			if err := certcheck.ValidateFingerprint(fingerprint); err != nil {
+				return nil, fmt.Errorf("target %d has an invalid cert_fingerprints entry: %w", i, err)
+			}
+		}
+		
+		if cfg.Targets[i].CAFile == "" {
+			cfg.Targets[i].CAFile = cfg.Defaults.CAFile
+		}
+		if cfg.Targets[i].CAFile != "" {
+			if _, err := certcheck.LoadCertPool(cfg.Targets[i].CAFile); err != nil {
+				return nil, fmt.Errorf("target %d has an invalid ca_file: %w", i, err)
+			}
+		}
+		
+		if cfg.Targets[i].DNSServer == "" {
+			cfg.Targets[i].DNSServer = cfg.Defaults.DNSServer
+		}
+		if err := dialer.Validate(cfg.Targets[i].Resolve, cfg.Targets[i].DNSServer); err != nil {
+			return nil, fmt.Errorf("target %d has invalid resolution settings: %w", i, err)
+		}
+		if cfg.Targets[i].IPFamily == "" {
+			cfg.Targets[i].IPFamily = cfg.Defaults.IPFamily
+		}
+		if err := dialer.ValidateIPFamily(cfg.Targets[i].IPFamily); err != nil {
+			return nil, fmt.Errorf("target %d has an invalid ip_family: %w", i, err)
+		}
+		if cfg.Targets[i].Proxy == "" {
+			cfg.Targets[i].Proxy = cfg.Defaults.Proxy
+		}
+		if err := dialer.ValidateProxy(cfg.Targets[i].Proxy); err != nil {
+			return nil, fmt.Errorf("target %d has an invalid proxy: %w", i, err)
+		}
+
+		if cfg.Targets[i].FollowRedirects == nil {
+			followRedirects := RedirectLimit(DefaultMaxRedirects)
+			if cfg.Defaults.FollowRedirects != nil {
+				followRedirects = *cfg.Defaults.FollowRedirects
+			}
+			cfg.Targets[i].FollowRedirects = &followRedirects
+		}
+		if err := ValidateFinalURL(cfg.Targets[i].ExpectedFinalURL); err != nil {
+			return nil, fmt.Errorf("target %d has an invalid expected_final_url: %w", i, err)
+		}
+		if err := ValidateProtocol(cfg.Targets[i].Protocol, cfg.Targets[i].URL); err != nil {
+			return nil, fmt.Errorf("target %d has an invalid protocol: %w", i, err)
+		}
+		for j, assertion := range cfg.Targets[i].HeaderAssertions {
+			if err := ValidateHeaderAssertion(assertion); err != nil {
+				return nil, fmt.Errorf("target %d has an invalid header_assertions entry %d: %w", i, j, err)
+			}
+		}
+		if err := ValidateRegexes(cfg.Targets[i].ChangeIgnoreRegexes); err != nil {
+			return nil, fmt.Errorf("target %d has an invalid change_ignore_regexes entry: %w", i, err)
+		}
+		if cfg.Targets[i].LatencyWarningMs == 0 {
+			cfg.Targets[i].LatencyWarningMs = cfg.Defaults.LatencyWarningMs
+		}
+		if cfg.Targets[i].LatencyCriticalMs == 0 {
+			cfg.Targets[i].LatencyCriticalMs = cfg.Defaults.LatencyCriticalMs
+		}
+		if err := ValidateLatencyThresholds(cfg.Targets[i].LatencyWarningMs, cfg.Targets[i].LatencyCriticalMs); err != nil {
+			return nil, fmt.Errorf("target %d has invalid latency thresholds: %w", i, err)
+		}
+		if slo := cfg.Targets[i].SLO; slo != nil {
+			SetSLODefaults(slo)
+			if err := ValidateSLO(*slo); err != nil {
+				return nil, fmt.Errorf("target %d has an invalid slo: %w", i, err)
+			}
+		}
+		if cfg.Targets[i].SecurityAudit == nil {
+			securityAudit := cfg.Defaults.SecurityAudit
+			cfg.Targets[i].SecurityAudit = &securityAudit
+		}
+		
+		if cfg.Targets[i].CheckRevocation == nil {
+			checkRevocation := cfg.Defaults.CheckRevocation
+			cfg.Targets[i].CheckRevocation = &checkRevocation
+		}
+		
 		if cfg.Targets[i].CheckCert == nil {
 			checkCert := cfg.Defaults.CheckCert
 			cfg.Targets[i].CheckCert = &checkCert
@@ -142,6 +525,177 @@ func Load(path string) (*Config, error) {
 	if cfg.Datadog.Port == 0 {
 		cfg.Datadog.Port = DefaultDogStatsDPort
 	}
+
+	if cfg.API.HistorySize < 0 {
+		return nil, fmt.Errorf("api history_size must not be negative")
+	}
+	if cfg.API.HistorySize == 0 {
+		cfg.API.HistorySize = DefaultHistorySize
+	}
+
+	if page := cfg.StatusPage; page != nil {
+		if page.Title == "" {
+			page.Title = DefaultStatusPageTitle
+		}
+		if page.Interval < 0 {
+			return nil, fmt.Errorf("status_page interval must not be negative")
+		}
+		if page.Interval == 0 {
+			page.Interval = DefaultStatusPageInterval
+		}
+	}
+
+	if err := defaultNotifiers(cfg.Notifiers); err != nil {
+		return nil, err
+	}
 	
 	return &cfg, nil
-}
\ No newline at end of file
+}
+// ValidateFinalURL checks that an expected final URL, when set, is an absolute HTTP(S) URL
+func ValidateFinalURL(rawURL string) error {
+	if rawURL == "" {
+		return nil
+	}
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return fmt.Errorf("%q must be an absolute http or https URL", rawURL)
+	}
+	return nil
+}
+
+// ValidateProtocol checks that a forced HTTP protocol exists and applies to the URL scheme:
+// h2c needs an http URL, h2 and h3 an https URL
+func ValidateProtocol(protocol, rawURL string) error {
+	scheme, _, _ := strings.Cut(strings.ToLower(rawURL), "://")
+	switch protocol {
+	case "":
+		return nil
+	case ProtocolH1:
+		if scheme != "http" && scheme != "https" {
+			return fmt.Errorf("%s only applies to http and https URLs", protocol)
+		}
+	case ProtocolH2C:
+		if scheme != "http" {
+			return fmt.Errorf("%s only applies to http URLs", protocol)
+		}
+	case ProtocolH2, ProtocolH3:
+		if scheme != "https" {
+			return fmt.Errorf("%s only applies to https URLs", protocol)
+		}
+	default:
+		return fmt.Errorf("protocol %q must be one of %s, %s, %s or %s", protocol, ProtocolH1, ProtocolH2, ProtocolH2C, ProtocolH3)
+	}
+	return nil
+}
+
+// ValidateHeaderAssertion checks that an assertion names a header and sets exactly one
+// condition, with a valid regular expression
+func ValidateHeaderAssertion(assertion HeaderAssertion) error {
+	if strings.TrimSpace(assertion.Header) == "" {
+		return fmt.Errorf("header is required")
+	}
+
+	conditions := 0
+	for _, set := range []bool{assertion.Present, assertion.Absent, assertion.Equals != "", assertion.Regex != ""} {
+		if set {
+			conditions++
+		}
+	}
+	if conditions != 1 {
+		return fmt.Errorf("exactly one of present, absent, equals and regex must be set for %s", assertion.Header)
+	}
+
+	if assertion.Regex != "" {
+		if _, err := regexp.Compile(assertion.Regex); err != nil {
+			return fmt.Errorf("invalid regex for %s: %w", assertion.Header, err)
+		}
+	}
+	return nil
+}
+
+// ValidateRegexes checks that every pattern is a valid regular expression
+func ValidateRegexes(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid regex %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// ValidateLatencyThresholds checks that the latency thresholds aren't negative and that the
+// warning threshold is below the critical one when both are set
+func ValidateLatencyThresholds(warningMs, criticalMs int) error {
+	if warningMs < 0 || criticalMs < 0 {
+		return fmt.Errorf("latency thresholds must not be negative")
+	}
+	if warningMs > 0 && criticalMs > 0 && warningMs >= criticalMs {
+		return fmt.Errorf("latency_warning_ms (%d) must be below latency_critical_ms (%d)", warningMs, criticalMs)
+	}
+	return nil
+}
+
+// ParseWindow parses a window given in days, like "30d", or as a Go duration like "6h"
+func ParseWindow(window string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(window, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid window %q", window)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid window %q", window)
+	}
+	return d, nil
+}
+
+// SetSLODefaults fills in the window and burn rate alerts of an SLO that doesn't set them
+func SetSLODefaults(slo *SLO) {
+	if slo.Window == "" {
+		slo.Window = DefaultSLOWindow
+	}
+	if len(slo.BurnRateAlerts) == 0 {
+		slo.BurnRateAlerts = DefaultBurnRateAlerts
+	}
+}
+
+// ValidateSLO checks that the objective is a percentage below 100, that the window is between
+// an hour and MaxSLOWindow and that every burn rate alert has a short window below its long
+// window, which fits in the SLO window
+func ValidateSLO(slo SLO) error {
+	if slo.Objective <= 0 || slo.Objective >= 100 {
+		return fmt.Errorf("objective must be a percentage between 0 and 100, got %v", slo.Objective)
+	}
+
+	window, err := ParseWindow(slo.Window)
+	if err != nil {
+		return err
+	}
+	if window < time.Hour || window > MaxSLOWindow {
+		return fmt.Errorf("window must be between 1h and %dd", MaxSLOWindow/(24*time.Hour))
+	}
+
+	for i, alert := range slo.BurnRateAlerts {
+		long, err := ParseWindow(alert.LongWindow)
+		if err != nil {
+			return fmt.Errorf("burn rate alert %d: %w", i, err)
+		}
+		short, err := ParseWindow(alert.ShortWindow)
+		if err != nil {
+			return fmt.Errorf("burn rate alert %d: %w", i, err)
+		}
+		if short >= long || long > window {
+			return fmt.Errorf("burn rate alert %d: short window must be below the long window, which must fit in the SLO window", i)
+		}
+		if alert.Threshold <= 0 {
+			return fmt.Errorf("burn rate alert %d: threshold must be positive", i)
+		}
+	}
+	return nil
+}
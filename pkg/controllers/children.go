@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	urlmonitorv1 "github.com/kuskoman/url-datadog-monitor/pkg/api/v1"
+)
+
+const (
+	// maxChildNameLength leaves room for the hash suffix within the DNS subdomain limit
+	maxChildNameLength = 253 - 9
+	// maxOwnerLabelLength leaves room for the hash suffix within the label value limit
+	maxOwnerLabelLength = validation.LabelValueMaxLength - 9
+)
+
+// childMonitorName builds a stable URLMonitor name from the owner name and the values identifying the child
+func childMonitorName(ownerName string, key ...string) string {
+	return truncateWithHash(ownerName, maxChildNameLength, strings.Join(key, "\x00"))
+}
+
+// ownerLabelValue returns the value of the label pointing children at their owner. Names
+// longer than a label value are shortened and suffixed with a hash of the full name.
+func ownerLabelValue(ownerName string) string {
+	if len(ownerName) <= validation.LabelValueMaxLength {
+		return ownerName
+	}
+	return truncateWithHash(ownerName, maxOwnerLabelLength, ownerName)
+}
+
+// truncateWithHash cuts prefix to at most maxLength characters and appends a hash of hashed
+func truncateWithHash(prefix string, maxLength int, hashed string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(hashed))
+
+	if len(prefix) > maxLength {
+		prefix = strings.TrimRight(prefix[:maxLength], "-.")
+	}
+	return fmt.Sprintf("%s-%08x", prefix, h.Sum32())
+}
+
+// syncOwnedMonitors makes the URLMonitors controlled by owner match desired.
+// Missing monitors are created, changed ones updated and monitors labelled with
+// ownerLabel=owner name that are no longer desired are deleted. A desired name taken by
+// a monitor owned by something else is skipped with a warning event on owner.
+// It returns the resulting set of child monitors sorted by name.
+func syncOwnedMonitors(ctx context.Context, c client.Client, scheme *runtime.Scheme, recorder record.EventRecorder, owner client.Object, ownerLabel string, desired []*urlmonitorv1.URLMonitor) ([]urlmonitorv1.URLMonitor, error) {
+	labelValue := ownerLabelValue(owner.GetName())
+	existingList := &urlmonitorv1.URLMonitorList{}
+	err := c.List(ctx, existingList,
+		client.InNamespace(owner.GetNamespace()),
+		client.MatchingLabels{ownerLabel: labelValue})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list owned URLMonitors: %w", err)
+	}
+
+	existing := make(map[string]*urlmonitorv1.URLMonitor)
+	for i := range existingList.Items {
+		item := &existingList.Items[i]
+		if metav1.IsControlledBy(item, owner) {
+			existing[item.Name] = item
+		}
+	}
+
+	result := make([]urlmonitorv1.URLMonitor, 0, len(desired))
+	for _, want := range desired {
+		want.Namespace = owner.GetNamespace()
+		if want.Labels == nil {
+			want.Labels = make(map[string]string)
+		}
+		want.Labels[ownerLabel] = labelValue
+
+		current, found := existing[want.Name]
+		if !found {
+			if err := controllerutil.SetControllerReference(owner, want, scheme); err != nil {
+				return nil, fmt.Errorf("failed to set owner of URLMonitor %s: %w", want.Name, err)
+			}
+			err := c.Create(ctx, want)
+			if err == nil {
+				result = append(result, *want)
+				continue
+			}
+			if !errors.IsAlreadyExists(err) {
+				return nil, fmt.Errorf("failed to create URLMonitor %s: %w", want.Name, err)
+			}
+
+			// Our own monitor is missing from the list when its label was changed
+			current = &urlmonitorv1.URLMonitor{}
+			if err := c.Get(ctx, client.ObjectKeyFromObject(want), current); err != nil {
+				return nil, fmt.Errorf("failed to get URLMonitor %s: %w", want.Name, err)
+			}
+			if !metav1.IsControlledBy(current, owner) {
+				recorder.Event(owner, "Warning", "MonitorConflict",
+					fmt.Sprintf("URLMonitor %s already exists and isn't managed by this resource", want.Name))
+				continue
+			}
+		}
+
+		delete(existing, want.Name)
+		if equality.Semantic.DeepEqual(current.Spec, want.Spec) && labelsContain(current.Labels, want.Labels) {
+			result = append(result, *current)
+			continue
+		}
+
+		current.Spec = want.Spec
+		if current.Labels == nil {
+			current.Labels = make(map[string]string)
+		}
+		for k, v := range want.Labels {
+			current.Labels[k] = v
+		}
+		if err := c.Update(ctx, current); err != nil {
+			return nil, fmt.Errorf("failed to update URLMonitor %s: %w", current.Name, err)
+		}
+		result = append(result, *current)
+	}
+
+	for _, stale := range existing {
+		if err := c.Delete(ctx, stale); err != nil && !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to delete URLMonitor %s: %w", stale.Name, err)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// labelsContain reports whether all labels in want are present in have with the same value
+func labelsContain(have, want map[string]string) bool {
+	for k, v := range want {
+		if have[k] != v {
+			return false
+		}
+	}
+	return true
+}
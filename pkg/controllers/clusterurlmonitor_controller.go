@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"log/slog"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	urlmonitorv1 "github.com/kuskoman/url-datadog-monitor/pkg/api/v1"
+	"github.com/kuskoman/url-datadog-monitor/pkg/exporter"
+)
+
+// +kubebuilder:rbac:groups=url-datadog-monitor.kuskoman.github.com,resources=clusterurlmonitors,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=url-datadog-monitor.kuskoman.github.com,resources=clusterurlmonitors/status,verbs=get;update;patch
+
+// NewClusterURLMonitorReconciler creates a new reconciler for cluster-scoped ClusterURLMonitor resources.
+// It runs the same monitoring logic as the URLMonitor reconciler.
+func NewClusterURLMonitorReconciler(client client.Client, scheme *runtime.Scheme, metricsClient exporter.MetricsExporter, logger *slog.Logger, eventRecorder record.EventRecorder) *URLMonitorReconciler {
+	return newMonitorReconciler("ClusterURLMonitor", func() monitoredResource { return &urlmonitorv1.ClusterURLMonitor{} },
+		client, scheme, metricsClient, logger, eventRecorder)
+}
@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	urlmonitorv1 "github.com/kuskoman/url-datadog-monitor/pkg/api/v1"
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+)
+
+const (
+	// AnnotationPrefix is the prefix of all annotations read by the discovery controllers
+	AnnotationPrefix = "url-monitor.kuskoman.github.com/"
+
+	// AnnotationEnabled opts a resource into monitor discovery when set to "true"
+	AnnotationEnabled = AnnotationPrefix + "enabled"
+	// AnnotationPath overrides the monitored paths (comma-separated)
+	AnnotationPath = AnnotationPrefix + "path"
+	// AnnotationScheme overrides the URL scheme (http or https)
+	AnnotationScheme = AnnotationPrefix + "scheme"
+	// AnnotationMethod overrides the HTTP method
+	AnnotationMethod = AnnotationPrefix + "method"
+	// AnnotationInterval overrides the check interval in seconds
+	AnnotationInterval = AnnotationPrefix + "interval"
+	// AnnotationTimeout overrides the request timeout in seconds
+	AnnotationTimeout = AnnotationPrefix + "timeout"
+	// AnnotationExpectedStatus lists the healthy status codes (comma-separated)
+	AnnotationExpectedStatus = AnnotationPrefix + "expected-status"
+	// AnnotationLabels adds metric labels as comma-separated key=value pairs
+	AnnotationLabels = AnnotationPrefix + "labels"
+	// AnnotationPort selects the Service port to monitor by name or number
+	AnnotationPort = AnnotationPrefix + "port"
+	// AnnotationPerEndpoint additionally monitors every ready endpoint of a Service when set to "true"
+	AnnotationPerEndpoint = AnnotationPrefix + "per-endpoint"
+
+	SchemeHTTP  = "http"
+	SchemeHTTPS = "https"
+)
+
+// discoveryEnabled reports whether a resource opted into monitor discovery
+func discoveryEnabled(obj metav1.Object) bool {
+	enabled, _ := strconv.ParseBool(obj.GetAnnotations()[AnnotationEnabled])
+	return enabled
+}
+
+// discoveryTemplate builds the URLMonitor spec shared by all monitors discovered from
+// a resource, applying annotation overrides on top of the standard defaults.
+// The URL of the returned spec is left empty.
+func discoveryTemplate(annotations map[string]string) (urlmonitorv1.URLMonitorSpec, error) {
+	checkCert := true
+	verifyCert := false
+	spec := urlmonitorv1.URLMonitorSpec{
+		Method:     config.DefaultMethod,
+		Interval:   config.DefaultInterval,
+		Timeout:    config.DefaultTimeout,
+		CheckCert:  &checkCert,
+		VerifyCert: &verifyCert,
+	}
+
+	if method, ok := annotations[AnnotationMethod]; ok {
+		spec.Method = strings.ToUpper(strings.TrimSpace(method))
+	}
+
+	if value, ok := annotations[AnnotationInterval]; ok {
+		interval, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return spec, fmt.Errorf("invalid %s annotation %q: %w", AnnotationInterval, value, err)
+		}
+		spec.Interval = interval
+	}
+
+	if value, ok := annotations[AnnotationTimeout]; ok {
+		timeout, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return spec, fmt.Errorf("invalid %s annotation %q: %w", AnnotationTimeout, value, err)
+		}
+		spec.Timeout = timeout
+	}
+
+	if value, ok := annotations[AnnotationExpectedStatus]; ok {
+		for _, code := range splitList(value) {
+			status, err := strconv.Atoi(code)
+			if err != nil {
+				return spec, fmt.Errorf("invalid %s annotation %q: %w", AnnotationExpectedStatus, value, err)
+			}
+			spec.ExpectedStatus = append(spec.ExpectedStatus, status)
+		}
+	}
+
+	if value, ok := annotations[AnnotationLabels]; ok {
+		spec.Labels = make(map[string]string)
+		for _, pair := range splitList(value) {
+			k, v, found := strings.Cut(pair, "=")
+			if !found || strings.TrimSpace(k) == "" {
+				return spec, fmt.Errorf("invalid %s annotation %q: expected key=value pairs", AnnotationLabels, value)
+			}
+			spec.Labels[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+
+	return spec, nil
+}
+
+// discoveryScheme returns the annotated scheme override or fallback
+func discoveryScheme(annotations map[string]string, fallback string) (string, error) {
+	scheme, ok := annotations[AnnotationScheme]
+	if !ok {
+		return fallback, nil
+	}
+	scheme = strings.ToLower(strings.TrimSpace(scheme))
+	if scheme != SchemeHTTP && scheme != SchemeHTTPS {
+		return "", fmt.Errorf("invalid %s annotation %q: must be http or https", AnnotationScheme, scheme)
+	}
+	return scheme, nil
+}
+
+// discoveryPaths returns the annotated path override, or nil if paths aren't overridden
+func discoveryPaths(annotations map[string]string) []string {
+	value, ok := annotations[AnnotationPath]
+	if !ok {
+		return nil
+	}
+	var paths []string
+	for _, path := range splitList(value) {
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// endpoint is a single URL discovered from a resource
+type endpoint struct {
+	scheme string
+	host   string
+	path   string
+	// labels are added to the labels of the template
+	labels map[string]string
+	// connectTo is the address connected to instead of host when set
+	connectTo string
+}
+
+// String returns the URL of the endpoint
+func (e endpoint) String() string {
+	u := url.URL{Scheme: e.scheme, Host: e.host, Path: e.path}
+	return u.String()
+}
+
+// key returns the values identifying the endpoint among those of its owner
+func (e endpoint) key() []string {
+	if e.connectTo == "" {
+		return []string{e.String()}
+	}
+	return []string{e.String(), e.connectTo}
+}
+
+// discoveredMonitors builds one URLMonitor per distinct endpoint from the shared template
+func discoveredMonitors(ownerName string, template urlmonitorv1.URLMonitorSpec, endpoints []endpoint) []*urlmonitorv1.URLMonitor {
+	byName := make(map[string]endpoint)
+	var names []string
+	for _, e := range endpoints {
+		name := childMonitorName(ownerName, e.key()...)
+		if _, seen := byName[name]; !seen {
+			byName[name] = e
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	monitors := make([]*urlmonitorv1.URLMonitor, 0, len(names))
+	for _, name := range names {
+		e := byName[name]
+		spec := template.DeepCopy()
+		spec.URL = e.String()
+		if e.connectTo != "" {
+			spec.Resolve = map[string]string{e.host: e.connectTo}
+		}
+		if len(e.labels) > 0 {
+			if spec.Labels == nil {
+				spec.Labels = make(map[string]string, len(e.labels))
+			}
+			for k, v := range e.labels {
+				spec.Labels[k] = v
+			}
+		}
+		monitors = append(monitors, &urlmonitorv1.URLMonitor{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: *spec,
+		})
+	}
+	return monitors
+}
+
+// monitorablePath converts a routing path into a concrete request path.
+// Paths that can't be requested literally, such as regular expressions, are rejected.
+func monitorablePath(path string) (string, bool) {
+	if path == "" {
+		return "/", true
+	}
+	if strings.ContainsAny(path, "*()[]{}^$|\\+?") {
+		return "", false
+	}
+	return path, true
+}
+
+// monitorableHost reports whether a routing hostname identifies a single host
+func monitorableHost(host string) bool {
+	return host != "" && !strings.Contains(host, "*")
+}
+
+// splitList splits a comma-separated annotation value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,324 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	urlmonitorv1 "github.com/kuskoman/url-datadog-monitor/pkg/api/v1"
+)
+
+const (
+	// LabelIngress is set on URLMonitors discovered from an Ingress
+	LabelIngress = "url-datadog-monitor.kuskoman.github.com/ingress"
+	// LabelHTTPRoute is set on URLMonitors discovered from a Gateway API HTTPRoute
+	LabelHTTPRoute = "url-datadog-monitor.kuskoman.github.com/httproute"
+)
+
+// HTTPRouteGVK identifies the Gateway API HTTPRoute kind watched for discovery
+var HTTPRouteGVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "HTTPRoute"}
+
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch
+
+// IngressDiscoveryReconciler creates URLMonitors for annotated Ingresses
+type IngressDiscoveryReconciler struct {
+	client.Client
+	Scheme                  *runtime.Scheme
+	Logger                  *slog.Logger
+	KubernetesEventRecorder record.EventRecorder
+}
+
+// NewIngressDiscoveryReconciler creates a new discovery reconciler for Ingress resources
+func NewIngressDiscoveryReconciler(client client.Client, scheme *runtime.Scheme, logger *slog.Logger, eventRecorder record.EventRecorder) *IngressDiscoveryReconciler {
+	return &IngressDiscoveryReconciler{
+		Client:                  client,
+		Scheme:                  scheme,
+		Logger:                  logger,
+		KubernetesEventRecorder: eventRecorder,
+	}
+}
+
+// Reconcile keeps the URLMonitors owned by an Ingress in sync with its rules and annotations
+func (r *IngressDiscoveryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.FromContext(ctx)
+	reqLogger.Info("Reconciling Ingress for monitor discovery")
+
+	ingress := &networkingv1.Ingress{}
+	err := r.Get(ctx, req.NamespacedName, ingress)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// Discovered URLMonitors are garbage collected through their owner references
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	var desired []*urlmonitorv1.URLMonitor
+	if discoveryEnabled(ingress) {
+		desired, err = ingressMonitors(ingress)
+		if err != nil {
+			r.KubernetesEventRecorder.Event(ingress, "Warning", "InvalidMonitorAnnotations",
+				fmt.Sprintf("Failed to discover URLMonitors: %v", err))
+			return ctrl.Result{}, nil
+		}
+	}
+
+	monitors, err := syncOwnedMonitors(ctx, r.Client, r.Scheme, r.KubernetesEventRecorder, ingress, LabelIngress, desired)
+	if err != nil {
+		r.KubernetesEventRecorder.Event(ingress, "Warning", "MonitorSyncFailed",
+			fmt.Sprintf("Failed to sync discovered URLMonitors: %v", err))
+		return ctrl.Result{}, err
+	}
+
+	r.Logger.Info("Synced discovered URLMonitors",
+		slog.String("ingress", req.String()),
+		slog.Int("monitor_count", len(monitors)))
+
+	return ctrl.Result{}, nil
+}
+
+// ingressMonitors builds the URLMonitors for every host and path of an Ingress
+func ingressMonitors(ingress *networkingv1.Ingress) ([]*urlmonitorv1.URLMonitor, error) {
+	template, err := discoveryTemplate(ingress.Annotations)
+	if err != nil {
+		return nil, err
+	}
+	overridePaths := discoveryPaths(ingress.Annotations)
+
+	var endpoints []endpoint
+	for _, rule := range ingress.Spec.Rules {
+		if !monitorableHost(rule.Host) {
+			continue
+		}
+
+		fallbackScheme := SchemeHTTP
+		if ingressServesTLS(ingress, rule.Host) {
+			fallbackScheme = SchemeHTTPS
+		}
+		scheme, err := discoveryScheme(ingress.Annotations, fallbackScheme)
+		if err != nil {
+			return nil, err
+		}
+
+		paths := overridePaths
+		if paths == nil {
+			paths = ingressRulePaths(rule)
+		}
+
+		for _, path := range paths {
+			endpoints = append(endpoints, endpoint{scheme: scheme, host: rule.Host, path: path})
+		}
+	}
+
+	return discoveredMonitors(ingress.Name, template, endpoints), nil
+}
+
+// ingressServesTLS reports whether a host is listed in the TLS section of an Ingress
+func ingressServesTLS(ingress *networkingv1.Ingress, host string) bool {
+	for _, tls := range ingress.Spec.TLS {
+		for _, tlsHost := range tls.Hosts {
+			if tlsHostMatches(tlsHost, host) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tlsHostMatches reports whether a TLS host covers a host. A wildcard only matches a single
+// label, so "*.example.com" matches "app.example.com" but not "example.com" or
+// "a.b.example.com".
+func tlsHostMatches(tlsHost, host string) bool {
+	if strings.EqualFold(tlsHost, host) {
+		return true
+	}
+
+	suffix, ok := strings.CutPrefix(tlsHost, "*.")
+	if !ok {
+		return false
+	}
+	label, rest, found := strings.Cut(host, ".")
+	return found && label != "" && strings.EqualFold(rest, suffix)
+}
+
+// ingressRulePaths returns the requestable paths of an Ingress rule
+func ingressRulePaths(rule networkingv1.IngressRule) []string {
+	if rule.HTTP == nil || len(rule.HTTP.Paths) == 0 {
+		return []string{"/"}
+	}
+
+	var paths []string
+	for _, p := range rule.HTTP.Paths {
+		if path, ok := monitorablePath(p.Path); ok {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *IngressDiscoveryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("ingress-discovery").
+		For(&networkingv1.Ingress{}).
+		Owns(&urlmonitorv1.URLMonitor{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
+
+// HTTPRouteDiscoveryReconciler creates URLMonitors for annotated Gateway API HTTPRoutes.
+// HTTPRoutes are handled as unstructured objects so the Gateway API types aren't a build dependency.
+type HTTPRouteDiscoveryReconciler struct {
+	client.Client
+	Scheme                  *runtime.Scheme
+	Logger                  *slog.Logger
+	KubernetesEventRecorder record.EventRecorder
+}
+
+// NewHTTPRouteDiscoveryReconciler creates a new discovery reconciler for HTTPRoute resources
+func NewHTTPRouteDiscoveryReconciler(client client.Client, scheme *runtime.Scheme, logger *slog.Logger, eventRecorder record.EventRecorder) *HTTPRouteDiscoveryReconciler {
+	return &HTTPRouteDiscoveryReconciler{
+		Client:                  client,
+		Scheme:                  scheme,
+		Logger:                  logger,
+		KubernetesEventRecorder: eventRecorder,
+	}
+}
+
+// HTTPRouteAvailable reports whether the HTTPRoute CRD is installed in the cluster
+func HTTPRouteAvailable(mapper meta.RESTMapper) bool {
+	_, err := mapper.RESTMapping(HTTPRouteGVK.GroupKind(), HTTPRouteGVK.Version)
+	return err == nil
+}
+
+// Reconcile keeps the URLMonitors owned by an HTTPRoute in sync with its rules and annotations
+func (r *HTTPRouteDiscoveryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.FromContext(ctx)
+	reqLogger.Info("Reconciling HTTPRoute for monitor discovery")
+
+	route := newHTTPRoute()
+	err := r.Get(ctx, req.NamespacedName, route)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	var desired []*urlmonitorv1.URLMonitor
+	if discoveryEnabled(route) {
+		desired, err = httpRouteMonitors(route)
+		if err != nil {
+			r.KubernetesEventRecorder.Event(route, "Warning", "InvalidMonitorAnnotations",
+				fmt.Sprintf("Failed to discover URLMonitors: %v", err))
+			return ctrl.Result{}, nil
+		}
+	}
+
+	monitors, err := syncOwnedMonitors(ctx, r.Client, r.Scheme, r.KubernetesEventRecorder, route, LabelHTTPRoute, desired)
+	if err != nil {
+		r.KubernetesEventRecorder.Event(route, "Warning", "MonitorSyncFailed",
+			fmt.Sprintf("Failed to sync discovered URLMonitors: %v", err))
+		return ctrl.Result{}, err
+	}
+
+	r.Logger.Info("Synced discovered URLMonitors",
+		slog.String("httproute", req.String()),
+		slog.Int("monitor_count", len(monitors)))
+
+	return ctrl.Result{}, nil
+}
+
+// httpRouteMonitors builds the URLMonitors for every hostname and path match of an HTTPRoute.
+// HTTPRoutes don't carry TLS settings, so https is assumed unless overridden by annotation.
+func httpRouteMonitors(route *unstructured.Unstructured) ([]*urlmonitorv1.URLMonitor, error) {
+	annotations := route.GetAnnotations()
+	template, err := discoveryTemplate(annotations)
+	if err != nil {
+		return nil, err
+	}
+	scheme, err := discoveryScheme(annotations, SchemeHTTPS)
+	if err != nil {
+		return nil, err
+	}
+
+	hostnames, _, err := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+	if err != nil {
+		return nil, fmt.Errorf("invalid HTTPRoute hostnames: %w", err)
+	}
+
+	paths := discoveryPaths(annotations)
+	if paths == nil {
+		rules, _, err := unstructured.NestedSlice(route.Object, "spec", "rules")
+		if err != nil {
+			return nil, fmt.Errorf("invalid HTTPRoute rules: %w", err)
+		}
+		for _, rule := range rules {
+			matches, _, _ := unstructured.NestedSlice(asObject(rule), "matches")
+			if len(matches) == 0 {
+				paths = append(paths, "/")
+			}
+			for _, match := range matches {
+				matchType, _, _ := unstructured.NestedString(asObject(match), "path", "type")
+				value, _, _ := unstructured.NestedString(asObject(match), "path", "value")
+				if matchType == "RegularExpression" {
+					continue
+				}
+				if path, ok := monitorablePath(value); ok {
+					paths = append(paths, path)
+				}
+			}
+		}
+		if len(rules) == 0 {
+			paths = []string{"/"}
+		}
+	}
+
+	var endpoints []endpoint
+	for _, host := range hostnames {
+		if !monitorableHost(host) {
+			continue
+		}
+		for _, path := range paths {
+			endpoints = append(endpoints, endpoint{scheme: scheme, host: host, path: path})
+		}
+	}
+
+	return discoveredMonitors(route.GetName(), template, endpoints), nil
+}
+
+// newHTTPRoute returns an empty unstructured HTTPRoute
+func newHTTPRoute() *unstructured.Unstructured {
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(HTTPRouteGVK)
+	return route
+}
+
+// asObject returns v as an unstructured object, or an empty one if it isn't a map
+func asObject(v interface{}) map[string]interface{} {
+	obj, _ := v.(map[string]interface{})
+	return obj
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *HTTPRouteDiscoveryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("httproute-discovery").
+		For(newHTTPRoute()).
+		Owns(&urlmonitorv1.URLMonitor{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIngressMonitors(t *testing.T) {
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "shop",
+			Namespace: "default",
+			Annotations: map[string]string{
+				AnnotationEnabled:        "true",
+				AnnotationInterval:       "30",
+				AnnotationExpectedStatus: "200,204",
+				AnnotationLabels:         "team=shop, env=production",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			TLS: []networkingv1.IngressTLS{{Hosts: []string{"shop.example.com"}}},
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: "shop.example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{Path: "/"}, {Path: "/api"}, {Path: "/static/(.*)"}},
+					}},
+				},
+				{Host: "internal.example.com"},
+				{Host: "*.example.com"},
+			},
+		},
+	}
+
+	monitors, err := ingressMonitors(ingress)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	urls := make(map[string]bool)
+	for _, m := range monitors {
+		urls[m.Spec.URL] = true
+		if m.Spec.Interval != 30 {
+			t.Errorf("Expected interval 30, got %d", m.Spec.Interval)
+		}
+		if len(m.Spec.ExpectedStatus) != 2 || m.Spec.ExpectedStatus[1] != 204 {
+			t.Errorf("Expected status codes [200 204], got %v", m.Spec.ExpectedStatus)
+		}
+		if m.Spec.Labels["team"] != "shop" || m.Spec.Labels["env"] != "production" {
+			t.Errorf("Expected labels from annotation, got %v", m.Spec.Labels)
+		}
+	}
+
+	expected := []string{"https://shop.example.com/", "https://shop.example.com/api", "http://internal.example.com/"}
+	if len(urls) != len(expected) {
+		t.Errorf("Expected %d monitors, got %v", len(expected), urls)
+	}
+	for _, u := range expected {
+		if !urls[u] {
+			t.Errorf("Expected a monitor for %s, got %v", u, urls)
+		}
+	}
+}
+
+func TestIngressMonitors_WildcardTLS(t *testing.T) {
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "shop",
+			Annotations: map[string]string{AnnotationEnabled: "true"},
+		},
+		Spec: networkingv1.IngressSpec{
+			TLS: []networkingv1.IngressTLS{{Hosts: []string{"*.example.com"}}},
+			Rules: []networkingv1.IngressRule{
+				{Host: "shop.example.com"},
+				{Host: "example.com"},
+				{Host: "api.shop.example.com"},
+			},
+		},
+	}
+
+	monitors, err := ingressMonitors(ingress)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	urls := make(map[string]bool)
+	for _, m := range monitors {
+		urls[m.Spec.URL] = true
+	}
+	expected := []string{"https://shop.example.com/", "http://example.com/", "http://api.shop.example.com/"}
+	if len(urls) != len(expected) {
+		t.Errorf("Expected %d monitors, got %v", len(expected), urls)
+	}
+	for _, u := range expected {
+		if !urls[u] {
+			t.Errorf("Expected a monitor for %s, got %v", u, urls)
+		}
+	}
+}
+
+func TestIngressMonitors_PathOverride(t *testing.T) {
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "shop",
+			Annotations: map[string]string{
+				AnnotationEnabled: "true",
+				AnnotationPath:    "/health,ready",
+				AnnotationScheme:  "https",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{Host: "shop.example.com"}},
+		},
+	}
+
+	monitors, err := ingressMonitors(ingress)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(monitors) != 2 {
+		t.Fatalf("Expected 2 monitors, got %d", len(monitors))
+	}
+	if monitors[0].Spec.URL != "https://shop.example.com/health" || monitors[1].Spec.URL != "https://shop.example.com/ready" {
+		t.Errorf("Expected overridden paths, got %s and %s", monitors[0].Spec.URL, monitors[1].Spec.URL)
+	}
+}
+
+func TestIngressMonitors_InvalidAnnotation(t *testing.T) {
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "shop",
+			Annotations: map[string]string{AnnotationInterval: "often"},
+		},
+	}
+
+	if _, err := ingressMonitors(ingress); err == nil {
+		t.Errorf("Expected an error for invalid interval annotation")
+	}
+}
+
+func TestHTTPRouteMonitors(t *testing.T) {
+	route := newHTTPRoute()
+	route.SetName("api")
+	route.SetAnnotations(map[string]string{AnnotationEnabled: "true"})
+	route.Object["spec"] = map[string]interface{}{
+		"hostnames": []interface{}{"api.example.com"},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"matches": []interface{}{
+					map[string]interface{}{"path": map[string]interface{}{"type": "PathPrefix", "value": "/v1"}},
+					map[string]interface{}{"path": map[string]interface{}{"type": "RegularExpression", "value": "/v[0-9]+"}},
+				},
+			},
+		},
+	}
+
+	monitors, err := httpRouteMonitors(route)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(monitors) != 1 {
+		t.Fatalf("Expected 1 monitor, got %d", len(monitors))
+	}
+	if monitors[0].Spec.URL != "https://api.example.com/v1" {
+		t.Errorf("Expected URL 'https://api.example.com/v1', got '%s'", monitors[0].Spec.URL)
+	}
+}
@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/certcheck"
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+	"github.com/kuskoman/url-datadog-monitor/pkg/monitor"
+)
+
+// notifyCheck tells the Notifier, when set, about a check of a monitor. Monitors are named
+// like on the status page, since monitors of different namespaces may share a name.
+func (r *URLMonitorReconciler) notifyCheck(urlMonitor monitoredResource, target config.Target, at time.Time, result monitor.Result, health monitor.Health, cert *certcheck.CertificateDetails) {
+	if r.Notifier == nil {
+		return
+	}
+	name := statusTarget(urlMonitor, target).Name
+	r.Notifier.ObserveCheck(name, target, monitor.NotifierCheck(target, at, result, health))
+	if cert != nil {
+		r.Notifier.ObserveCertificate(name, target, monitor.NotifierCertificate(at, cert))
+	}
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	urlmonitorv1 "github.com/kuskoman/url-datadog-monitor/pkg/api/v1"
+	"github.com/kuskoman/url-datadog-monitor/pkg/certcheck"
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+	"github.com/kuskoman/url-datadog-monitor/pkg/monitor"
+	"github.com/kuskoman/url-datadog-monitor/pkg/notifier"
+)
+
+func TestNotifyCheck(t *testing.T) {
+	var mu sync.Mutex
+	var transitions []notifier.Transition
+	webhook := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		var transition notifier.Transition
+		_ = json.NewDecoder(r.Body).Decode(&transition)
+		mu.Lock()
+		defer mu.Unlock()
+		transitions = append(transitions, transition)
+	}))
+	defer webhook.Close()
+
+	dispatcher, err := notifier.NewDispatcher([]config.Notifier{{URL: webhook.URL, Timeout: 5}}, monitor.NopLogger())
+	if err != nil {
+		t.Fatalf("Failed to create dispatcher: %v", err)
+	}
+	defer dispatcher.Close()
+
+	scheme := newTestScheme(t)
+	r := NewURLMonitorReconciler(fake.NewClientBuilder().WithScheme(scheme).Build(), scheme, nil, monitor.NopLogger(), record.NewFakeRecorder(10))
+	r.Notifier = dispatcher
+
+	urlMonitor := &urlmonitorv1.URLMonitor{ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"}}
+	target, _ := targetFromSpec("example", &urlmonitorv1.URLMonitorSpec{URL: "https://example.com"})
+	now := time.Now()
+	r.notifyCheck(urlMonitor, target, now, monitor.Result{Status: 500}, monitor.HealthDown,
+		&certcheck.CertificateDetails{IsValid: true, NotAfter: now.Add(5 * 24 * time.Hour)})
+	dispatcher.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(transitions) != 2 {
+		t.Fatalf("Expected a down and a cert_expiring notification, got %+v", transitions)
+	}
+	if transitions[0].Event != config.NotifierEventDown || transitions[0].Target != "default/example" || transitions[0].StatusCode != 500 {
+		t.Errorf("Expected default/example to be down, got %+v", transitions[0])
+	}
+	if transitions[1].Event != config.NotifierEventCertExpiring {
+		t.Errorf("Expected the certificate to be expiring, got %+v", transitions[1])
+	}
+}
@@ -0,0 +1,244 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	urlmonitorv1 "github.com/kuskoman/url-datadog-monitor/pkg/api/v1"
+)
+
+const (
+	// LabelService is set on URLMonitors discovered from a Service
+	LabelService = "url-datadog-monitor.kuskoman.github.com/service"
+
+	// LabelPod is the metric label identifying the pod behind a per-endpoint monitor
+	LabelPod = "pod"
+
+	// DefaultClusterDomain is the DNS domain of the cluster used when none is configured
+	DefaultClusterDomain = "cluster.local"
+)
+
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
+
+// ServiceDiscoveryReconciler creates URLMonitors for annotated Services
+// and, optionally, for each of their ready endpoints
+type ServiceDiscoveryReconciler struct {
+	client.Client
+	Scheme                  *runtime.Scheme
+	Logger                  *slog.Logger
+	KubernetesEventRecorder record.EventRecorder
+	// ClusterDomain is the DNS domain of the cluster, DefaultClusterDomain when empty
+	ClusterDomain string
+}
+
+// NewServiceDiscoveryReconciler creates a new discovery reconciler for Service resources
+func NewServiceDiscoveryReconciler(client client.Client, scheme *runtime.Scheme, logger *slog.Logger, eventRecorder record.EventRecorder) *ServiceDiscoveryReconciler {
+	return &ServiceDiscoveryReconciler{
+		Client:                  client,
+		Scheme:                  scheme,
+		Logger:                  logger,
+		KubernetesEventRecorder: eventRecorder,
+	}
+}
+
+// Reconcile keeps the URLMonitors owned by a Service in sync with its ports, endpoints and annotations
+func (r *ServiceDiscoveryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.FromContext(ctx)
+	reqLogger.Info("Reconciling Service for monitor discovery")
+
+	service := &corev1.Service{}
+	err := r.Get(ctx, req.NamespacedName, service)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// Discovered URLMonitors are garbage collected through their owner references
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	var desired []*urlmonitorv1.URLMonitor
+	if discoveryEnabled(service) {
+		var slices []discoveryv1.EndpointSlice
+		if perEndpoint, _ := strconv.ParseBool(service.Annotations[AnnotationPerEndpoint]); perEndpoint {
+			sliceList := &discoveryv1.EndpointSliceList{}
+			err := r.List(ctx, sliceList,
+				client.InNamespace(service.Namespace),
+				client.MatchingLabels{discoveryv1.LabelServiceName: service.Name})
+			if err != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to list EndpointSlices: %w", err)
+			}
+			slices = sliceList.Items
+		}
+
+		clusterDomain := r.ClusterDomain
+		if clusterDomain == "" {
+			clusterDomain = DefaultClusterDomain
+		}
+		desired, err = serviceMonitors(service, slices, clusterDomain)
+		if err != nil {
+			r.KubernetesEventRecorder.Event(service, "Warning", "InvalidMonitorAnnotations",
+				fmt.Sprintf("Failed to discover URLMonitors: %v", err))
+			return ctrl.Result{}, nil
+		}
+	}
+
+	monitors, err := syncOwnedMonitors(ctx, r.Client, r.Scheme, r.KubernetesEventRecorder, service, LabelService, desired)
+	if err != nil {
+		r.KubernetesEventRecorder.Event(service, "Warning", "MonitorSyncFailed",
+			fmt.Sprintf("Failed to sync discovered URLMonitors: %v", err))
+		return ctrl.Result{}, err
+	}
+
+	r.Logger.Info("Synced discovered URLMonitors",
+		slog.String("service", req.String()),
+		slog.Int("monitor_count", len(monitors)))
+
+	return ctrl.Result{}, nil
+}
+
+// serviceMonitors builds the URLMonitor for the in-cluster DNS name of a Service
+// and one URLMonitor for every ready endpoint in slices. Endpoints checked over https
+// keep the Service name in the URL and connect to the pod through a resolve override,
+// so the certificate of the Service is verified against its name.
+func serviceMonitors(service *corev1.Service, slices []discoveryv1.EndpointSlice, clusterDomain string) ([]*urlmonitorv1.URLMonitor, error) {
+	template, err := discoveryTemplate(service.Annotations)
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := servicePort(service)
+	if err != nil {
+		return nil, err
+	}
+
+	fallbackScheme := SchemeHTTP
+	if port.Port == 443 || strings.EqualFold(port.Name, SchemeHTTPS) {
+		fallbackScheme = SchemeHTTPS
+	}
+	scheme, err := discoveryScheme(service.Annotations, fallbackScheme)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := discoveryPaths(service.Annotations)
+	if paths == nil {
+		paths = []string{"/"}
+	}
+
+	host := net.JoinHostPort(fmt.Sprintf("%s.%s.svc.%s", service.Name, service.Namespace, clusterDomain), strconv.Itoa(int(port.Port)))
+	var endpoints []endpoint
+	for _, path := range paths {
+		endpoints = append(endpoints, endpoint{
+			scheme: scheme,
+			host:   host,
+			path:   path,
+		})
+	}
+
+	for _, slice := range slices {
+		targetPort, ok := sliceTargetPort(slice, port.Name)
+		if !ok {
+			continue
+		}
+		for _, ep := range slice.Endpoints {
+			if ep.Conditions.Ready != nil && !*ep.Conditions.Ready {
+				continue
+			}
+
+			var labels map[string]string
+			if ep.TargetRef != nil && ep.TargetRef.Kind == "Pod" {
+				labels = map[string]string{LabelPod: ep.TargetRef.Name}
+			}
+			for _, address := range ep.Addresses {
+				podAddress := net.JoinHostPort(address, strconv.Itoa(int(targetPort)))
+				for _, path := range paths {
+					e := endpoint{
+						scheme: scheme,
+						host:   podAddress,
+						path:   path,
+						labels: labels,
+					}
+					if scheme == SchemeHTTPS {
+						e.host = host
+						e.connectTo = podAddress
+					}
+					endpoints = append(endpoints, e)
+				}
+			}
+		}
+	}
+
+	return discoveredMonitors(service.Name, template, endpoints), nil
+}
+
+// servicePort returns the annotated Service port, or the first port without annotation
+func servicePort(service *corev1.Service) (corev1.ServicePort, error) {
+	if len(service.Spec.Ports) == 0 {
+		return corev1.ServicePort{}, fmt.Errorf("service has no ports")
+	}
+
+	value, ok := service.Annotations[AnnotationPort]
+	if !ok {
+		return service.Spec.Ports[0], nil
+	}
+
+	value = strings.TrimSpace(value)
+	for _, port := range service.Spec.Ports {
+		if port.Name == value || strconv.Itoa(int(port.Port)) == value {
+			return port, nil
+		}
+	}
+	return corev1.ServicePort{}, fmt.Errorf("invalid %s annotation %q: service has no such port", AnnotationPort, value)
+}
+
+// sliceTargetPort returns the endpoint port of an EndpointSlice matching a Service port name
+func sliceTargetPort(slice discoveryv1.EndpointSlice, name string) (int32, bool) {
+	for _, port := range slice.Ports {
+		portName := ""
+		if port.Name != nil {
+			portName = *port.Name
+		}
+		if portName == name && port.Port != nil {
+			return *port.Port, true
+		}
+	}
+	return 0, false
+}
+
+// endpointSliceToService maps an EndpointSlice to the Service it belongs to
+func endpointSliceToService(_ context.Context, obj client.Object) []reconcile.Request {
+	name, ok := obj.GetLabels()[discoveryv1.LabelServiceName]
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}}}
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *ServiceDiscoveryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("service-discovery").
+		For(&corev1.Service{}).
+		Owns(&urlmonitorv1.URLMonitor{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&discoveryv1.EndpointSlice{}, handler.EnqueueRequestsFromMapFunc(endpointSliceToService)).
+		Complete(r)
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestServiceMonitors(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "orders",
+			Namespace: "shop",
+			Annotations: map[string]string{
+				AnnotationEnabled:     "true",
+				AnnotationPath:        "/healthz",
+				AnnotationPort:        "http",
+				AnnotationPerEndpoint: "true",
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{Name: "grpc", Port: 9090},
+				{Name: "http", Port: 80},
+			},
+		},
+	}
+
+	ready := true
+	notReady := false
+	portName := "http"
+	port := int32(8080)
+	slices := []discoveryv1.EndpointSlice{
+		{
+			Ports: []discoveryv1.EndpointPort{{Name: &portName, Port: &port}},
+			Endpoints: []discoveryv1.Endpoint{
+				{
+					Addresses:  []string{"10.0.0.1"},
+					Conditions: discoveryv1.EndpointConditions{Ready: &ready},
+					TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: "orders-abc"},
+				},
+				{
+					Addresses:  []string{"10.0.0.2"},
+					Conditions: discoveryv1.EndpointConditions{Ready: &notReady},
+					TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: "orders-def"},
+				},
+			},
+		},
+	}
+
+	monitors, err := serviceMonitors(service, slices, DefaultClusterDomain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(monitors) != 2 {
+		t.Fatalf("Expected 2 monitors, got %d", len(monitors))
+	}
+
+	byURL := make(map[string]map[string]string)
+	for _, m := range monitors {
+		byURL[m.Spec.URL] = m.Spec.Labels
+	}
+
+	if _, ok := byURL["http://orders.shop.svc.cluster.local:80/healthz"]; !ok {
+		t.Errorf("Expected a monitor for the service DNS name, got %v", byURL)
+	}
+	labels, ok := byURL["http://10.0.0.1:8080/healthz"]
+	if !ok {
+		t.Fatalf("Expected a monitor for the ready endpoint, got %v", byURL)
+	}
+	if labels[LabelPod] != "orders-abc" {
+		t.Errorf("Expected pod label 'orders-abc', got '%s'", labels[LabelPod])
+	}
+}
+
+func TestServiceMonitors_UnknownPort(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "orders",
+			Namespace:   "shop",
+			Annotations: map[string]string{AnnotationEnabled: "true", AnnotationPort: "metrics"},
+		},
+		Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "http", Port: 80}}},
+	}
+
+	if _, err := serviceMonitors(service, nil, DefaultClusterDomain); err == nil {
+		t.Errorf("Expected an error for unknown port annotation")
+	}
+}
+
+func TestServiceMonitors_HTTPSPort(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "gateway",
+			Namespace:   "edge",
+			Annotations: map[string]string{AnnotationEnabled: "true"},
+		},
+		Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 443}}},
+	}
+
+	monitors, err := serviceMonitors(service, nil, DefaultClusterDomain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(monitors) != 1 || monitors[0].Spec.URL != "https://gateway.edge.svc.cluster.local:443/" {
+		t.Errorf("Expected a single https monitor, got %v", monitors)
+	}
+}
+
+func TestServiceMonitors_HTTPSEndpoints(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "gateway",
+			Namespace:   "edge",
+			Annotations: map[string]string{AnnotationEnabled: "true", AnnotationPerEndpoint: "true"},
+		},
+		Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "https", Port: 443}}},
+	}
+
+	portName := "https"
+	port := int32(8443)
+	slices := []discoveryv1.EndpointSlice{
+		{
+			Ports: []discoveryv1.EndpointPort{{Name: &portName, Port: &port}},
+			Endpoints: []discoveryv1.Endpoint{
+				{Addresses: []string{"10.0.0.1"}},
+				{Addresses: []string{"10.0.0.2"}},
+			},
+		},
+	}
+
+	monitors, err := serviceMonitors(service, slices, "example.internal")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(monitors) != 3 {
+		t.Fatalf("Expected a service and 2 endpoint monitors, got %d", len(monitors))
+	}
+
+	resolved := make(map[string]bool)
+	for _, m := range monitors {
+		if m.Spec.URL != "https://gateway.edge.svc.example.internal:443/" {
+			t.Errorf("Expected monitors to request the service name, got %s", m.Spec.URL)
+		}
+		for host, address := range m.Spec.Resolve {
+			if host != "gateway.edge.svc.example.internal:443" {
+				t.Errorf("Expected the service address to be resolved, got %s", host)
+			}
+			resolved[address] = true
+		}
+	}
+	if !resolved["10.0.0.1:8443"] || !resolved["10.0.0.2:8443"] {
+		t.Errorf("Expected endpoint monitors to connect to the pods, got %v", resolved)
+	}
+}
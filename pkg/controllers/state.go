@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/state"
+)
+
+// StateSaveInterval is how often reconcilers with a StateStore save the state of their monitors
+const StateSaveInterval = time.Minute
+
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;create;update
+
+// statePrefix returns the prefix of the snapshot names of the reconciled kind, like "urlmonitor."
+func (r *URLMonitorReconciler) statePrefix() string {
+	return strings.ToLower(r.kind) + "."
+}
+
+// trackers returns the state of the monitors carried over between checks
+func (r *URLMonitorReconciler) trackers() state.Trackers {
+	return r.runner.Trackers()
+}
+
+// LoadState restores the certificate serials, content hashes and SLO counts saved by SaveState.
+// Monitors seeded from their status or checked since keep their state.
+func (r *URLMonitorReconciler) LoadState(ctx context.Context) error {
+	return r.trackers().Load(ctx, r.StateStore, r.statePrefix())
+}
+
+// SaveState saves the certificate serials, content hashes and SLO counts of all monitors.
+// SLO counts that don't fit in the store are left out, which is logged once.
+func (r *URLMonitorReconciler) SaveState(ctx context.Context) error {
+	trimmed, err := r.trackers().Save(ctx, r.StateStore, r.statePrefix())
+	if trimmed {
+		r.trimmedState.Do(func() {
+			r.Logger.Warn("Saved " + r.kind + " state without some SLO counts, which don't fit in the state store")
+		})
+	}
+	return err
+}
+
+// persistState loads the saved state, then saves it every StateSaveInterval and once more
+// when the manager stops. It runs on the leader only.
+func (r *URLMonitorReconciler) persistState(ctx context.Context) error {
+	if err := r.LoadState(ctx); err != nil {
+		r.Logger.Error("Failed to load "+r.kind+" state", slog.Any("error", err))
+	}
+
+	ticker := time.NewTicker(StateSaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// The manager context is done, give the final save its own deadline
+			saveCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := r.SaveState(saveCtx); err != nil {
+				r.Logger.Error("Failed to save "+r.kind+" state", slog.Any("error", err))
+			}
+			return nil
+		case <-ticker.C:
+			if err := r.SaveState(ctx); err != nil {
+				r.Logger.Warn("Failed to save "+r.kind+" state", slog.Any("error", err))
+			}
+		}
+	}
+}
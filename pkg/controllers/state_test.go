@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/monitor"
+	"github.com/kuskoman/url-datadog-monitor/pkg/state"
+)
+
+func TestReconciler_StateStore(t *testing.T) {
+	ctx := context.Background()
+	scheme := newTestScheme(t)
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	store := state.NewConfigMapStore(c, nil, "monitoring", "url-monitor-state")
+
+	r := NewURLMonitorReconciler(c, scheme, nil, monitor.NopLogger(), record.NewFakeRecorder(10))
+	r.StateStore = store
+	r.runner.Seed("default/example", "1", "")
+	r.runner.Seed("default/status", "", "abc")
+	if err := r.SaveState(ctx); err != nil {
+		t.Fatalf("Failed to save state: %v", err)
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "monitoring", Name: "url-monitor-state"}, configMap); err != nil {
+		t.Fatalf("Expected the state ConfigMap to be created: %v", err)
+	}
+	if _, ok := configMap.BinaryData["urlmonitor.serials"]; !ok {
+		t.Errorf("Expected the serials under a key of the kind, got %v", configMap.BinaryData)
+	}
+
+	// The cluster reconciler shares the ConfigMap without reading the namespaced state
+	cluster := NewClusterURLMonitorReconciler(c, scheme, nil, monitor.NopLogger(), record.NewFakeRecorder(10))
+	cluster.StateStore = store
+	if err := cluster.LoadState(ctx); err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+	if serials := cluster.runner.Trackers().Serials.Snapshot(); len(serials) != 0 {
+		t.Errorf("Expected the cluster reconciler not to restore URLMonitor serials")
+	}
+
+	restarted := NewURLMonitorReconciler(c, scheme, nil, monitor.NopLogger(), record.NewFakeRecorder(10))
+	restarted.StateStore = store
+	if err := restarted.LoadState(ctx); err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+	if serial := restarted.runner.Trackers().Serials.Snapshot()["default/example"]; serial != "1" {
+		t.Errorf("Expected the serial to be restored, got %q", serial)
+	}
+	if hash := restarted.runner.Trackers().ContentHashes.Snapshot()["default/status"]; hash != "abc" {
+		t.Errorf("Expected the content hash to be restored, got %q", hash)
+	}
+}
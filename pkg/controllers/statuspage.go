@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/certcheck"
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+	"github.com/kuskoman/url-datadog-monitor/pkg/monitor"
+)
+
+// statusName returns the name of a monitor in the Statuses tracker: "namespace/name", or the
+// name of cluster-scoped monitors
+func statusName(monitorKey string) string {
+	return strings.TrimPrefix(monitorKey, "/")
+}
+
+// statusTarget returns the target of a monitor named after its key, since monitors of different
+// namespaces may share a name
+func statusTarget(urlMonitor monitoredResource, target config.Target) config.Target {
+	target.Name = statusName(urlMonitor.GetNamespace() + "/" + urlMonitor.GetName())
+	return target
+}
+
+// registerStatus adds a monitor to the Statuses tracker, when set, and schedules its first check
+func (r *URLMonitorReconciler) registerStatus(urlMonitor monitoredResource, target config.Target) {
+	if r.Statuses == nil {
+		return
+	}
+	target = statusTarget(urlMonitor, target)
+	r.Statuses.Register(target)
+	r.Statuses.Schedule(target, time.Now().Add(time.Duration(target.Interval)*time.Second))
+}
+
+// recordStatus records a check of a monitor in the Statuses tracker, when set
+func (r *URLMonitorReconciler) recordStatus(urlMonitor monitoredResource, target config.Target, at time.Time, result monitor.Result, health monitor.Health, cert *certcheck.CertificateDetails) {
+	if r.Statuses == nil {
+		return
+	}
+	target = statusTarget(urlMonitor, target)
+	r.Statuses.Record(target, at, result, health)
+	if cert != nil {
+		r.Statuses.ObserveCertificate(target, at, cert)
+	}
+	r.Statuses.Schedule(target, at.Add(time.Duration(target.Interval)*time.Second))
+}
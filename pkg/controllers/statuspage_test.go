@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	urlmonitorv1 "github.com/kuskoman/url-datadog-monitor/pkg/api/v1"
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+	"github.com/kuskoman/url-datadog-monitor/pkg/monitor"
+)
+
+func TestRecordStatus(t *testing.T) {
+	scheme := newTestScheme(t)
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	statuses := monitor.NewStatusTracker(config.DefaultHistorySize)
+
+	r := NewURLMonitorReconciler(c, scheme, nil, monitor.NopLogger(), record.NewFakeRecorder(10))
+	r.Statuses = statuses
+	cluster := NewClusterURLMonitorReconciler(c, scheme, nil, monitor.NopLogger(), record.NewFakeRecorder(10))
+	cluster.Statuses = statuses
+
+	spec := &urlmonitorv1.URLMonitorSpec{URL: "https://example.com", Interval: 60}
+	target, _ := targetFromSpec("example", spec)
+	now := time.Now()
+	for _, urlMonitor := range []monitoredResource{
+		&urlmonitorv1.URLMonitor{ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"}},
+		&urlmonitorv1.URLMonitor{ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "staging"}},
+	} {
+		r.registerStatus(urlMonitor, target)
+		r.recordStatus(urlMonitor, target, now, monitor.Result{Up: true, Status: 200}, monitor.HealthUp, nil)
+	}
+	clusterMonitor := &urlmonitorv1.ClusterURLMonitor{ObjectMeta: metav1.ObjectMeta{Name: "example"}}
+	cluster.registerStatus(clusterMonitor, target)
+
+	got := statuses.Statuses()
+	if len(got) != 3 || got[0].Name != "default/example" || got[1].Name != "staging/example" || got[2].Name != "example" {
+		t.Fatalf("Expected monitors to be tracked by namespace, got %+v", got)
+	}
+	if got[0].LastCheck == nil || !got[0].NextCheck.Equal(now.Add(time.Minute)) {
+		t.Errorf("Expected the check and the next one to be recorded, got %+v", got[0])
+	}
+
+	// Deleted monitors are dropped from the page
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "staging", Name: "example"}}); err != nil {
+		t.Fatalf("Failed to reconcile: %v", err)
+	}
+	if _, err := cluster.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "example"}}); err != nil {
+		t.Fatalf("Failed to reconcile: %v", err)
+	}
+	if got := statuses.Statuses(); len(got) != 1 || got[0].Name != "default/example" {
+		t.Errorf("Expected only default/example to remain, got %+v", got)
+	}
+}
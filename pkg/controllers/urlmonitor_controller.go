@@ -4,25 +4,52 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	urlmonitorv1 "github.com/kuskoman/url-datadog-monitor/pkg/api/v1"
 	"github.com/kuskoman/url-datadog-monitor/pkg/certcheck"
 	"github.com/kuskoman/url-datadog-monitor/pkg/config"
 	"github.com/kuskoman/url-datadog-monitor/pkg/exporter"
 	"github.com/kuskoman/url-datadog-monitor/pkg/monitor"
+	"github.com/kuskoman/url-datadog-monitor/pkg/notifier"
+	"github.com/kuskoman/url-datadog-monitor/pkg/slo"
+	"github.com/kuskoman/url-datadog-monitor/pkg/state"
 )
 
+const (
+	// ConditionCertificateWeak is true when the presented certificate chain uses weak cryptography
+	ConditionCertificateWeak = "CertificateWeak"
+	ReasonWeakCryptography   = "WeakCryptography"
+	ReasonStrongCryptography = "StrongCryptography"
+
+	// DefaultCASecretKey is the Secret key holding CA certificates when a caSecret doesn't name one
+	DefaultCASecretKey = urlmonitorv1.DefaultCASecretKey
+)
+
+// monitoredResource is implemented by every resource kind carrying a URLMonitorSpec,
+// so URLMonitor and ClusterURLMonitor share the same reconciliation logic
+type monitoredResource interface {
+	client.Object
+	MonitorSpec() *urlmonitorv1.URLMonitorSpec
+	MonitorStatus() *urlmonitorv1.URLMonitorStatus
+}
+
 // URLMonitorReconciler reconciles a URLMonitor object
 type URLMonitorReconciler struct {
 	client.Client
@@ -30,49 +57,88 @@ type URLMonitorReconciler struct {
 	MetricsClient       exporter.MetricsExporter
 	Logger              *slog.Logger
 	KubernetesEventRecorder record.EventRecorder
+	// APIReader reads Secrets directly from the API server, so the operator doesn't
+	// need to cache every Secret of the cluster. The client is used when nil.
+	APIReader client.Reader
+	// StateStore persists the certificate serials, content hashes and SLO counts of all
+	// monitors across operator restarts when set. Without it serials and content hashes are
+	// seeded from the status and SLO counts start over.
+	StateStore state.Store
+	// Statuses keeps the recent checks of all monitors for the status page when set
+	Statuses *monitor.StatusTracker
+	// Notifier sends the state transitions of monitors to webhooks when set
+	Notifier *notifier.Dispatcher
+
+	// kind is the name of the reconciled resource kind, used in logs
+	kind string
+	// newObject returns an empty instance of the reconciled resource kind
+	newObject func() monitoredResource
 
 	// Map to track active monitors
 	monitors     map[string]context.CancelFunc
 	monitorsLock sync.Mutex
+
+	// runner checks the monitors and keeps their serials, content hashes and SLO counts
+	// under their "namespace/name" key
+	runner *monitor.Runner
+	// trimmedState logs the first save that left SLO counts out to fit the state store
+	trimmedState sync.Once
 }
 
 // NewURLMonitorReconciler creates a new reconciler for URLMonitor resources
 func NewURLMonitorReconciler(client client.Client, scheme *runtime.Scheme, metricsClient exporter.MetricsExporter, logger *slog.Logger, eventRecorder record.EventRecorder) *URLMonitorReconciler {
+	return newMonitorReconciler("URLMonitor", func() monitoredResource { return &urlmonitorv1.URLMonitor{} },
+		client, scheme, metricsClient, logger, eventRecorder)
+}
+
+// newMonitorReconciler creates a reconciler for the resource kind produced by newObject
+func newMonitorReconciler(kind string, newObject func() monitoredResource, client client.Client, scheme *runtime.Scheme, metricsClient exporter.MetricsExporter, logger *slog.Logger, eventRecorder record.EventRecorder) *URLMonitorReconciler {
 	return &URLMonitorReconciler{
 		Client:                client,
 		Scheme:                scheme,
 		MetricsClient:         metricsClient,
 		Logger:                logger,
 		KubernetesEventRecorder: eventRecorder,
+		kind:                  kind,
+		newObject:             newObject,
 		monitors:              make(map[string]context.CancelFunc),
+		runner:                monitor.NewRunner(metricsClient, logger),
 	}
 }
 
 // +kubebuilder:rbac:groups=url-datadog-monitor.kuskoman.github.com,resources=urlmonitors,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=url-datadog-monitor.kuskoman.github.com,resources=urlmonitors/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
 
 // Reconcile implements the reconciliation loop for URLMonitor resources
 func (r *URLMonitorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	reqLogger := log.FromContext(ctx)
-	reqLogger.Info("Reconciling URLMonitor")
+	reqLogger.Info("Reconciling " + r.kind)
 
 	// Fetch the URLMonitor instance
-	urlMonitor := &urlmonitorv1.URLMonitor{}
+	urlMonitor := r.newObject()
 	err := r.Get(ctx, req.NamespacedName, urlMonitor)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request
 			monitorKey := req.String()
-			r.Logger.Info("URLMonitor resource was deleted", slog.String("monitor", monitorKey))
+			r.Logger.Info(r.kind+" resource was deleted", slog.String("monitor", monitorKey))
 			
 			// We can't record a K8s event for a deleted object, but we can log it
 			r.stopMonitoring(monitorKey)
+			r.runner.Forget(monitorKey)
+			if r.Statuses != nil {
+				r.Statuses.Forget(statusName(monitorKey))
+			}
+			if r.Notifier != nil {
+				r.Notifier.Forget(statusName(monitorKey))
+			}
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object - requeue the request
 		r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "ReconcileError", 
-			fmt.Sprintf("Failed to reconcile URLMonitor: %v", err))
+			fmt.Sprintf("Failed to reconcile %s: %v", r.kind, err))
 		return ctrl.Result{}, err
 	}
 
@@ -83,11 +149,25 @@ func (r *URLMonitorReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 }
 
 // startOrUpdateMonitoring starts or updates monitoring for a URLMonitor resource
-func (r *URLMonitorReconciler) startOrUpdateMonitoring(ctx context.Context, urlMonitor *urlmonitorv1.URLMonitor) {
-	monitorKey := fmt.Sprintf("%s/%s", urlMonitor.Namespace, urlMonitor.Name)
+func (r *URLMonitorReconciler) startOrUpdateMonitoring(ctx context.Context, urlMonitor monitoredResource) {
+	monitorKey := fmt.Sprintf("%s/%s", urlMonitor.GetNamespace(), urlMonitor.GetName())
+	spec := urlMonitor.MonitorSpec()
 
 	r.stopMonitoring(monitorKey)
 
+	target, err := targetFromSpec(urlMonitor.GetName(), spec)
+	if err != nil {
+		r.reportError(ctx, urlMonitor, "InvalidSpec", fmt.Sprintf("Can't monitor %s: %v", spec.URL, err), err)
+		return
+	}
+
+	// Seed the rotation tracker so a rotation during an operator restart is still noticed
+	var serial string
+	if cert := urlMonitor.MonitorStatus().Certificate; cert != nil {
+		serial = cert.SerialNumber
+	}
+	r.runner.Seed(monitorKey, serial, urlMonitor.MonitorStatus().ContentHash)
+
 	monitorCtx, cancel := context.WithCancel(context.Background())
 
 	r.monitorsLock.Lock()
@@ -96,24 +176,108 @@ func (r *URLMonitorReconciler) startOrUpdateMonitoring(ctx context.Context, urlM
 
 	// Record an event for the monitoring start
 	r.KubernetesEventRecorder.Event(urlMonitor, "Normal", "MonitoringStarted", 
-		fmt.Sprintf("Starting URL monitoring for %s with %d second interval", urlMonitor.Spec.URL, urlMonitor.Spec.Interval))
+		fmt.Sprintf("Starting URL monitoring for %s with %d second interval", spec.URL, spec.Interval))
 
 	// Start monitoring in a separate goroutine
-	go func() {
-		target := config.Target{
-			Name:       urlMonitor.Name,
-			URL:        urlMonitor.Spec.URL,
-			Method:     urlMonitor.Spec.Method,
-			Interval:   urlMonitor.Spec.Interval,
-			Timeout:    urlMonitor.Spec.Timeout,
-			Headers:    urlMonitor.Spec.Headers,
-			Labels:     urlMonitor.Spec.Labels,
-			CheckCert:  urlMonitor.Spec.CheckCert,
-			VerifyCert: urlMonitor.Spec.VerifyCert,
+	go r.monitorURL(monitorCtx, urlMonitor, target)
+}
+
+// targetFromSpec converts a URLMonitorSpec into the target checked by the monitor package
+func targetFromSpec(name string, spec *urlmonitorv1.URLMonitorSpec) (config.Target, error) {
+	target := config.Target{
+		Name:             name,
+		URL:              spec.URL,
+		Method:           spec.Method,
+		Interval:         spec.Interval,
+		Timeout:          spec.Timeout,
+		Headers:          spec.Headers,
+		Labels:           spec.Labels,
+		CheckCert:        spec.CheckCert,
+		VerifyCert:       spec.VerifyCert,
+		ExpectedStatus:   spec.ExpectedStatus,
+		CertFingerprints: spec.CertFingerprints,
+		CheckRevocation:  spec.CheckRevocation,
+		ServerName:       spec.ServerName,
+		ExpectedDNSNames: spec.ExpectedDNSNames,
+		Resolve:          spec.Resolve,
+		DNSServer:        spec.DNSServer,
+		IPFamily:         spec.IPFamily,
+		Proxy:            spec.Proxy,
+		ExpectedFinalURL: spec.ExpectedFinalURL,
+		Protocol:         spec.Protocol,
+		DetectChanges:    spec.DetectChanges,
+
+		CheckRedirectCerts:  spec.CheckRedirectCertificates,
+		ChangeIgnoreRegexes: spec.ChangeIgnoreRegexes,
+		LatencyWarningMs:    spec.LatencyWarningMs,
+		LatencyCriticalMs:   spec.LatencyCriticalMs,
+	}
+
+	for _, assertion := range spec.HeaderAssertions {
+		target.HeaderAssertions = append(target.HeaderAssertions, config.HeaderAssertion{
+			Header:  assertion.Header,
+			Present: assertion.Present,
+			Absent:  assertion.Absent,
+			Equals:  assertion.Equals,
+			Regex:   assertion.Regex,
+		})
+	}
+	securityAudit := spec.SecurityAudit
+	target.SecurityAudit = &securityAudit
+
+	if spec.FollowRedirects != nil {
+		followRedirects := config.RedirectLimit(*spec.FollowRedirects)
+		target.FollowRedirects = &followRedirects
+	}
+
+	if spec.TLSPolicy != nil {
+		target.TLSPolicy = &config.TLSPolicy{
+			MinVersion:  spec.TLSPolicy.MinVersion,
+			Ciphers:     spec.TLSPolicy.Ciphers,
+			ProbeLegacy: spec.TLSPolicy.ProbeLegacy,
+		}
+	}
+
+	if spec.SLO != nil {
+		slo, err := sloFromSpec(spec.SLO)
+		if err != nil {
+			return target, fmt.Errorf("invalid slo: %w", err)
+		}
+		target.SLO = slo
+	}
+
+	return target, nil
+}
+
+// reportError records that a monitor can't be checked because of its configuration, with a
+// Warning event and an Error status explaining why
+func (r *URLMonitorReconciler) reportError(ctx context.Context, urlMonitor monitoredResource, reason, message string, err error) {
+	r.Logger.Error("Can't check "+r.kind,
+		slog.String("name", urlMonitor.GetName()),
+		slog.String("namespace", urlMonitor.GetNamespace()),
+		slog.String("reason", reason),
+		slog.Any("error", err))
+
+	// Writing the same error again would only bump the check time and repeat the event
+	latest := r.newObject()
+	if getErr := r.Get(ctx, client.ObjectKeyFromObject(urlMonitor), latest); getErr == nil {
+		if current := latest.MonitorStatus(); current.Status == "Error" && current.Message == err.Error() {
+			return
 		}
+	}
+	r.KubernetesEventRecorder.Event(urlMonitor, "Warning", reason, message)
 
-		r.monitorURL(monitorCtx, urlMonitor, target)
-	}()
+	status := &urlmonitorv1.URLMonitorStatus{
+		LastCheckTime: metav1.Now(),
+		Status:        "Error",
+		Message:       err.Error(),
+	}
+	if err := r.updateStatus(ctx, urlMonitor, status); err != nil {
+		r.Logger.Error("Failed to update "+r.kind+" status",
+			slog.String("name", urlMonitor.GetName()),
+			slog.String("namespace", urlMonitor.GetNamespace()),
+			slog.Any("error", err))
+	}
 }
 
 // stopMonitoring stops monitoring for a URLMonitor resource
@@ -132,7 +296,7 @@ func (r *URLMonitorReconciler) stopMonitoring(key string) {
 }
 
 // monitorURL periodically checks a URL and updates the URLMonitor status
-func (r *URLMonitorReconciler) monitorURL(ctx context.Context, urlMonitor *urlmonitorv1.URLMonitor, target config.Target) {
+func (r *URLMonitorReconciler) monitorURL(ctx context.Context, urlMonitor monitoredResource, target config.Target) {
 	ticker := time.NewTicker(time.Duration(target.Interval) * time.Second)
 	defer ticker.Stop()
 
@@ -140,133 +304,375 @@ func (r *URLMonitorReconciler) monitorURL(ctx context.Context, urlMonitor *urlmo
 		slog.String("name", target.Name),
 		slog.String("url", target.URL),
 		slog.Int("interval", target.Interval))
+	r.registerStatus(urlMonitor, target)
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			client := &http.Client{
-				Timeout: time.Duration(target.Timeout) * time.Second,
-			}
+			r.check(ctx, urlMonitor, target)
+		}
+	}
+}
 
-			up, status, duration, err := monitor.CheckTarget(client, target)
+// check checks a monitor once. The runner sends the metrics and Datadog events like for
+// standalone targets, the result is reported on the status and as Kubernetes events.
+func (r *URLMonitorReconciler) check(ctx context.Context, urlMonitor monitoredResource, target config.Target) {
+	if ref := urlMonitor.MonitorSpec().CASecret; ref != nil {
+		caData, err := r.caData(ctx, urlMonitor, ref)
+		if err != nil {
+			r.Logger.Warn("Failed to read CA secret",
+				slog.String("url", target.URL),
+				slog.Any("error", err))
+			r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "CASecretError",
+				fmt.Sprintf("Failed to read CA certificates for %s: %v", target.URL, err))
+		}
+		target.CAData = caData
+	}
 
-			tags := []string{"url:" + target.URL, "name:" + target.Name}
-			for k, v := range target.Labels {
-				tags = append(tags, k+":"+v)
-			}
+	httpClient, err := monitor.NewClient(target)
+	if err != nil {
+		r.reportError(ctx, urlMonitor, "ClientConfigError",
+			fmt.Sprintf("Failed to set up the check of %s: %v", target.URL, err), err)
+		return
+	}
 
-			val := 0.0
-			if up {
-				val = 1.0
-			}
+	// Targets checked over both IP families report availability per family. The status
+	// reflects the worst family and the certificate is checked once.
+	report := r.runner.CheckNamed(client.ObjectKeyFromObject(urlMonitor).String(), httpClient, target)
+	statusUpdate := r.checkStatus(urlMonitor, target, report)
 
-			_ = r.MetricsClient.Gauge(monitor.MetricURLUp, val, tags)
-			_ = r.MetricsClient.Histogram(monitor.MetricResponseTime, float64(duration.Milliseconds()), tags)
+	r.recordStatus(urlMonitor, target, report.CheckedAt, report.Worst, report.Health, report.Certificate)
+	r.notifyCheck(urlMonitor, target, report.CheckedAt, report.Worst, report.Health, report.Certificate)
 
-			statusUpdate := &urlmonitorv1.URLMonitorStatus{
-				LastCheckTime: metav1.Now(),
-				ResponseTime:  duration.Milliseconds(),
-			}
+	if err := r.updateStatus(ctx, urlMonitor, statusUpdate); err != nil {
+		r.Logger.Error("Failed to update "+r.kind+" status",
+			slog.String("name", urlMonitor.GetName()),
+			slog.String("namespace", urlMonitor.GetNamespace()),
+			slog.Any("error", err))
 
-			if err != nil {
-				statusUpdate.Status = "Error"
-				r.Logger.Warn("Error checking URL",
-					slog.String("url", target.URL),
-					slog.Any("error", err))
-				
-				// Record a warning event for the error
-				r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "MonitorCheckError", 
-					fmt.Sprintf("Error checking URL %s: %v", target.URL, err))
-			} else {
-				statusUpdate.StatusCode = status
-				if up {
-					statusUpdate.Status = "Up"
-					
-					// Record normal event for successful check (but not too frequently)
-					if statusUpdate.StatusCode >= 200 && statusUpdate.StatusCode < 300 {
-						// Only record events for successful checks occasionally to avoid flooding
-						if time.Now().Minute()%10 == 0 { // Record once every ~10 minutes
-							r.KubernetesEventRecorder.Event(urlMonitor, "Normal", "URLStatusUp", 
-								fmt.Sprintf("URL %s is up with status code %d (response time: %dms)", 
-									target.URL, status, duration.Milliseconds()))
-						}
-					}
-				} else {
-					statusUpdate.Status = "Down"
-					
-					// Always record events for down status
-					r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "URLStatusDown", 
-						fmt.Sprintf("URL %s is down with status code %d", target.URL, status))
-				}
-			}
+		// Record event for status update failure
+		r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "StatusUpdateFailed",
+			fmt.Sprintf("Failed to update %s status: %v", r.kind, err))
+	}
+}
 
-			if monitor.ShouldCheckCertificate(target) {
-				certDetails, certErr := certcheck.CheckCertificate(target.URL, *target.VerifyCert)
-				if certErr == nil && certDetails != nil {
-					daysUntilExpiry := time.Until(certDetails.NotAfter).Hours() / 24
-
-					certVal := 0.0
-					if certDetails.IsValid {
-						certVal = 1.0
-					} else {
-						// Record an event for invalid certificate
-						r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "InvalidCertificate", 
-							fmt.Sprintf("SSL certificate for %s is invalid", target.URL))
-					}
-
-					// Record event if certificate is expiring soon (less than 14 days)
-					if daysUntilExpiry < 14 {
-						r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "CertificateExpiringSoon", 
-							fmt.Sprintf("SSL certificate for %s expires in %.1f days", target.URL, daysUntilExpiry))
-					}
-
-					_ = r.MetricsClient.Gauge(monitor.MetricSSLValid, certVal, tags)
-					_ = r.MetricsClient.Gauge(monitor.MetricSSLDaysToExpiry, daysUntilExpiry, tags)
-
-					// Update certificate status
-					statusUpdate.Certificate = &urlmonitorv1.CertificateStatus{
-						Valid:           certDetails.IsValid,
-						Subject:         certDetails.Subject,
-						Issuer:          certDetails.Issuer,
-						NotAfter:        metav1.NewTime(certDetails.NotAfter),
-						DaysUntilExpiry: fmt.Sprintf("%.2f", daysUntilExpiry),
-					}
-				}
-			}
+// checkStatus records the Kubernetes events of a check and returns the status reporting it
+func (r *URLMonitorReconciler) checkStatus(urlMonitor monitoredResource, target config.Target, report monitor.Report) *urlmonitorv1.URLMonitorStatus {
+	result, health := report.Worst, report.Health
+	status := &urlmonitorv1.URLMonitorStatus{
+		LastCheckTime: metav1.NewTime(report.CheckedAt),
+		ResponseTime:  result.Duration.Milliseconds(),
+		Redirects:     len(result.Hops),
+		FinalURL:      result.FinalURL,
+		ContentHash:   report.Result.ContentHash,
+	}
 
-			err = r.updateStatus(ctx, urlMonitor, statusUpdate)
-			if err != nil {
-				r.Logger.Error("Failed to update URLMonitor status",
-					slog.String("name", urlMonitor.Name),
-					slog.String("namespace", urlMonitor.Namespace),
-					slog.Any("error", err))
-				
-				// Record event for status update failure
-				r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "StatusUpdateFailed", 
-					fmt.Sprintf("Failed to update URLMonitor status: %v", err))
-			}
+	if report.PreviousContentHash != "" {
+		r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "ContentChanged",
+			fmt.Sprintf("Content served by %s changed from hash %s to %s (%d bytes)",
+				report.Result.FinalURL, report.PreviousContentHash, report.Result.ContentHash, report.Result.ResponseBytes))
+	}
+
+	if report.SLO != nil {
+		status.SLO = r.sloStatus(urlMonitor, target, report.SLOObjective, *report.SLO)
+	}
+
+	switch {
+	case result.Err != nil:
+		status.Status = "Error"
+		if result.ProxyFailed {
+			r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "ProxyConnectError",
+				fmt.Sprintf("Failed to connect to the proxy for %s: %v", target.URL, result.Err))
+		} else {
+			r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "MonitorCheckError",
+				fmt.Sprintf("Error checking URL %s: %v", target.URL, result.Err))
 		}
+	case health == monitor.HealthDegraded:
+		status.StatusCode = result.Status
+		status.Status = "Degraded"
+		r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "LatencyDegraded",
+			monitor.HealthMessage(target, result, health))
+	case health == monitor.HealthCritical:
+		status.StatusCode = result.Status
+		status.Status = "Critical"
+		r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "LatencyCritical",
+			monitor.HealthMessage(target, result, health))
+	case health == monitor.HealthUp:
+		status.StatusCode = result.Status
+		status.Status = "Up"
+
+		// Only record events for successful checks occasionally to avoid flooding
+		if result.Status >= 200 && result.Status < 300 && report.CheckedAt.Minute()%10 == 0 {
+			r.KubernetesEventRecorder.Event(urlMonitor, "Normal", "URLStatusUp",
+				fmt.Sprintf("URL %s is up with status code %d (response time: %dms)",
+					target.URL, result.Status, result.Duration.Milliseconds()))
+		}
+	default:
+		status.StatusCode = result.Status
+		status.Status = "Down"
+
+		// Always record events for down status
+		if !monitor.FinalURLMatches(target, result) {
+			r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "UnexpectedFinalURL",
+				fmt.Sprintf("URL %s redirected to %s instead of %s", target.URL, result.FinalURL, target.ExpectedFinalURL))
+		} else if !monitor.ProtocolMatches(target, result) {
+			r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "UnexpectedProtocol",
+				fmt.Sprintf("URL %s negotiated %s instead of %s", target.URL, result.Protocol, target.Protocol))
+		} else if len(result.HeaderFailures) > 0 {
+			r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "HeaderAssertionFailed",
+				fmt.Sprintf("URL %s failed header assertions: %s", target.URL, strings.Join(result.HeaderFailures, "; ")))
+		} else {
+			r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "URLStatusDown",
+				fmt.Sprintf("URL %s is down with status code %d", target.URL, result.Status))
+		}
+	}
+
+	for _, cert := range report.RedirectCertificates {
+		if cert.Details != nil && !cert.Details.IsValid {
+			r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "InvalidRedirectCertificate",
+				fmt.Sprintf("SSL certificate of %s, which %s redirects through, is invalid", cert.Host, target.URL))
+		}
+	}
+
+	if certDetails := report.Certificate; certDetails != nil {
+		r.certificateEvents(urlMonitor, target, report)
+		status.Certificate = certificateStatus(certDetails, len(target.CertFingerprints) > 0)
+		status.Conditions = append(status.Conditions,
+			certificateWeakCondition(certDetails, urlMonitor.GetGeneration()))
+	}
+
+	return status
+}
+
+// certificateEvents records the Kubernetes events about the certificate of a check
+func (r *URLMonitorReconciler) certificateEvents(urlMonitor monitoredResource, target config.Target, report monitor.Report) {
+	certDetails := report.Certificate
+	if !certDetails.IsValid {
+		r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "InvalidCertificate",
+			fmt.Sprintf("SSL certificate for %s is invalid", target.URL))
+	}
+
+	// Record event if certificate is expiring soon (less than 14 days)
+	daysUntilExpiry := time.Until(certDetails.NotAfter).Hours() / 24
+	if daysUntilExpiry < 14 {
+		r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "CertificateExpiringSoon",
+			fmt.Sprintf("SSL certificate for %s expires in %.1f days", target.URL, daysUntilExpiry))
+	}
+
+	chainDaysUntilExpiry := time.Until(certDetails.ChainNotAfter()).Hours() / 24
+	if chainDaysUntilExpiry < 14 && chainDaysUntilExpiry < daysUntilExpiry {
+		r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "ChainCertificateExpiringSoon",
+			fmt.Sprintf("A certificate in the chain for %s expires in %.1f days", target.URL, chainDaysUntilExpiry))
+	}
+
+	if certDetails.IsRevoked() {
+		r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "CertificateRevoked",
+			fmt.Sprintf("SSL certificate for %s was revoked at %s", target.URL,
+				certDetails.Revocation.RevokedAt.Format(time.RFC3339)))
+	}
+
+	if len(target.CertFingerprints) > 0 && !certDetails.PinMatch {
+		r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "CertificatePinMismatch",
+			fmt.Sprintf("SSL certificate for %s matches none of the pinned fingerprints", target.URL))
+	}
+
+	if report.PreviousSerial != "" {
+		r.KubernetesEventRecorder.Event(urlMonitor, "Normal", "CertificateRotated",
+			fmt.Sprintf("SSL certificate for %s rotated from serial %s to %s, valid until %s",
+				target.URL, report.PreviousSerial, certDetails.SerialNumber, certDetails.NotAfter.Format(time.RFC3339)))
+	}
+
+	if target.TLSPolicy != nil && len(certDetails.PolicyViolations) > 0 {
+		r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "TLSPolicyViolation",
+			fmt.Sprintf("TLS policy for %s violated: %s", target.URL, strings.Join(certDetails.PolicyViolations, "; ")))
+	}
+}
+
+// caData reads the PEM encoded CA certificates a monitor references. Namespaced
+// monitors may only read Secrets of their own namespace.
+func (r *URLMonitorReconciler) caData(ctx context.Context, urlMonitor monitoredResource, ref *urlmonitorv1.SecretKeyReference) ([]byte, error) {
+	namespace := urlMonitor.GetNamespace()
+	switch {
+	case namespace == "" && ref.Namespace == "":
+		return nil, fmt.Errorf("caSecret of a cluster-scoped monitor must set a namespace")
+	case namespace == "":
+		namespace = ref.Namespace
+	case ref.Namespace != "" && ref.Namespace != namespace:
+		return nil, fmt.Errorf("caSecret must be in the monitor's namespace %s", namespace)
+	}
+
+	key := ref.Key
+	if key == "" {
+		key = DefaultCASecretKey
+	}
+
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+
+	secret := &corev1.Secret{}
+	if err := reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get Secret %s/%s: %w", namespace, ref.Name, err)
 	}
+
+	data, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("secret %s/%s has no key %s", namespace, ref.Name, key)
+	}
+	return data, nil
 }
 
 // updateStatus updates the status of a URLMonitor resource
-func (r *URLMonitorReconciler) updateStatus(ctx context.Context, urlMonitor *urlmonitorv1.URLMonitor, status *urlmonitorv1.URLMonitorStatus) error {
-	latest := &urlmonitorv1.URLMonitor{}
-	err := r.Get(ctx, client.ObjectKey{Namespace: urlMonitor.Namespace, Name: urlMonitor.Name}, latest)
+func (r *URLMonitorReconciler) updateStatus(ctx context.Context, urlMonitor monitoredResource, status *urlmonitorv1.URLMonitorStatus) error {
+	latest := r.newObject()
+	err := r.Get(ctx, client.ObjectKeyFromObject(urlMonitor), latest)
 	if err != nil {
 		return err
 	}
 
-	latest.Status = *status
+	// Carry existing conditions over so their transition times are preserved
+	conditions := latest.MonitorStatus().Conditions
+	for _, condition := range status.Conditions {
+		meta.SetStatusCondition(&conditions, condition)
+	}
+	status.Conditions = conditions
+
+	*latest.MonitorStatus() = *status
 
 	return r.Status().Update(ctx, latest)
 }
 
+// certificateStatus converts certificate details into the status reported on the resource
+func certificateStatus(details *certcheck.CertificateDetails, pinned bool) *urlmonitorv1.CertificateStatus {
+	status := &urlmonitorv1.CertificateStatus{
+		Valid:                details.IsValid,
+		Subject:              details.Subject,
+		Issuer:               details.Issuer,
+		SerialNumber:         details.SerialNumber,
+		NotAfter:             metav1.NewTime(details.NotAfter),
+		DaysUntilExpiry:      fmt.Sprintf("%.2f", time.Until(details.NotAfter).Hours()/24),
+		ChainDaysUntilExpiry: fmt.Sprintf("%.2f", time.Until(details.ChainNotAfter()).Hours()/24),
+		Weak:                 details.IsWeak(),
+		TLSVersion:           details.TLSVersion,
+		CipherSuite:          details.CipherSuite,
+		PolicyViolations:     details.PolicyViolations,
+		OCSPStapled:          details.OCSPStapled,
+		RevocationStatus:     details.Revocation.Status,
+	}
+
+	if details.IsRevoked() {
+		revokedAt := metav1.NewTime(details.Revocation.RevokedAt)
+		status.RevokedAt = &revokedAt
+	}
+
+	if pinned {
+		pinMatch := details.PinMatch
+		status.PinMatch = &pinMatch
+	}
+
+	for _, cert := range details.Chain {
+		status.Chain = append(status.Chain, urlmonitorv1.ChainCertificateStatus{
+			Subject:               cert.Subject,
+			Issuer:                cert.Issuer,
+			NotAfter:              metav1.NewTime(cert.NotAfter),
+			KeyType:               cert.KeyType,
+			KeyBits:               cert.KeyBits,
+			SignatureAlgorithm:    cert.SignatureAlgorithm,
+			FingerprintSHA256:     cert.FingerprintSHA256,
+			SPKIFingerprintSHA256: cert.SPKIFingerprintSHA256,
+		})
+
+	}
+
+	return status
+}
+
+// certificateWeakCondition builds the CertificateWeak condition for the checked certificate chain
+func certificateWeakCondition(details *certcheck.CertificateDetails, generation int64) metav1.Condition {
+	if details.IsWeak() {
+		return metav1.Condition{
+			Type:               ConditionCertificateWeak,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             ReasonWeakCryptography,
+			Message:            strings.Join(details.WeakReasons, "; "),
+		}
+	}
+
+	return metav1.Condition{
+		Type:               ConditionCertificateWeak,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             ReasonStrongCryptography,
+		Message:            "All certificates in the chain use strong keys and signatures",
+	}
+}
+
+// sloStatus records the Kubernetes events of the burn rate alerts that fired or resolved
+// with a check and returns the SLO status to report
+func (r *URLMonitorReconciler) sloStatus(urlMonitor monitoredResource, target config.Target, objective slo.Objective, report slo.Report) *urlmonitorv1.SLOStatus {
+	status := &urlmonitorv1.SLOStatus{
+		Attainment:           fmt.Sprintf("%.3f", report.Attainment),
+		ErrorBudgetRemaining: fmt.Sprintf("%.2f", report.ErrorBudgetRemaining),
+		BurnRates:            make(map[string]string),
+	}
+	for _, window := range objective.BurnRateWindows() {
+		status.BurnRates[slo.FormatWindow(window)] = fmt.Sprintf("%.2f", report.BurnRates[window])
+	}
+	for _, alert := range report.Firing {
+		status.FiringAlerts = append(status.FiringAlerts, alert.Name())
+	}
+
+	for _, alert := range report.Fired {
+		r.KubernetesEventRecorder.Event(urlMonitor, "Warning", "SLOBurnRateHigh",
+			monitor.SLOAlertText(target, objective, alert, report))
+	}
+	for _, alert := range report.Resolved {
+		r.KubernetesEventRecorder.Event(urlMonitor, "Normal", "SLOBurnRateResolved",
+			monitor.SLOAlertText(target, objective, alert, report))
+	}
+	return status
+}
+
+// sloFromSpec converts the SLO of a spec, filling in the default window and alerts, and
+// validates it with the rules applied to the standalone config
+func sloFromSpec(spec *urlmonitorv1.SLO) (*config.SLO, error) {
+	objective, err := strconv.ParseFloat(spec.Objective, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid objective %q", spec.Objective)
+	}
+	target := &config.SLO{Objective: objective, Window: spec.Window}
+	for i, alert := range spec.BurnRateAlerts {
+		threshold, err := strconv.ParseFloat(alert.Threshold, 64)
+		if err != nil {
+			return nil, fmt.Errorf("burn rate alert %d: invalid threshold %q", i, alert.Threshold)
+		}
+		target.BurnRateAlerts = append(target.BurnRateAlerts, config.BurnRateAlert{
+			LongWindow:  alert.LongWindow,
+			ShortWindow: alert.ShortWindow,
+			Threshold:   threshold,
+		})
+	}
+
+	config.SetSLODefaults(target)
+	if err := config.ValidateSLO(*target); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
 // SetupWithManager sets up the controller with the Manager
 func (r *URLMonitorReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.StateStore != nil {
+		if err := mgr.Add(manager.RunnableFunc(r.persistState)); err != nil {
+			return err
+		}
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&urlmonitorv1.URLMonitor{}).
+		For(r.newObject(), builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Complete(r)
 }
@@ -0,0 +1,325 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	urlmonitorv1 "github.com/kuskoman/url-datadog-monitor/pkg/api/v1"
+	"github.com/kuskoman/url-datadog-monitor/pkg/certcheck"
+	"github.com/kuskoman/url-datadog-monitor/pkg/monitor"
+)
+
+func newTestCertificateDetails() *certcheck.CertificateDetails {
+	now := time.Now()
+	return &certcheck.CertificateDetails{
+		Subject:  "example.com",
+		Issuer:   "Intermediate CA",
+		NotAfter: now.Add(60 * 24 * time.Hour),
+		IsValid:  true,
+		Chain: []certcheck.ChainCertificate{
+			{Subject: "example.com", Issuer: "Intermediate CA", NotAfter: now.Add(60 * 24 * time.Hour), KeyType: certcheck.KeyTypeRSA, KeyBits: 1024},
+			{Subject: "Intermediate CA", Issuer: "Root CA", NotAfter: now.Add(3 * 24 * time.Hour), KeyType: certcheck.KeyTypeECDSA, KeyBits: 384},
+		},
+		WeakReasons: []string{`"example.com" uses a 1024-bit RSA key`},
+	}
+}
+
+func TestCertificateStatus(t *testing.T) {
+	status := certificateStatus(newTestCertificateDetails(), false)
+
+	if len(status.Chain) != 2 {
+		t.Fatalf("Expected 2 chain entries, got %d", len(status.Chain))
+	}
+	if status.Chain[1].KeyType != certcheck.KeyTypeECDSA || status.Chain[1].KeyBits != 384 {
+		t.Errorf("Expected ECDSA 384-bit intermediate, got %s %d-bit", status.Chain[1].KeyType, status.Chain[1].KeyBits)
+	}
+	if !status.Weak {
+		t.Errorf("Expected weak certificate status")
+	}
+	if status.ChainDaysUntilExpiry != "3.00" {
+		t.Errorf("Expected chain to expire in 3.00 days, got %s", status.ChainDaysUntilExpiry)
+	}
+}
+
+func TestUpdateStatus_PreservesConditionTransitionTime(t *testing.T) {
+	scheme := newTestScheme(t)
+	transitioned := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	urlMonitor := &urlmonitorv1.URLMonitor{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default", Generation: 1},
+		Status: urlmonitorv1.URLMonitorStatus{
+			Conditions: []metav1.Condition{{
+				Type:               ConditionCertificateWeak,
+				Status:             metav1.ConditionTrue,
+				Reason:             ReasonWeakCryptography,
+				LastTransitionTime: transitioned,
+			}},
+		},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(urlMonitor).
+		WithStatusSubresource(urlMonitor).
+		Build()
+
+	r := NewURLMonitorReconciler(c, scheme, nil, monitor.NopLogger(), record.NewFakeRecorder(100))
+	ctx := context.Background()
+
+	details := newTestCertificateDetails()
+	statusUpdate := &urlmonitorv1.URLMonitorStatus{
+		Status:      "Up",
+		Certificate: certificateStatus(details, false),
+		Conditions:  []metav1.Condition{certificateWeakCondition(details, urlMonitor.Generation)},
+	}
+	if err := r.updateStatus(ctx, urlMonitor, statusUpdate); err != nil {
+		t.Fatalf("Failed to update status: %v", err)
+	}
+
+	latest := &urlmonitorv1.URLMonitor{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(urlMonitor), latest); err != nil {
+		t.Fatalf("Failed to get URLMonitor: %v", err)
+	}
+
+	condition := meta.FindStatusCondition(latest.Status.Conditions, ConditionCertificateWeak)
+	if condition == nil {
+		t.Fatalf("Expected %s condition to be set", ConditionCertificateWeak)
+	}
+	if !condition.LastTransitionTime.Equal(&transitioned) {
+		t.Errorf("Expected transition time %v to be preserved, got %v", transitioned, condition.LastTransitionTime)
+	}
+	if condition.Message != details.WeakReasons[0] {
+		t.Errorf("Expected message %q, got %q", details.WeakReasons[0], condition.Message)
+	}
+
+	details.WeakReasons = nil
+	statusUpdate.Conditions = []metav1.Condition{certificateWeakCondition(details, urlMonitor.Generation)}
+	if err := r.updateStatus(ctx, urlMonitor, statusUpdate); err != nil {
+		t.Fatalf("Failed to update status: %v", err)
+	}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(urlMonitor), latest); err != nil {
+		t.Fatalf("Failed to get URLMonitor: %v", err)
+	}
+	if !meta.IsStatusConditionFalse(latest.Status.Conditions, ConditionCertificateWeak) {
+		t.Errorf("Expected %s condition to be false after the chain became strong", ConditionCertificateWeak)
+	}
+}
+
+func TestCheckStatus_ReportsRotation(t *testing.T) {
+	scheme := newTestScheme(t)
+	recorder := record.NewFakeRecorder(10)
+	r := NewURLMonitorReconciler(fake.NewClientBuilder().WithScheme(scheme).Build(), scheme, nil, monitor.NopLogger(), recorder)
+
+	urlMonitor := &urlmonitorv1.URLMonitor{ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"}}
+	target, _ := targetFromSpec("example", &urlmonitorv1.URLMonitorSpec{URL: "https://example.com"})
+	result := monitor.Result{Up: true, Status: 200}
+	report := monitor.Report{
+		CheckedAt: time.Date(2024, 1, 1, 12, 1, 0, 0, time.UTC),
+		Result:    result,
+		Worst:     result,
+		Health:    monitor.HealthUp,
+		Certificate: &certcheck.CertificateDetails{
+			SerialNumber: "2",
+			IsValid:      true,
+			NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+		},
+		PreviousSerial: "1",
+	}
+
+	status := r.checkStatus(urlMonitor, target, report)
+	if status.Status != "Up" || status.Certificate == nil || status.Certificate.SerialNumber != "2" {
+		t.Errorf("Expected an Up status with the rotated certificate, got %+v", status)
+	}
+	if len(recorder.Events) != 1 {
+		t.Fatalf("Expected a single event, got %d", len(recorder.Events))
+	}
+	if event := <-recorder.Events; !strings.Contains(event, "CertificateRotated") {
+		t.Errorf("Expected a CertificateRotated event, got %q", event)
+	}
+
+	report.PreviousSerial = ""
+	r.checkStatus(urlMonitor, target, report)
+	if len(recorder.Events) != 0 {
+		t.Errorf("Expected no event without a rotation, got %d", len(recorder.Events))
+	}
+}
+
+func TestCheckStatus_CriticalLatency(t *testing.T) {
+	scheme := newTestScheme(t)
+	recorder := record.NewFakeRecorder(10)
+	r := NewURLMonitorReconciler(fake.NewClientBuilder().WithScheme(scheme).Build(), scheme, nil, monitor.NopLogger(), recorder)
+
+	urlMonitor := &urlmonitorv1.URLMonitor{ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"}}
+	target, _ := targetFromSpec("example", &urlmonitorv1.URLMonitorSpec{URL: "http://example.com"})
+	result := monitor.Result{Up: true, Status: 200, Duration: 3 * time.Second}
+	status := r.checkStatus(urlMonitor, target, monitor.Report{
+		CheckedAt: time.Now(),
+		Result:    result,
+		Worst:     result,
+		Health:    monitor.HealthCritical,
+	})
+
+	if status.Status != "Critical" || status.StatusCode != 200 || status.ResponseTime != 3000 {
+		t.Errorf("Expected a Critical status with the response, got %+v", status)
+	}
+	if event := <-recorder.Events; !strings.Contains(event, "LatencyCritical") {
+		t.Errorf("Expected a LatencyCritical event, got %q", event)
+	}
+}
+
+func TestCAData(t *testing.T) {
+	scheme := newTestScheme(t)
+	secrets := []client.Object{
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "internal-ca", Namespace: "default"},
+			Data:       map[string][]byte{DefaultCASecretKey: []byte("default-ca"), "bundle.pem": []byte("bundle")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "internal-ca", Namespace: "other"},
+			Data:       map[string][]byte{DefaultCASecretKey: []byte("other-ca")},
+		},
+	}
+	r := NewURLMonitorReconciler(fake.NewClientBuilder().WithScheme(scheme).WithObjects(secrets...).Build(),
+		scheme, nil, monitor.NopLogger(), record.NewFakeRecorder(10))
+
+	namespaced := &urlmonitorv1.URLMonitor{ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"}}
+	cluster := &urlmonitorv1.ClusterURLMonitor{ObjectMeta: metav1.ObjectMeta{Name: "example"}}
+
+	for name, tc := range map[string]struct {
+		urlMonitor monitoredResource
+		ref        urlmonitorv1.SecretKeyReference
+		expected   string
+	}{
+		"default key":               {urlMonitor: namespaced, ref: urlmonitorv1.SecretKeyReference{Name: "internal-ca"}, expected: "default-ca"},
+		"custom key":                {urlMonitor: namespaced, ref: urlmonitorv1.SecretKeyReference{Name: "internal-ca", Key: "bundle.pem"}, expected: "bundle"},
+		"own namespace":             {urlMonitor: namespaced, ref: urlmonitorv1.SecretKeyReference{Name: "internal-ca", Namespace: "default"}, expected: "default-ca"},
+		"other namespace":           {urlMonitor: namespaced, ref: urlmonitorv1.SecretKeyReference{Name: "internal-ca", Namespace: "other"}},
+		"missing key":               {urlMonitor: namespaced, ref: urlmonitorv1.SecretKeyReference{Name: "internal-ca", Key: "tls.crt"}},
+		"missing secret":            {urlMonitor: namespaced, ref: urlmonitorv1.SecretKeyReference{Name: "missing"}},
+		"cluster with namespace":    {urlMonitor: cluster, ref: urlmonitorv1.SecretKeyReference{Name: "internal-ca", Namespace: "other"}, expected: "other-ca"},
+		"cluster without namespace": {urlMonitor: cluster, ref: urlmonitorv1.SecretKeyReference{Name: "internal-ca"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data, err := r.caData(context.Background(), tc.urlMonitor, &tc.ref)
+			if tc.expected == "" {
+				if err == nil {
+					t.Errorf("Expected an error, got %q", data)
+				}
+				return
+			}
+			if err != nil || string(data) != tc.expected {
+				t.Errorf("Expected %q, got %q (%v)", tc.expected, data, err)
+			}
+		})
+	}
+}
+
+func TestStartOrUpdateMonitoring_InvalidSLO(t *testing.T) {
+	scheme := newTestScheme(t)
+	urlMonitor := &urlmonitorv1.URLMonitor{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec: urlmonitorv1.URLMonitorSpec{
+			URL:      "https://example.com",
+			Interval: 60,
+			SLO:      &urlmonitorv1.SLO{Objective: "99.9", Window: "0d"},
+		},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(urlMonitor).
+		WithStatusSubresource(urlMonitor).
+		Build()
+	recorder := record.NewFakeRecorder(10)
+	r := NewURLMonitorReconciler(c, scheme, nil, monitor.NopLogger(), recorder)
+	ctx := context.Background()
+
+	r.startOrUpdateMonitoring(ctx, urlMonitor)
+
+	if len(r.monitors) != 0 {
+		t.Errorf("Expected an invalid monitor not to be started, got %d monitors", len(r.monitors))
+	}
+	latest := &urlmonitorv1.URLMonitor{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(urlMonitor), latest); err != nil {
+		t.Fatalf("Failed to get URLMonitor: %v", err)
+	}
+	if latest.Status.Status != "Error" || !strings.Contains(latest.Status.Message, `invalid window "0d"`) {
+		t.Errorf("Expected an Error status explaining the window, got %q: %q", latest.Status.Status, latest.Status.Message)
+	}
+	if event := <-recorder.Events; !strings.Contains(event, "InvalidSpec") {
+		t.Errorf("Expected an InvalidSpec event, got %q", event)
+	}
+}
+
+func TestStartOrUpdateMonitoring_RepeatedErrorIsNotRewritten(t *testing.T) {
+	scheme := newTestScheme(t)
+	urlMonitor := &urlmonitorv1.URLMonitor{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec: urlmonitorv1.URLMonitorSpec{
+			URL:      "https://example.com",
+			Interval: 60,
+			SLO:      &urlmonitorv1.SLO{Objective: "99.9", Window: "0d"},
+		},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(urlMonitor).
+		WithStatusSubresource(urlMonitor).
+		Build()
+	recorder := record.NewFakeRecorder(10)
+	r := NewURLMonitorReconciler(c, scheme, nil, monitor.NopLogger(), recorder)
+	ctx := context.Background()
+
+	r.startOrUpdateMonitoring(ctx, urlMonitor)
+	first := &urlmonitorv1.URLMonitor{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(urlMonitor), first); err != nil {
+		t.Fatalf("Failed to get URLMonitor: %v", err)
+	}
+
+	r.startOrUpdateMonitoring(ctx, first)
+	second := &urlmonitorv1.URLMonitor{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(urlMonitor), second); err != nil {
+		t.Fatalf("Failed to get URLMonitor: %v", err)
+	}
+
+	if second.ResourceVersion != first.ResourceVersion {
+		t.Errorf("Expected an unchanged error not to update the status, got resource version %s after %s",
+			second.ResourceVersion, first.ResourceVersion)
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("Expected a single InvalidSpec event, got %d", len(recorder.Events))
+	}
+}
+
+func TestCheck_InvalidCAData(t *testing.T) {
+	ctx := context.Background()
+	scheme := newTestScheme(t)
+	urlMonitor := &urlmonitorv1.URLMonitor{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec:       urlmonitorv1.URLMonitorSpec{URL: "https://example.com"},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(urlMonitor).WithStatusSubresource(urlMonitor).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := NewURLMonitorReconciler(c, scheme, nil, monitor.NopLogger(), recorder)
+
+	target, _ := targetFromSpec("example", &urlMonitor.Spec)
+	target.CAData = []byte("not a certificate")
+	r.check(ctx, urlMonitor, target)
+
+	latest := &urlmonitorv1.URLMonitor{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(urlMonitor), latest); err != nil {
+		t.Fatalf("Failed to get URLMonitor: %v", err)
+	}
+	if latest.Status.Status != "Error" {
+		t.Errorf("Expected an Error status instead of falling back to the system trust store, got %q", latest.Status.Status)
+	}
+	if event := <-recorder.Events; !strings.Contains(event, "ClientConfigError") {
+		t.Errorf("Expected a ClientConfigError event, got %q", event)
+	}
+}
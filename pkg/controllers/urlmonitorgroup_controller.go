@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	urlmonitorv1 "github.com/kuskoman/url-datadog-monitor/pkg/api/v1"
+)
+
+const (
+	// LabelGroup is set on URLMonitors generated by a URLMonitorGroup
+	LabelGroup = "url-datadog-monitor.kuskoman.github.com/group"
+
+	ParamHost = "host"
+	ParamPath = "path"
+
+	GroupStatusHealthy  = "Healthy"
+	GroupStatusDegraded = "Degraded"
+	GroupStatusDown     = "Down"
+	GroupStatusPending  = "Pending"
+)
+
+// URLMonitorGroupReconciler reconciles a URLMonitorGroup object
+type URLMonitorGroupReconciler struct {
+	client.Client
+	Scheme                  *runtime.Scheme
+	Logger                  *slog.Logger
+	KubernetesEventRecorder record.EventRecorder
+}
+
+// NewURLMonitorGroupReconciler creates a new reconciler for URLMonitorGroup resources
+func NewURLMonitorGroupReconciler(client client.Client, scheme *runtime.Scheme, logger *slog.Logger, eventRecorder record.EventRecorder) *URLMonitorGroupReconciler {
+	return &URLMonitorGroupReconciler{
+		Client:                  client,
+		Scheme:                  scheme,
+		Logger:                  logger,
+		KubernetesEventRecorder: eventRecorder,
+	}
+}
+
+// +kubebuilder:rbac:groups=url-datadog-monitor.kuskoman.github.com,resources=urlmonitorgroups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=url-datadog-monitor.kuskoman.github.com,resources=urlmonitorgroups/status,verbs=get;update;patch
+
+// Reconcile generates the URLMonitors of a group, prunes removed ones and aggregates their health
+func (r *URLMonitorGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.FromContext(ctx)
+	reqLogger.Info("Reconciling URLMonitorGroup")
+
+	group := &urlmonitorv1.URLMonitorGroup{}
+	err := r.Get(ctx, req.NamespacedName, group)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// Generated URLMonitors are garbage collected through their owner references
+			r.Logger.Info("URLMonitorGroup resource was deleted", slog.String("group", req.String()))
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	desired, err := expandGroup(group)
+	if err != nil {
+		r.KubernetesEventRecorder.Event(group, "Warning", "InvalidGroup",
+			fmt.Sprintf("Failed to generate URLMonitors: %v", err))
+		return ctrl.Result{}, nil
+	}
+
+	children, err := syncOwnedMonitors(ctx, r.Client, r.Scheme, r.KubernetesEventRecorder, group, LabelGroup, desired)
+	if err != nil {
+		r.KubernetesEventRecorder.Event(group, "Warning", "SyncFailed",
+			fmt.Sprintf("Failed to sync generated URLMonitors: %v", err))
+		return ctrl.Result{}, err
+	}
+
+	status := aggregateGroupStatus(children)
+	if groupStatusEqual(group.Status, status) {
+		return ctrl.Result{}, nil
+	}
+
+	if group.Status.Status != status.Status && status.Status != GroupStatusPending {
+		eventType := "Normal"
+		if status.Status != GroupStatusHealthy {
+			eventType = "Warning"
+		}
+		r.KubernetesEventRecorder.Event(group, eventType, "GroupStatus"+status.Status,
+			fmt.Sprintf("%d of %d URLMonitors are up", status.Up, status.Monitors))
+	}
+
+	status.LastUpdateTime = metav1.Now()
+	group.Status = status
+	if err := r.Status().Update(ctx, group); err != nil {
+		r.Logger.Error("Failed to update URLMonitorGroup status",
+			slog.String("name", group.Name),
+			slog.String("namespace", group.Namespace),
+			slog.Any("error", err))
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// expandGroup builds one URLMonitor per combination of the group parameters
+func expandGroup(group *urlmonitorv1.URLMonitorGroup) ([]*urlmonitorv1.URLMonitor, error) {
+	params := make(map[string][]string, len(group.Spec.Matrix)+2)
+	for name, values := range group.Spec.Matrix {
+		params[name] = values
+	}
+	if len(group.Spec.Hosts) > 0 {
+		params[ParamHost] = group.Spec.Hosts
+	}
+	if len(group.Spec.Paths) > 0 {
+		params[ParamPath] = group.Spec.Paths
+	}
+
+	names := make([]string, 0, len(params))
+	for name, values := range params {
+		if len(values) == 0 {
+			return nil, fmt.Errorf("parameter %q has no values", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var monitors []*urlmonitorv1.URLMonitor
+	seen := make(map[string]bool)
+	for _, combination := range parameterCombinations(names, params) {
+		spec := group.Spec.Template.DeepCopy()
+		spec.URL = substituteParams(spec.URL, combination)
+		if strings.Contains(spec.URL, "${") {
+			return nil, fmt.Errorf("URL %q references an undefined parameter", spec.URL)
+		}
+		for k, v := range spec.Headers {
+			spec.Headers[k] = substituteParams(v, combination)
+		}
+		for k, v := range spec.Labels {
+			spec.Labels[k] = substituteParams(v, combination)
+		}
+
+		// Parameters the template doesn't use expand to identical monitors
+		expanded, err := json.Marshal(spec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode URLMonitor spec: %w", err)
+		}
+		if seen[string(expanded)] {
+			continue
+		}
+		seen[string(expanded)] = true
+
+		key := make([]string, 0, len(names))
+		for _, name := range names {
+			key = append(key, name+"="+combination[name])
+		}
+
+		monitors = append(monitors, &urlmonitorv1.URLMonitor{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: childMonitorName(group.Name, key...),
+			},
+			Spec: *spec,
+		})
+	}
+
+	return monitors, nil
+}
+
+// parameterCombinations returns the cartesian product of the parameter values
+func parameterCombinations(names []string, params map[string][]string) []map[string]string {
+	combinations := []map[string]string{{}}
+	for _, name := range names {
+		var next []map[string]string
+		for _, combination := range combinations {
+			for _, value := range params[name] {
+				c := make(map[string]string, len(combination)+1)
+				for k, v := range combination {
+					c[k] = v
+				}
+				c[name] = value
+				next = append(next, c)
+			}
+		}
+		combinations = next
+	}
+	return combinations
+}
+
+// substituteParams replaces ${name} placeholders in s with parameter values in a single
+// pass, so placeholders inside values are left as they are
+func substituteParams(s string, params map[string]string) string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	replacements := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		replacements = append(replacements, "${"+name+"}", params[name])
+	}
+	return strings.NewReplacer(replacements...).Replace(s)
+}
+
+// aggregateGroupStatus summarizes the health of the generated URLMonitors
+func aggregateGroupStatus(children []urlmonitorv1.URLMonitor) urlmonitorv1.URLMonitorGroupStatus {
+	status := urlmonitorv1.URLMonitorGroupStatus{
+		Monitors: len(children),
+	}
+
+	for _, child := range children {
+		switch child.Status.Status {
+		case "Up":
+			status.Up++
+		case "Degraded", "Critical":
+			// Slow monitors still respond, so they don't count as failing
+			status.Degraded++
+		case "":
+			// Not checked yet
+		default:
+			status.Down++
+			status.FailingMonitors = append(status.FailingMonitors, child.Name)
+		}
+	}
+
+	switch {
+	case status.Monitors > 0 && status.Up == status.Monitors:
+		status.Status = GroupStatusHealthy
+	case status.Down > 0 && status.Up == 0 && status.Down == status.Monitors:
+		status.Status = GroupStatusDown
+	case status.Down > 0 || status.Degraded > 0:
+		status.Status = GroupStatusDegraded
+	default:
+		status.Status = GroupStatusPending
+	}
+
+	return status
+}
+
+// groupStatusEqual compares two group statuses ignoring the update time
+func groupStatusEqual(a, b urlmonitorv1.URLMonitorGroupStatus) bool {
+	a.LastUpdateTime = metav1.Time{}
+	b.LastUpdateTime = metav1.Time{}
+	return equality.Semantic.DeepEqual(a, b)
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *URLMonitorGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&urlmonitorv1.URLMonitorGroup{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&urlmonitorv1.URLMonitor{}).
+		Complete(r)
+}
@@ -0,0 +1,285 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	urlmonitorv1 "github.com/kuskoman/url-datadog-monitor/pkg/api/v1"
+	"github.com/kuskoman/url-datadog-monitor/pkg/monitor"
+)
+
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	if err := urlmonitorv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	return scheme
+}
+
+func newTestGroup() *urlmonitorv1.URLMonitorGroup {
+	return &urlmonitorv1.URLMonitorGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "edge", Namespace: "default", UID: "group-uid"},
+		Spec: urlmonitorv1.URLMonitorGroupSpec{
+			Template: urlmonitorv1.URLMonitorSpec{
+				URL:      "https://${host}${path}",
+				Interval: 60,
+				Timeout:  10,
+				Labels:   map[string]string{"path": "${path}"},
+			},
+			Hosts: []string{"a.example.com", "b.example.com"},
+			Paths: []string{"/health", "/ready"},
+		},
+	}
+}
+
+func TestExpandGroup(t *testing.T) {
+	monitors, err := expandGroup(newTestGroup())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(monitors) != 4 {
+		t.Fatalf("Expected 4 monitors, got %d", len(monitors))
+	}
+
+	urls := make(map[string]string)
+	for _, m := range monitors {
+		urls[m.Spec.URL] = m.Spec.Labels["path"]
+	}
+	for _, expected := range []string{
+		"https://a.example.com/health",
+		"https://a.example.com/ready",
+		"https://b.example.com/health",
+		"https://b.example.com/ready",
+	} {
+		if _, ok := urls[expected]; !ok {
+			t.Errorf("Expected a monitor for %s, got %v", expected, urls)
+		}
+	}
+	if urls["https://a.example.com/ready"] != "/ready" {
+		t.Errorf("Expected path label to be substituted, got '%s'", urls["https://a.example.com/ready"])
+	}
+
+	again, _ := expandGroup(newTestGroup())
+	if again[0].Name != monitors[0].Name {
+		t.Errorf("Expected stable monitor names, got '%s' and '%s'", monitors[0].Name, again[0].Name)
+	}
+}
+
+func TestExpandGroup_UndefinedParameter(t *testing.T) {
+	group := newTestGroup()
+	group.Spec.Template.URL = "https://${host}${path}?region=${region}"
+
+	if _, err := expandGroup(group); err == nil {
+		t.Errorf("Expected an error for undefined parameter")
+	}
+}
+
+func TestExpandGroup_CombinationsSharingURL(t *testing.T) {
+	group := newTestGroup()
+	group.Spec.Template.Headers = map[string]string{"X-Region": "${region}"}
+	group.Spec.Matrix = map[string][]string{"region": {"eu", "us"}}
+	group.Spec.Hosts = []string{"a.example.com"}
+	group.Spec.Paths = []string{"/health"}
+
+	monitors, err := expandGroup(group)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(monitors) != 2 {
+		t.Fatalf("Expected a monitor per region header, got %d", len(monitors))
+	}
+
+	group.Spec.Template.Headers = nil
+	monitors, err = expandGroup(group)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(monitors) != 1 {
+		t.Errorf("Expected identical monitors to be generated once, got %d", len(monitors))
+	}
+}
+
+func TestSubstituteParams(t *testing.T) {
+	params := map[string]string{"a": "${b}", "b": "x"}
+	for i := 0; i < 20; i++ {
+		if got := substituteParams("${a}-${b}", params); got != "${b}-x" {
+			t.Fatalf("Expected placeholders inside values to be kept, got %q", got)
+		}
+	}
+}
+
+func TestURLMonitorGroupReconcile_LongName(t *testing.T) {
+	scheme := newTestScheme(t)
+	group := newTestGroup()
+	group.Name = strings.Repeat("edge-", 20)
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(group).
+		WithStatusSubresource(group).
+		Build()
+
+	r := NewURLMonitorGroupReconciler(c, scheme, monitor.NopLogger(), record.NewFakeRecorder(100))
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(group)}
+	ctx := context.Background()
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	children := &urlmonitorv1.URLMonitorList{}
+	if err := c.List(ctx, children); err != nil {
+		t.Fatalf("Failed to list children: %v", err)
+	}
+	if len(children.Items) != 4 {
+		t.Fatalf("Expected 4 children, got %d", len(children.Items))
+	}
+	for _, child := range children.Items {
+		if errs := validation.IsValidLabelValue(child.Labels[LabelGroup]); len(errs) > 0 {
+			t.Errorf("Expected a valid group label, got %q: %v", child.Labels[LabelGroup], errs)
+		}
+	}
+}
+
+func TestURLMonitorGroupReconcile_ConflictingMonitor(t *testing.T) {
+	scheme := newTestScheme(t)
+	group := newTestGroup()
+	group.Spec.Hosts = []string{"a.example.com"}
+	group.Spec.Paths = []string{"/health"}
+	desired, err := expandGroup(group)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	foreign := &urlmonitorv1.URLMonitor{
+		ObjectMeta: metav1.ObjectMeta{Name: desired[0].Name, Namespace: "default"},
+		Spec:       urlmonitorv1.URLMonitorSpec{URL: "https://other.example.com"},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(group, foreign).
+		WithStatusSubresource(group).
+		Build()
+
+	recorder := record.NewFakeRecorder(100)
+	r := NewURLMonitorGroupReconciler(c, scheme, monitor.NopLogger(), recorder)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(group)}
+	ctx := context.Background()
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	latest := &urlmonitorv1.URLMonitor{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(foreign), latest); err != nil {
+		t.Fatalf("Failed to get URLMonitor: %v", err)
+	}
+	if latest.Spec.URL != "https://other.example.com" {
+		t.Errorf("Expected the foreign monitor to be left alone, got %s", latest.Spec.URL)
+	}
+	if event := <-recorder.Events; !strings.Contains(event, "MonitorConflict") {
+		t.Errorf("Expected a MonitorConflict event, got %q", event)
+	}
+}
+
+func TestURLMonitorGroupReconcile_PrunesRemovedChildren(t *testing.T) {
+	scheme := newTestScheme(t)
+	group := newTestGroup()
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(group).
+		WithStatusSubresource(group).
+		Build()
+
+	r := NewURLMonitorGroupReconciler(c, scheme, monitor.NopLogger(), record.NewFakeRecorder(100))
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "edge"}}
+	ctx := context.Background()
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	children := &urlmonitorv1.URLMonitorList{}
+	if err := c.List(ctx, children, client.MatchingLabels{LabelGroup: "edge"}); err != nil {
+		t.Fatalf("Failed to list children: %v", err)
+	}
+	if len(children.Items) != 4 {
+		t.Fatalf("Expected 4 children, got %d", len(children.Items))
+	}
+	if !metav1.IsControlledBy(&children.Items[0], group) {
+		t.Errorf("Expected child to be controlled by the group")
+	}
+
+	latest := &urlmonitorv1.URLMonitorGroup{}
+	if err := c.Get(ctx, req.NamespacedName, latest); err != nil {
+		t.Fatalf("Failed to get group: %v", err)
+	}
+	latest.Spec.Hosts = []string{"a.example.com"}
+	if err := c.Update(ctx, latest); err != nil {
+		t.Fatalf("Failed to update group: %v", err)
+	}
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+
+	if err := c.List(ctx, children, client.MatchingLabels{LabelGroup: "edge"}); err != nil {
+		t.Fatalf("Failed to list children: %v", err)
+	}
+	if len(children.Items) != 2 {
+		t.Errorf("Expected 2 children after removing a host, got %d", len(children.Items))
+	}
+
+	if err := c.Get(ctx, req.NamespacedName, latest); err != nil {
+		t.Fatalf("Failed to get group: %v", err)
+	}
+	if latest.Status.Monitors != 2 || latest.Status.Status != GroupStatusPending {
+		t.Errorf("Expected 2 pending monitors in status, got %d (%s)", latest.Status.Monitors, latest.Status.Status)
+	}
+}
+
+func TestAggregateGroupStatus(t *testing.T) {
+	children := []urlmonitorv1.URLMonitor{
+		{ObjectMeta: metav1.ObjectMeta{Name: "a"}, Status: urlmonitorv1.URLMonitorStatus{Status: "Up"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "b"}, Status: urlmonitorv1.URLMonitorStatus{Status: "Down"}},
+	}
+
+	status := aggregateGroupStatus(children)
+	if status.Status != GroupStatusDegraded {
+		t.Errorf("Expected status '%s', got '%s'", GroupStatusDegraded, status.Status)
+	}
+	if status.Up != 1 || status.Down != 1 {
+		t.Errorf("Expected 1 up and 1 down, got %d up and %d down", status.Up, status.Down)
+	}
+	if len(status.FailingMonitors) != 1 || status.FailingMonitors[0] != "b" {
+		t.Errorf("Expected failing monitors [b], got %v", status.FailingMonitors)
+	}
+
+	children[1].Status.Status = "Degraded"
+	if status := aggregateGroupStatus(children); status.Status != GroupStatusDegraded || status.Degraded != 1 || len(status.FailingMonitors) != 0 {
+		t.Errorf("Expected a degraded monitor to degrade the group without failing, got %+v", status)
+	}
+
+	children[1].Status.Status = "Critical"
+	if status := aggregateGroupStatus(children); status.Status != GroupStatusDegraded || status.Degraded != 1 || len(status.FailingMonitors) != 0 {
+		t.Errorf("Expected a critical monitor to degrade the group without failing, got %+v", status)
+	}
+
+	children[1].Status.Status = "Up"
+	if status := aggregateGroupStatus(children); status.Status != GroupStatusHealthy {
+		t.Errorf("Expected status '%s', got '%s'", GroupStatusHealthy, status.Status)
+	}
+}
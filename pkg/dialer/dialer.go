@@ -0,0 +1,150 @@
+package dialer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// DefaultDNSPort is used for DNS servers configured without a port
+const DefaultDNSPort = "53"
+
+// IP families a target can be checked over
+const (
+	IPFamilyAny  = "any"
+	IPFamilyIPv4 = "ipv4"
+	IPFamilyIPv6 = "ipv6"
+	// IPFamilyBoth checks the target over IPv4 and IPv6 separately
+	IPFamilyBoth = "both"
+)
+
+// Options configure how the addresses of a target are resolved
+type Options struct {
+	// Resolve maps host:port pairs to the address connected to instead, like curl --resolve.
+	// Addresses without a port keep the port of the original address.
+	Resolve map[string]string
+	// DNSServer is the host:port of the DNS server used instead of the system resolver
+	DNSServer string
+	// Timeout bounds connecting, name resolution included, no limit when zero
+	Timeout time.Duration
+	// Network restricts TCP connections to "tcp4" or "tcp6", any family when empty
+	Network string
+	// Proxy is the URL of the HTTP or SOCKS5 proxy to connect through, ProxyEnv or ProxyDirect.
+	// Resolution overrides, the DNS server and the network apply to the connection to the proxy.
+	Proxy string
+}
+
+// IsZero reports whether the options leave connecting to the system defaults
+func (o Options) IsZero() bool {
+	return len(o.Resolve) == 0 && o.DNSServer == "" && o.Network == "" && o.Proxy == ""
+}
+
+// DialContext connects to the address, through the proxy when one applies
+func (o Options) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	proxyURL, err := o.proxyURL(address)
+	if err != nil {
+		return nil, &ProxyError{Err: err}
+	}
+	if proxyURL != nil {
+		return o.dialProxy(ctx, proxyURL, address)
+	}
+	return o.dialDirect(ctx, network, address)
+}
+
+// dialDirect connects to the address after applying the resolve overrides, the DNS
+// server and the network
+func (o Options) dialDirect(ctx context.Context, network, address string) (net.Conn, error) {
+	d := &net.Dialer{Timeout: o.Timeout}
+	if o.DNSServer != "" {
+		server := dnsServerAddress(o.DNSServer)
+		d.Resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return (&net.Dialer{Timeout: o.Timeout}).DialContext(ctx, network, server)
+			},
+		}
+	}
+	if o.Network != "" && network == "tcp" {
+		network = o.Network
+	}
+	return d.DialContext(ctx, network, o.Override(address))
+}
+
+// Network returns the TCP network restricting connections to an IP family,
+// empty for families that don't restrict connections
+func Network(family string) string {
+	switch family {
+	case IPFamilyIPv4:
+		return "tcp4"
+	case IPFamilyIPv6:
+		return "tcp6"
+	default:
+		return ""
+	}
+}
+
+// ValidateIPFamily checks that family is one of the supported IP families, empty included
+func ValidateIPFamily(family string) error {
+	switch family {
+	case "", IPFamilyAny, IPFamilyIPv4, IPFamilyIPv6, IPFamilyBoth:
+		return nil
+	default:
+		return fmt.Errorf("ip family %q must be one of %s, %s, %s or %s",
+			family, IPFamilyAny, IPFamilyIPv4, IPFamilyIPv6, IPFamilyBoth)
+	}
+}
+
+// Override returns the address to connect to instead of address, or address itself
+func (o Options) Override(address string) string {
+	for from, to := range o.Resolve {
+		if !strings.EqualFold(from, address) {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(to); err == nil {
+			return to
+		}
+		_, port, _ := net.SplitHostPort(address)
+		return net.JoinHostPort(strings.Trim(to, "[]"), port)
+	}
+	return address
+}
+
+// Validate checks resolve overrides and a DNS server address
+func Validate(resolve map[string]string, dnsServer string) error {
+	for from, to := range resolve {
+		if _, _, err := net.SplitHostPort(from); err != nil {
+			return fmt.Errorf("resolve key %q must be host:port", from)
+		}
+		if to == "" {
+			return fmt.Errorf("resolve entry for %q has an empty address", from)
+		}
+	}
+
+	if dnsServer != "" {
+		host, _, err := net.SplitHostPort(dnsServerAddress(dnsServer))
+		if err != nil || net.ParseIP(host) == nil {
+			return fmt.Errorf("dns server %q must be an IP address with an optional port", dnsServer)
+		}
+	}
+
+	return nil
+}
+
+// RemoteIP returns the IP address a connection is connected to
+func RemoteIP(conn net.Conn) string {
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+// dnsServerAddress adds the default DNS port to a server address without one
+func dnsServerAddress(server string) string {
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return server
+	}
+	return net.JoinHostPort(strings.Trim(server, "[]"), DefaultDNSPort)
+}
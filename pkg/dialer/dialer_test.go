@@ -0,0 +1,129 @@
+package dialer
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestOverride(t *testing.T) {
+	opts := Options{Resolve: map[string]string{
+		"www.example.com:443":  "192.0.2.10",
+		"api.example.com:8443": "192.0.2.20:9443",
+		"v6.example.com:443":   "[2001:db8::1]",
+	}}
+
+	for address, expected := range map[string]string{
+		"www.example.com:443":   "192.0.2.10:443",
+		"WWW.Example.com:443":   "192.0.2.10:443",
+		"api.example.com:8443":  "192.0.2.20:9443",
+		"v6.example.com:443":    "[2001:db8::1]:443",
+		"www.example.com:80":    "www.example.com:80",
+		"other.example.com:443": "other.example.com:443",
+	} {
+		if got := opts.Override(address); got != expected {
+			t.Errorf("Expected Override(%q) to be %q, got %q", address, expected, got)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		resolve   map[string]string
+		dnsServer string
+		wantErr   bool
+	}{
+		"empty":               {},
+		"valid":               {resolve: map[string]string{"example.com:443": "192.0.2.10"}, dnsServer: "10.0.0.53"},
+		"DNS server port":     {dnsServer: "10.0.0.53:5353"},
+		"IPv6 DNS server":     {dnsServer: "2001:db8::53"},
+		"key without port":    {resolve: map[string]string{"example.com": "192.0.2.10"}, wantErr: true},
+		"empty address":       {resolve: map[string]string{"example.com:443": ""}, wantErr: true},
+		"DNS server hostname": {dnsServer: "dns.example.com", wantErr: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := Validate(tc.resolve, tc.dnsServer)
+			if tc.wantErr && err == nil {
+				t.Errorf("Expected an error")
+			}
+			if !tc.wantErr && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDialContext_Resolve(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	opts := Options{
+		Resolve: map[string]string{"unresolvable.invalid:" + port: "127.0.0.1"},
+		Timeout: 5 * time.Second,
+	}
+
+	conn, err := opts.DialContext(context.Background(), "tcp", "unresolvable.invalid:"+port)
+	if err != nil {
+		t.Fatalf("Expected the override to be dialed, got %v", err)
+	}
+	defer conn.Close()
+
+	if ip := RemoteIP(conn); ip != "127.0.0.1" {
+		t.Errorf("Expected remote IP 127.0.0.1, got %q", ip)
+	}
+}
+
+func TestDialContext_Network(t *testing.T) {
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	ipv4 := Options{Network: Network(IPFamilyIPv4), Timeout: 5 * time.Second}
+	conn, err := ipv4.DialContext(context.Background(), "tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Expected an IPv4 connection, got %v", err)
+	}
+	conn.Close()
+
+	ipv6 := Options{Network: Network(IPFamilyIPv6), Timeout: 5 * time.Second}
+	if conn, err := ipv6.DialContext(context.Background(), "tcp", listener.Addr().String()); err == nil {
+		conn.Close()
+		t.Errorf("Expected an IPv6-only connection to an IPv4 address to fail")
+	}
+}
+
+func TestValidateIPFamily(t *testing.T) {
+	for _, family := range []string{"", IPFamilyAny, IPFamilyIPv4, IPFamilyIPv6, IPFamilyBoth} {
+		if err := ValidateIPFamily(family); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", family, err)
+		}
+	}
+	if err := ValidateIPFamily("ipv5"); err == nil {
+		t.Errorf("Expected an error for an unknown IP family")
+	}
+}
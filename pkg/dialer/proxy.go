@@ -0,0 +1,368 @@
+package dialer
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+const (
+	// ProxyEnv selects the proxy from the HTTPS_PROXY and NO_PROXY environment variables
+	ProxyEnv = "env"
+	// ProxyDirect connects without a proxy, overriding a default proxy
+	ProxyDirect = "direct"
+
+	ProxySchemeHTTP   = "http"
+	ProxySchemeHTTPS  = "https"
+	ProxySchemeSOCKS5 = "socks5"
+
+	// socks5Version is the protocol version byte of SOCKS5 (RFC 1928)
+	socks5Version = 0x05
+	// socks5AuthVersion is the version byte of username/password authentication (RFC 1929)
+	socks5AuthVersion = 0x01
+)
+
+// defaultProxyPorts maps the supported proxy schemes to the port used when the URL has none
+var defaultProxyPorts = map[string]string{
+	ProxySchemeHTTP:   "80",
+	ProxySchemeHTTPS:  "443",
+	ProxySchemeSOCKS5: "1080",
+}
+
+// SOCKS5 reply codes reporting that the proxy couldn't reach the target rather than
+// refusing the request itself
+var socks5TargetReplies = map[byte]string{
+	0x03: "network unreachable",
+	0x04: "host unreachable",
+	0x05: "connection refused",
+	0x06: "TTL expired",
+}
+
+// ProxyError reports a failure to connect to or authenticate with the proxy, as
+// opposed to the proxy failing to reach the target
+type ProxyError struct {
+	// Proxy is the address of the proxy, empty when none could be selected
+	Proxy string
+	Err   error
+}
+
+func (e *ProxyError) Error() string {
+	if e.Proxy == "" {
+		return fmt.Sprintf("proxy: %v", e.Err)
+	}
+	return fmt.Sprintf("proxy %s: %v", e.Proxy, e.Err)
+}
+
+func (e *ProxyError) Unwrap() error {
+	return e.Err
+}
+
+// IsProxyError reports whether err was caused by the proxy rather than the target.
+// Besides ProxyError it recognizes the proxy dial failures of net/http.
+func IsProxyError(err error) bool {
+	var proxyErr *ProxyError
+	if errors.As(err, &proxyErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "proxyconnect"
+}
+
+// ValidateProxy checks a proxy setting: empty, ProxyEnv, ProxyDirect or an
+// http, https or socks5 URL with a host
+func ValidateProxy(proxy string) error {
+	_, err := parseProxy(proxy)
+	return err
+}
+
+// parseProxy parses a proxy URL, nil for the settings that aren't URLs
+func parseProxy(proxy string) (*url.URL, error) {
+	if proxy == "" || proxy == ProxyEnv || proxy == ProxyDirect {
+		return nil, nil
+	}
+
+	// The URL may hold credentials, so errors don't repeat it
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return nil, errors.New("invalid proxy URL")
+	}
+	if _, ok := defaultProxyPorts[proxyURL.Scheme]; !ok {
+		return nil, fmt.Errorf("proxy must be %s, %s or an http, https or socks5 URL", ProxyEnv, ProxyDirect)
+	}
+	if proxyURL.Hostname() == "" {
+		return nil, errors.New("proxy URL must include a host")
+	}
+	return proxyURL, nil
+}
+
+// proxyURL returns the proxy used to connect to address, nil to connect directly
+func (o Options) proxyURL(address string) (*url.URL, error) {
+	if o.Proxy != ProxyEnv {
+		return parseProxy(o.Proxy)
+	}
+
+	// Connections made by the dialer carry TLS, so the HTTPS_PROXY rules apply
+	proxyURL, err := httpproxy.FromEnvironment().ProxyFunc()(&url.URL{Scheme: "https", Host: address})
+	if err != nil || proxyURL == nil {
+		return nil, err
+	}
+	if _, ok := defaultProxyPorts[proxyURL.Scheme]; !ok {
+		return nil, fmt.Errorf("unsupported proxy scheme %q in environment", proxyURL.Scheme)
+	}
+	return proxyURL, nil
+}
+
+// ConfigureTransport makes an HTTP transport connect as described by the options.
+// HTTP proxies are left to the transport, which sends plain HTTP requests to them
+// and tunnels HTTPS with CONNECT, while SOCKS5 proxies are dialed by the options.
+func (o Options) ConfigureTransport(transport *http.Transport) error {
+	direct := o
+	direct.Proxy = ""
+	transport.DialContext = direct.DialContext
+
+	switch o.Proxy {
+	case "":
+		// Keep the proxy selection of the transport
+		return nil
+	case ProxyDirect:
+		transport.Proxy = nil
+		return nil
+	case ProxyEnv:
+		transport.Proxy = http.ProxyFromEnvironment
+	default:
+		proxyURL, err := parseProxy(o.Proxy)
+		if err != nil {
+			return err
+		}
+		if proxyURL.Scheme == ProxySchemeSOCKS5 {
+			transport.Proxy = nil
+			transport.DialContext = o.DialContext
+			return nil
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	transport.OnProxyConnectResponse = func(_ context.Context, proxyURL *url.URL, _ *http.Request, resp *http.Response) error {
+		if resp.StatusCode == http.StatusProxyAuthRequired {
+			return &ProxyError{Proxy: proxyURL.Host, Err: errors.New(resp.Status)}
+		}
+		return nil
+	}
+	return nil
+}
+
+// dialProxy connects to address through the proxy
+func (o Options) dialProxy(ctx context.Context, proxyURL *url.URL, address string) (net.Conn, error) {
+	proxyAddress := proxyURL.Host
+	if proxyURL.Port() == "" {
+		proxyAddress = net.JoinHostPort(proxyURL.Hostname(), defaultProxyPorts[proxyURL.Scheme])
+	}
+
+	conn, err := o.dialDirect(ctx, "tcp", proxyAddress)
+	if err != nil {
+		return nil, &ProxyError{Proxy: proxyAddress, Err: err}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if proxyURL.Scheme == ProxySchemeHTTPS {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, &ProxyError{Proxy: proxyAddress, Err: err}
+		}
+		conn = tlsConn
+	}
+
+	if proxyURL.Scheme == ProxySchemeSOCKS5 {
+		err = socks5Connect(conn, proxyURL.User, address)
+	} else {
+		conn, err = httpConnect(conn, proxyURL.User, address)
+	}
+	if err != nil {
+		conn.Close()
+		var targetErr *proxyTargetError
+		if errors.As(err, &targetErr) {
+			return nil, err
+		}
+		return nil, &ProxyError{Proxy: proxyAddress, Err: err}
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// proxyTargetError reports that the proxy accepted the request but couldn't reach the target
+type proxyTargetError struct {
+	msg string
+}
+
+func (e *proxyTargetError) Error() string {
+	return e.msg
+}
+
+// httpConnect opens a tunnel to address with an HTTP CONNECT request
+func httpConnect(conn net.Conn, user *url.Userinfo, address string) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		return conn, err
+	}
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		return conn, fmt.Errorf("failed to read CONNECT response: %w", err)
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode >= http.StatusInternalServerError:
+		// Bad gateway and gateway timeout mean the proxy couldn't reach the target
+		return conn, &proxyTargetError{msg: fmt.Sprintf("proxy couldn't connect to %s: %s", address, resp.Status)}
+	default:
+		return conn, fmt.Errorf("CONNECT refused: %s", resp.Status)
+	}
+
+	if r.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: r}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a connection whose first bytes were already read into a buffer
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+// socks5Connect asks a SOCKS5 proxy to connect to address as described in RFC 1928,
+// authenticating with a username and password (RFC 1929) when user is set
+func socks5Connect(conn net.Conn, user *url.Userinfo, address string) error {
+	host, portString, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.ParseUint(portString, 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid port %q", portString)
+	}
+
+	method := byte(0x00) // No authentication
+	if user != nil {
+		method = 0x02 // Username/password
+	}
+	if _, err := conn.Write([]byte{socks5Version, 1, method}); err != nil {
+		return err
+	}
+
+	greeting := make([]byte, 2)
+	if _, err := io.ReadFull(conn, greeting); err != nil {
+		return fmt.Errorf("failed to read SOCKS5 greeting: %w", err)
+	}
+	if greeting[0] != socks5Version {
+		return fmt.Errorf("unexpected SOCKS version %d", greeting[0])
+	}
+	if greeting[1] != method {
+		return fmt.Errorf("SOCKS5 proxy doesn't accept the authentication method")
+	}
+
+	if user != nil {
+		password, _ := user.Password()
+		if len(user.Username()) > 255 || len(password) > 255 {
+			return fmt.Errorf("SOCKS5 username and password are limited to 255 bytes")
+		}
+		auth := []byte{socks5AuthVersion, byte(len(user.Username()))}
+		auth = append(auth, user.Username()...)
+		auth = append(auth, byte(len(password)))
+		auth = append(auth, password...)
+		if _, err := conn.Write(auth); err != nil {
+			return err
+		}
+
+		status := make([]byte, 2)
+		if _, err := io.ReadFull(conn, status); err != nil {
+			return fmt.Errorf("failed to read SOCKS5 authentication status: %w", err)
+		}
+		if status[1] != 0x00 {
+			return fmt.Errorf("SOCKS5 authentication failed")
+		}
+	}
+
+	// CONNECT with the address as IPv4, IPv6 or domain name, leaving resolution to the proxy
+	request := []byte{socks5Version, 0x01, 0x00}
+	if ip := net.ParseIP(host); ip == nil {
+		if len(host) > 255 {
+			return fmt.Errorf("host name %q is too long for SOCKS5", host)
+		}
+		request = append(request, 0x03, byte(len(host)))
+		request = append(request, host...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		request = append(request, 0x01)
+		request = append(request, ip4...)
+	} else {
+		request = append(request, 0x04)
+		request = append(request, ip.To16()...)
+	}
+	request = binary.BigEndian.AppendUint16(request, uint16(port))
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	reply := make([]byte, 4)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return fmt.Errorf("failed to read SOCKS5 reply: %w", err)
+	}
+	if reply[1] != 0x00 {
+		if reason, ok := socks5TargetReplies[reply[1]]; ok {
+			return &proxyTargetError{msg: fmt.Sprintf("proxy couldn't connect to %s: %s", address, reason)}
+		}
+		return fmt.Errorf("SOCKS5 CONNECT refused with reply code %d", reply[1])
+	}
+
+	// Skip the bound address
+	var boundLength int
+	switch reply[3] {
+	case 0x01:
+		boundLength = net.IPv4len
+	case 0x04:
+		boundLength = net.IPv6len
+	case 0x03:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return err
+		}
+		boundLength = int(length[0])
+	default:
+		return fmt.Errorf("unexpected SOCKS5 address type %d", reply[3])
+	}
+	_, err = io.ReadFull(conn, make([]byte, boundLength+2))
+	return err
+}
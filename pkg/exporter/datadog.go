@@ -16,6 +16,21 @@ const (
 	MetricTypeCounter  = "c"
 )
 
+const (
+	AlertTypeInfo    = "info"
+	AlertTypeSuccess = "success"
+	AlertTypeWarning = "warning"
+	AlertTypeError   = "error"
+)
+
+// Service check statuses
+const (
+	ServiceCheckOK       = 0
+	ServiceCheckWarning  = 1
+	ServiceCheckCritical = 2
+	ServiceCheckUnknown  = 3
+)
+
 // DatadogClient implements the DogStatsD client for sending metrics to Datadog.
 type DatadogClient struct {
 	conn      net.Conn
@@ -76,6 +91,47 @@ func (d *DatadogClient) Histogram(name string, value float64, tags []string) err
 	return d.send(name, value, MetricTypeHistogram, tags)
 }
 
+// Event sends a DogStatsD event. The alert type is one of the AlertType constants.
+func (d *DatadogClient) Event(title, text, alertType string, tags []string) error {
+	text = strings.ReplaceAll(text, "\n", "\\n")
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "_e{%d,%d}:%s|%s", len(title), len(text), title, text)
+	if alertType != "" {
+		message.WriteString("|t:")
+		message.WriteString(alertType)
+	}
+
+	if len(tags) > 0 {
+		message.WriteString("|#")
+		message.WriteString(strings.Join(tags, ","))
+	}
+
+	_, err := io.WriteString(d.conn, message.String())
+	return err
+}
+
+// ServiceCheck sends a DogStatsD service check, namespaced like metrics. The status is
+// one of the ServiceCheck constants.
+func (d *DatadogClient) ServiceCheck(name string, status int, message string, tags []string) error {
+	var check strings.Builder
+	fmt.Fprintf(&check, "_sc|%s%s|%d", d.namespace, name, status)
+
+	if len(tags) > 0 {
+		check.WriteString("|#")
+		check.WriteString(strings.Join(tags, ","))
+	}
+
+	// The message has to come last
+	if message != "" {
+		check.WriteString("|m:")
+		check.WriteString(strings.ReplaceAll(message, "\n", "\\n"))
+	}
+
+	_, err := io.WriteString(d.conn, check.String())
+	return err
+}
+
 // Count sends a counter metric.
 func (d *DatadogClient) Count(name string, value float64, tags []string) error {
 	return d.send(name, value, MetricTypeCounter, tags)
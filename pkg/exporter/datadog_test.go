@@ -120,4 +120,73 @@ func TestDatadogClient_SendMetrics(t *testing.T) {
 	case <-time.After(1 * time.Second):
 		t.Errorf("Timed out waiting for histogram message")
 	}
-}
\ No newline at end of file
+}
+func TestDatadogClient_SendEvent(t *testing.T) {
+	server := newMockUDPServer(t)
+	defer server.close()
+
+	host, portStr, err := net.SplitHostPort(server.addr)
+	if err != nil {
+		t.Fatalf("Invalid address format: %s", server.addr)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		t.Fatalf("Failed to parse port number: %v", err)
+	}
+
+	client, err := NewDatadogClient(host, port)
+	if err != nil {
+		t.Fatalf("Failed to create Datadog client: %v", err)
+	}
+	defer client.Close()
+
+	err = client.Event("Certificate rotated", "old serial 1\nnew serial 2", AlertTypeInfo, []string{"name:example"})
+	if err != nil {
+		t.Errorf("Error sending event: %v", err)
+	}
+
+	select {
+	case msg := <-server.received:
+		expected := "_e{19,26}:Certificate rotated|old serial 1\\nnew serial 2|t:info|#name:example"
+		if msg != expected {
+			t.Errorf("Expected event message '%s', got '%s'", expected, msg)
+		}
+	case <-time.After(1 * time.Second):
+		t.Errorf("Timed out waiting for event message")
+	}
+}
+
+func TestDatadogClient_SendServiceCheck(t *testing.T) {
+	server := newMockUDPServer(t)
+	defer server.close()
+
+	host, portStr, err := net.SplitHostPort(server.addr)
+	if err != nil {
+		t.Fatalf("Invalid address format: %s", server.addr)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		t.Fatalf("Failed to parse port number: %v", err)
+	}
+
+	client, err := NewDatadogClient(host, port)
+	if err != nil {
+		t.Fatalf("Failed to create Datadog client: %v", err)
+	}
+	defer client.Close()
+
+	err = client.ServiceCheck("url.status", ServiceCheckWarning, "slow\nresponse", []string{"name:example"})
+	if err != nil {
+		t.Errorf("Error sending service check: %v", err)
+	}
+
+	select {
+	case msg := <-server.received:
+		expected := "_sc|url_monitor.url.status|1|#name:example|m:slow\\nresponse"
+		if msg != expected {
+			t.Errorf("Expected service check message '%s', got '%s'", expected, msg)
+		}
+	case <-time.After(1 * time.Second):
+		t.Errorf("Timed out waiting for service check message")
+	}
+}
@@ -5,4 +5,13 @@ type MetricsExporter interface {
 	Gauge(name string, value float64, tags []string) error
 	Histogram(name string, value float64, tags []string) error
 	Count(name string, value float64, tags []string) error
-}
\ No newline at end of file
+}
+// EventExporter is implemented by exporters that can also send events
+type EventExporter interface {
+	Event(title, text, alertType string, tags []string) error
+}
+
+// ServiceCheckExporter is implemented by exporters that can also send service checks
+type ServiceCheckExporter interface {
+	ServiceCheck(name string, status int, message string, tags []string) error
+}
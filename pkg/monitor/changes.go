@@ -0,0 +1,63 @@
+package monitor
+
+import "sync"
+
+// ChangeTracker remembers the last seen value per target, like the serial number of its
+// certificate or the hash of its content, to report when it changes
+type ChangeTracker struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+// NewChangeTracker creates a new empty ChangeTracker
+func NewChangeTracker() *ChangeTracker {
+	return &ChangeTracker{values: make(map[string]string)}
+}
+
+// Seed records a value for a target that hasn't been observed yet
+func (t *ChangeTracker) Seed(key, value string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.values[key]; !ok && value != "" {
+		t.values[key] = value
+	}
+}
+
+// Observe records the value seen for a target. It returns the previously seen value and
+// whether the value changed since the last observation.
+func (t *ChangeTracker) Observe(key, value string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	previous, ok := t.values[key]
+	t.values[key] = value
+	return previous, ok && previous != value
+}
+
+// Forget drops the remembered value of a target
+func (t *ChangeTracker) Forget(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.values, key)
+}
+
+// Snapshot returns a copy of the remembered values by target
+func (t *ChangeTracker) Snapshot() map[string]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snapshot := make(map[string]string, len(t.values))
+	for key, value := range t.values {
+		snapshot[key] = value
+	}
+	return snapshot
+}
+
+// Restore seeds the values of a snapshot, keeping those observed since
+func (t *ChangeTracker) Restore(snapshot map[string]string) {
+	for key, value := range snapshot {
+		t.Seed(key, value)
+	}
+}
@@ -0,0 +1,28 @@
+package monitor
+
+import "testing"
+
+func TestChangeTracker(t *testing.T) {
+	tracker := NewChangeTracker()
+
+	if _, changed := tracker.Observe("example", "1"); changed {
+		t.Errorf("Expected the first observation not to be a change")
+	}
+	if _, changed := tracker.Observe("example", "1"); changed {
+		t.Errorf("Expected an unchanged value not to be a change")
+	}
+	if previous, changed := tracker.Observe("example", "2"); !changed || previous != "1" {
+		t.Errorf("Expected a change from value 1, got %q (changed: %v)", previous, changed)
+	}
+
+	tracker.Seed("seeded", "10")
+	tracker.Seed("seeded", "11")
+	if previous, changed := tracker.Observe("seeded", "12"); !changed || previous != "10" {
+		t.Errorf("Expected a change from seeded value 10, got %q (changed: %v)", previous, changed)
+	}
+
+	tracker.Forget("seeded")
+	if _, changed := tracker.Observe("seeded", "13"); changed {
+		t.Errorf("Expected a forgotten target not to report a change")
+	}
+}
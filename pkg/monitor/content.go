@@ -0,0 +1,80 @@
+package monitor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+	"github.com/kuskoman/url-datadog-monitor/pkg/exporter"
+)
+
+// MaxContentHashBytes limits how much of a response body is kept in memory and hashed
+// for change detection. Larger bodies are hashed up to the limit.
+const MaxContentHashBytes = 10 << 20
+
+// readBody drains a response body, returning its size and, for targets detecting changes,
+// the hash of its normalized content
+func readBody(target config.Target, body io.Reader) (int64, string) {
+	if !target.DetectChanges {
+		size, _ := io.Copy(io.Discard, body)
+		return size, ""
+	}
+
+	content, _ := io.ReadAll(io.LimitReader(body, MaxContentHashBytes))
+	rest, _ := io.Copy(io.Discard, body)
+	return int64(len(content)) + rest, ContentHash(content, target.ChangeIgnoreRegexes)
+}
+
+// ContentHash returns the hex SHA-256 hash of a body after removing the parts matching
+// the ignore regexes and collapsing whitespace, so reformatting and volatile values like
+// timestamps don't count as changes. Invalid regexes are skipped.
+func ContentHash(content []byte, ignoreRegexes []string) string {
+	for _, pattern := range ignoreRegexes {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			continue
+		}
+		content = re.ReplaceAll(content, nil)
+	}
+
+	normalized := strings.Join(strings.Fields(string(content)), " ")
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// observeContent records the content hash of a target and reports content changes. It
+// returns the previous hash when the content changed.
+func (r *Runner) observeContent(name string, target config.Target, result Result, tags []string) string {
+	previous, changed := r.contentHashes.Observe(name, result.ContentHash)
+	if !changed {
+		return ""
+	}
+
+	r.Logger.Warn("Target content changed",
+		slog.String("target", target.Name),
+		slog.String("url", result.FinalURL),
+		slog.String("previous_hash", previous),
+		slog.String("hash", result.ContentHash),
+		slog.Int64("response_bytes", result.ResponseBytes))
+
+	events, ok := r.Metrics.(exporter.EventExporter)
+	if !ok {
+		return previous
+	}
+
+	title := "Content changed for " + target.Name
+	text := fmt.Sprintf("The content served by %s changed from hash %s to %s (%d bytes)",
+		result.FinalURL, previous, result.ContentHash, result.ResponseBytes)
+	if err := events.Event(title, text, exporter.AlertTypeWarning, tags); err != nil {
+		r.Logger.Warn("Failed to send content change event",
+			slog.String("target", target.Name),
+			slog.String("url", target.URL),
+			slog.Any("error", err))
+	}
+	return previous
+}
@@ -0,0 +1,69 @@
+package monitor
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+)
+
+func TestContentHash(t *testing.T) {
+	ignore := []string{`Generated at \d{2}:\d{2}:\d{2}`, `nonce="[^"]*"`}
+	base := ContentHash([]byte(`<p>All systems operational</p> Generated at 10:00:00 <script nonce="abc">`), ignore)
+
+	for name, tc := range map[string]struct {
+		body    string
+		changed bool
+	}{
+		"ignored timestamp and nonce": {`<p>All systems operational</p> Generated at 11:42:07 <script nonce="xyz">`, false},
+		"reformatted":                 {"<p>All systems operational</p>\n\tGenerated at 10:00:00\n<script nonce=\"abc\">", false},
+		"changed text":                {`<p>Partial outage</p> Generated at 10:00:00 <script nonce="abc">`, true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if hash := ContentHash([]byte(tc.body), ignore); (hash != base) != tc.changed {
+				t.Errorf("Expected changed=%v, got hash %s for base %s", tc.changed, hash, base)
+			}
+		})
+	}
+}
+
+func TestRunner_DetectChanges(t *testing.T) {
+	version := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, "status page v%d, rendered %s", version, time.Now().Format(time.RFC3339Nano))
+	}))
+	defer server.Close()
+
+	target := config.Target{
+		Name:                "Status page",
+		URL:                 server.URL,
+		Method:              "GET",
+		DetectChanges:       true,
+		ChangeIgnoreRegexes: []string{`rendered \S+`},
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	mock := &mockEventDatadog{}
+	runner := NewRunner(mock, NopLogger())
+	for _, v := range []int{1, 1, 2} {
+		version = v
+		runner.Check(client, target)
+	}
+
+	if len(mock.eventTitles) != 1 || mock.eventTitles[0] != "Content changed for Status page" {
+		t.Errorf("Expected a single content change event, got %v", mock.eventTitles)
+	}
+
+	result := Probe(client, target)
+	if result.ContentHash == "" || result.ResponseBytes < int64(len("status page v2, rendered ")) {
+		t.Errorf("Expected the body to be hashed and measured, got %+v", result)
+	}
+
+	target.DetectChanges = false
+	if result = Probe(client, target); result.ContentHash != "" || result.ResponseBytes == 0 {
+		t.Errorf("Expected only the size without detect_changes, got %+v", result)
+	}
+}
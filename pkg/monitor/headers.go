@@ -0,0 +1,163 @@
+package monitor
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+)
+
+// MinHSTSMaxAge is the lowest Strict-Transport-Security max-age the security audit
+// accepts, six months in seconds
+const MinHSTSMaxAge = 15552000
+
+// Headers and cookie flags checked by the security audit, used as security_header tag values
+const (
+	AuditHSTS               = "strict-transport-security"
+	AuditCSP                = "content-security-policy"
+	AuditContentTypeOptions = "x-content-type-options"
+	AuditCookies            = "set-cookie"
+)
+
+// SecurityCheck is the outcome of one check of the security header audit
+type SecurityCheck struct {
+	// Header is one of the Audit constants
+	Header string
+	Passed bool
+	// Reason explains why the check failed
+	Reason string
+}
+
+// CheckHeaderAssertions returns a description of every assertion the response headers fail
+func CheckHeaderAssertions(assertions []config.HeaderAssertion, header http.Header) []string {
+	var failures []string
+	for _, assertion := range assertions {
+		values := header.Values(assertion.Header)
+		switch {
+		case assertion.Present && len(values) == 0:
+			failures = append(failures, fmt.Sprintf("%s is missing", assertion.Header))
+		case assertion.Absent && len(values) > 0:
+			failures = append(failures, fmt.Sprintf("%s is present", assertion.Header))
+		case assertion.Equals != "" && !anyValue(values, func(v string) bool { return v == assertion.Equals }):
+			failures = append(failures, fmt.Sprintf("%s doesn't equal %q", assertion.Header, assertion.Equals))
+		case assertion.Regex != "":
+			re, err := regexp.Compile(assertion.Regex)
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("%s has an invalid regex: %v", assertion.Header, err))
+			} else if !anyValue(values, re.MatchString) {
+				failures = append(failures, fmt.Sprintf("%s doesn't match %q", assertion.Header, assertion.Regex))
+			}
+		}
+	}
+	return failures
+}
+
+// anyValue reports whether match holds for one of the header values
+func anyValue(values []string, match func(string) bool) bool {
+	for _, value := range values {
+		if match(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditSecurityHeaders checks the security headers and cookie flags of a response.
+// HSTS and the Secure cookie flag only apply to responses received over HTTPS.
+func AuditSecurityHeaders(resp *http.Response) []SecurityCheck {
+	secure := resp.Request != nil && resp.Request.URL.Scheme == "https"
+	var checks []SecurityCheck
+
+	if secure {
+		checks = append(checks, auditHSTS(resp.Header.Get("Strict-Transport-Security")))
+	}
+
+	csp := SecurityCheck{Header: AuditCSP, Passed: strings.TrimSpace(resp.Header.Get("Content-Security-Policy")) != ""}
+	if !csp.Passed {
+		csp.Reason = "missing"
+	}
+	checks = append(checks, csp)
+
+	contentTypeOptions := SecurityCheck{Header: AuditContentTypeOptions, Passed: true}
+	if value := resp.Header.Get("X-Content-Type-Options"); !strings.EqualFold(strings.TrimSpace(value), "nosniff") {
+		contentTypeOptions = SecurityCheck{Header: AuditContentTypeOptions, Reason: "must be nosniff"}
+	}
+	checks = append(checks, contentTypeOptions)
+
+	cookies := SecurityCheck{Header: AuditCookies, Passed: true}
+	var insecure []string
+	for _, cookie := range resp.Cookies() {
+		var missing []string
+		if secure && !cookie.Secure {
+			missing = append(missing, "Secure")
+		}
+		if !cookie.HttpOnly {
+			missing = append(missing, "HttpOnly")
+		}
+		if len(missing) > 0 {
+			insecure = append(insecure, fmt.Sprintf("%s lacks %s", cookie.Name, strings.Join(missing, " and ")))
+		}
+	}
+	if len(insecure) > 0 {
+		cookies = SecurityCheck{Header: AuditCookies, Reason: strings.Join(insecure, ", ")}
+	}
+	return append(checks, cookies)
+}
+
+// auditHSTS checks that Strict-Transport-Security is sent with a max-age of at least MinHSTSMaxAge
+func auditHSTS(value string) SecurityCheck {
+	check := SecurityCheck{Header: AuditHSTS}
+	if value == "" {
+		check.Reason = "missing"
+		return check
+	}
+
+	for _, directive := range strings.Split(value, ";") {
+		name, maxAge, found := strings.Cut(strings.TrimSpace(directive), "=")
+		if !found || !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.Trim(maxAge, `"`))
+		if err != nil || seconds < MinHSTSMaxAge {
+			check.Reason = fmt.Sprintf("max-age must be at least %d seconds", MinHSTSMaxAge)
+			return check
+		}
+		check.Passed = true
+		return check
+	}
+	check.Reason = "max-age is missing"
+	return check
+}
+
+// SecurityScore returns the percentage of passed security checks
+func SecurityScore(checks []SecurityCheck) float64 {
+	if len(checks) == 0 {
+		return 0
+	}
+	passed := 0
+	for _, check := range checks {
+		if check.Passed {
+			passed++
+		}
+	}
+	return float64(passed) * 100 / float64(len(checks))
+}
+
+// SecurityFailures returns the failed security checks as "header: reason" descriptions
+func SecurityFailures(checks []SecurityCheck) []string {
+	var failures []string
+	for _, check := range checks {
+		if !check.Passed {
+			failures = append(failures, check.Header+": "+check.Reason)
+		}
+	}
+	return failures
+}
+
+// AuditsSecurityHeaders reports whether the security header audit runs for the target
+func AuditsSecurityHeaders(target config.Target) bool {
+	return IsHTTPTarget(target) && target.SecurityAudit != nil && *target.SecurityAudit
+}
@@ -0,0 +1,137 @@
+package monitor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+)
+
+func TestCheckHeaderAssertions(t *testing.T) {
+	header := http.Header{}
+	header.Set("Cache-Control", "no-store")
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	header.Add("Vary", "Accept")
+	header.Add("Vary", "Origin")
+
+	for name, tc := range map[string]struct {
+		assertion config.HeaderAssertion
+		passes    bool
+	}{
+		"present":             {config.HeaderAssertion{Header: "cache-control", Present: true}, true},
+		"present missing":     {config.HeaderAssertion{Header: "ETag", Present: true}, false},
+		"absent":              {config.HeaderAssertion{Header: "Server", Absent: true}, true},
+		"absent sent":         {config.HeaderAssertion{Header: "Cache-Control", Absent: true}, false},
+		"equals":              {config.HeaderAssertion{Header: "Cache-Control", Equals: "no-store"}, true},
+		"equals other":        {config.HeaderAssertion{Header: "Cache-Control", Equals: "no-cache"}, false},
+		"equals second value": {config.HeaderAssertion{Header: "Vary", Equals: "Origin"}, true},
+		"regex":               {config.HeaderAssertion{Header: "Content-Type", Regex: "^application/json"}, true},
+		"regex mismatch":      {config.HeaderAssertion{Header: "Content-Type", Regex: "^text/"}, false},
+		"regex missing":       {config.HeaderAssertion{Header: "ETag", Regex: ".*"}, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			failures := CheckHeaderAssertions([]config.HeaderAssertion{tc.assertion}, header)
+			if (len(failures) == 0) != tc.passes {
+				t.Errorf("Expected passes=%v, got failures %v", tc.passes, failures)
+			}
+		})
+	}
+}
+
+func TestAuditSecurityHeaders(t *testing.T) {
+	hardened := func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
+		w.Header().Set("Content-Security-Policy", "default-src 'self'")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "1", Secure: true, HttpOnly: true})
+	}
+	weak := func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Strict-Transport-Security", "max-age=3600")
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "1"})
+	}
+
+	for name, tc := range map[string]struct {
+		handler  http.HandlerFunc
+		tls      bool
+		score    float64
+		failures []string
+	}{
+		"hardened HTTPS": {handler: hardened, tls: true, score: 100},
+		"weak HTTPS": {handler: weak, tls: true, score: 0, failures: []string{
+			AuditHSTS, AuditCSP, AuditContentTypeOptions, AuditCookies}},
+		// HSTS and Secure don't apply to plain HTTP
+		"weak HTTP": {handler: weak, score: 0, failures: []string{AuditCSP, AuditContentTypeOptions, AuditCookies}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewUnstartedServer(tc.handler)
+			if tc.tls {
+				server.StartTLS()
+			} else {
+				server.Start()
+			}
+			defer server.Close()
+
+			resp, err := server.Client().Get(server.URL)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			resp.Body.Close()
+
+			checks := AuditSecurityHeaders(resp)
+			if score := SecurityScore(checks); score != tc.score {
+				t.Errorf("Expected score %v, got %v (%+v)", tc.score, score, checks)
+			}
+			failures := SecurityFailures(checks)
+			if len(failures) != len(tc.failures) {
+				t.Fatalf("Expected failures for %v, got %v", tc.failures, failures)
+			}
+			for i, header := range tc.failures {
+				if !strings.HasPrefix(failures[i], header+":") {
+					t.Errorf("Expected failure %d to be for %s, got %q", i, header, failures[i])
+				}
+			}
+		})
+	}
+}
+
+func TestProbe_HeaderAssertionsAndAudit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Server", "nginx/1.18.0")
+	}))
+	defer server.Close()
+
+	audit := true
+	target := config.Target{
+		Name:          "audited",
+		URL:           server.URL,
+		Method:        "GET",
+		SecurityAudit: &audit,
+		HeaderAssertions: []config.HeaderAssertion{
+			{Header: "Server", Absent: true},
+		},
+	}
+
+	result := Probe(&http.Client{Timeout: 5 * time.Second}, target)
+	if result.Up || len(result.HeaderFailures) != 1 {
+		t.Errorf("Expected the Server header to fail the check, got %+v", result)
+	}
+	if score := SecurityScore(result.SecurityChecks); score != 200.0/3 {
+		t.Errorf("Expected X-Content-Type-Options and cookies to pass, got %v (%+v)", score, result.SecurityChecks)
+	}
+
+	mock := &recordingDatadog{upTags: make(map[float64][]string)}
+	NewRunner(mock, NopLogger()).Check(&http.Client{Timeout: 5 * time.Second}, target)
+	failedHeaders := 0
+	for _, name := range mock.gauges {
+		if name == MetricSecurityHeaderFailed {
+			failedHeaders++
+		}
+	}
+	if failedHeaders != 3 {
+		t.Errorf("Expected a url.security_header_failed gauge per audited header, got %v", mock.gauges)
+	}
+}
@@ -0,0 +1,121 @@
+package monitor
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+	"github.com/kuskoman/url-datadog-monitor/pkg/exporter"
+)
+
+// ServiceCheckURLStatus is the name of the service check reporting the health of a target
+const ServiceCheckURLStatus = "url.status"
+
+// Health is the state of a check that takes the latency thresholds of the target into account
+type Health int
+
+// Health states, from worst to best
+const (
+	// HealthDown is a failed check
+	HealthDown Health = iota
+	// HealthCritical is a successful check slower than the critical latency threshold
+	HealthCritical
+	// HealthDegraded is a successful check slower than the warning latency threshold
+	HealthDegraded
+	// HealthUp is a successful check within the latency thresholds
+	HealthUp
+)
+
+// String returns the name of the health state
+func (h Health) String() string {
+	switch h {
+	case HealthUp:
+		return "up"
+	case HealthDegraded:
+		return "degraded"
+	case HealthCritical:
+		return "critical"
+	default:
+		return "down"
+	}
+}
+
+// StatusValue returns the url.status gauge value: 0 down, 1 degraded and 2 up. Critical
+// checks count as down.
+func (h Health) StatusValue() float64 {
+	switch h {
+	case HealthUp:
+		return 2.0
+	case HealthDegraded:
+		return 1.0
+	default:
+		return 0.0
+	}
+}
+
+// ServiceCheckStatus returns the service check status of the health state
+func (h Health) ServiceCheckStatus() int {
+	switch h {
+	case HealthUp:
+		return exporter.ServiceCheckOK
+	case HealthDegraded:
+		return exporter.ServiceCheckWarning
+	default:
+		return exporter.ServiceCheckCritical
+	}
+}
+
+// CheckHealth returns the health of a check result. url.up only reflects whether the check
+// succeeded, the health also degrades when it was slower than the latency thresholds.
+func CheckHealth(target config.Target, result Result) Health {
+	switch {
+	case !result.Up:
+		return HealthDown
+	case target.LatencyCriticalMs > 0 && result.Duration > time.Duration(target.LatencyCriticalMs)*time.Millisecond:
+		return HealthCritical
+	case target.LatencyWarningMs > 0 && result.Duration > time.Duration(target.LatencyWarningMs)*time.Millisecond:
+		return HealthDegraded
+	default:
+		return HealthUp
+	}
+}
+
+// HealthMessage describes the health of a check for service checks and events
+func HealthMessage(target config.Target, result Result, health Health) string {
+	ms := result.Duration.Milliseconds()
+	switch health {
+	case HealthCritical:
+		return fmt.Sprintf("%s responded in %dms, above the critical threshold of %dms", target.URL, ms, target.LatencyCriticalMs)
+	case HealthDegraded:
+		return fmt.Sprintf("%s responded in %dms, above the warning threshold of %dms", target.URL, ms, target.LatencyWarningMs)
+	case HealthUp:
+		return fmt.Sprintf("%s responded in %dms", target.URL, ms)
+	}
+	if result.Err != nil {
+		return fmt.Sprintf("%s is down: %v", target.URL, result.Err)
+	}
+	return fmt.Sprintf("%s is down with status code %d", target.URL, result.Status)
+}
+
+// reportHealth sends the url.status gauge and, when the exporter supports it, the service check
+func (r *Runner) reportHealth(target config.Target, result Result, tags []string) {
+	health := CheckHealth(target, result)
+	if err := r.Metrics.Gauge(MetricStatus, health.StatusValue(), tags); err != nil {
+		r.Logger.Warn("Failed to send url.status metric",
+			slog.String("target", target.Name),
+			slog.String("url", target.URL),
+			slog.Any("error", err))
+	}
+
+	checks, ok := r.Metrics.(exporter.ServiceCheckExporter)
+	if !ok {
+		return
+	}
+	if err := checks.ServiceCheck(ServiceCheckURLStatus, health.ServiceCheckStatus(), HealthMessage(target, result, health), tags); err != nil {
+		r.Logger.Warn("Failed to send url.status service check",
+			slog.String("target", target.Name),
+			slog.String("url", target.URL),
+			slog.Any("error", err))
+	}
+}
@@ -0,0 +1,80 @@
+package monitor
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+	"github.com/kuskoman/url-datadog-monitor/pkg/exporter"
+)
+
+func TestCheckHealth(t *testing.T) {
+	target := config.Target{URL: "https://example.com", LatencyWarningMs: 500, LatencyCriticalMs: 2000}
+
+	for name, tc := range map[string]struct {
+		result       Result
+		health       Health
+		status       float64
+		serviceCheck int
+	}{
+		"fast":         {Result{Up: true, Duration: 100 * time.Millisecond}, HealthUp, 2, exporter.ServiceCheckOK},
+		"at warning":   {Result{Up: true, Duration: 500 * time.Millisecond}, HealthUp, 2, exporter.ServiceCheckOK},
+		"slow":         {Result{Up: true, Duration: 900 * time.Millisecond}, HealthDegraded, 1, exporter.ServiceCheckWarning},
+		"very slow":    {Result{Up: true, Duration: 9 * time.Second}, HealthCritical, 0, exporter.ServiceCheckCritical},
+		"down quickly": {Result{Status: 503, Duration: 10 * time.Millisecond}, HealthDown, 0, exporter.ServiceCheckCritical},
+		"failed":       {Result{Err: errors.New("connection refused")}, HealthDown, 0, exporter.ServiceCheckCritical},
+	} {
+		t.Run(name, func(t *testing.T) {
+			health := CheckHealth(target, tc.result)
+			if health != tc.health {
+				t.Fatalf("Expected %s, got %s", tc.health, health)
+			}
+			if health.StatusValue() != tc.status || health.ServiceCheckStatus() != tc.serviceCheck {
+				t.Errorf("Expected url.status %v and service check %d, got %v and %d",
+					tc.status, tc.serviceCheck, health.StatusValue(), health.ServiceCheckStatus())
+			}
+		})
+	}
+
+	if health := CheckHealth(config.Target{}, Result{Up: true, Duration: time.Minute}); health != HealthUp {
+		t.Errorf("Expected targets without thresholds to be up whatever the latency, got %s", health)
+	}
+}
+
+type serviceCheckDatadog struct {
+	mockDatadog
+	statuses map[string]float64
+	checks   []int
+}
+
+func (m *serviceCheckDatadog) Gauge(name string, value float64, tags []string) error {
+	m.statuses[name] = value
+	return m.mockDatadog.Gauge(name, value, tags)
+}
+
+func (m *serviceCheckDatadog) ServiceCheck(name string, status int, message string, tags []string) error {
+	m.checks = append(m.checks, status)
+	return nil
+}
+
+func TestRunner_LatencyThresholds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	mock := &serviceCheckDatadog{statuses: make(map[string]float64)}
+	target := config.Target{Name: "slow", URL: server.URL, Method: "GET", LatencyWarningMs: 10}
+	NewRunner(mock, NopLogger()).Check(&http.Client{Timeout: 5 * time.Second}, target)
+
+	if mock.statuses[MetricURLUp] != 1 || mock.statuses[MetricStatus] != 1 {
+		t.Errorf("Expected url.up 1 and url.status 1 (degraded), got %v", mock.statuses)
+	}
+	if len(mock.checks) != 1 || mock.checks[0] != exporter.ServiceCheckWarning {
+		t.Errorf("Expected a warning service check, got %v", mock.checks)
+	}
+}
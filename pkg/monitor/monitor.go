@@ -2,31 +2,215 @@ package monitor
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/http/httptrace"
+	"net/url"
 	"os"
 	"strings"
 	"time"
 	
 	"github.com/kuskoman/url-datadog-monitor/pkg/certcheck"
 	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+	"github.com/kuskoman/url-datadog-monitor/pkg/dialer"
+	"github.com/kuskoman/url-datadog-monitor/pkg/exporter"
+	"github.com/kuskoman/url-datadog-monitor/pkg/notifier"
+	"github.com/kuskoman/url-datadog-monitor/pkg/slo"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"golang.org/x/net/http2"
 )
 
 const (
-	MetricURLUp             = "url.up"
-	MetricResponseTime      = "url.response_time_ms"
-	MetricSSLValid          = "ssl.valid"
-	MetricSSLDaysToExpiry   = "ssl.days_until_expiry"
-	HealthyStatusMin        = 200
-	HealthyStatusMax        = 300
-	TickInterval            = 1 * time.Second
+	MetricURLUp                   = "url.up"
+	MetricResponseTime            = "url.response_time_ms"
+	MetricSSLValid                = "ssl.valid"
+	MetricSSLDaysToExpiry         = "ssl.days_until_expiry"
+	MetricSSLChainDaysToExpiry    = "ssl.chain_min_days_until_expiry"
+	MetricSSLWeak                 = "ssl.weak"
+	MetricSSLPolicyCompliant      = "ssl.policy_compliant"
+	MetricSSLPinMatch             = "ssl.pin_match"
+	MetricSSLRevoked              = "ssl.revoked"
+	MetricSSLOCSPStapled          = "ssl.ocsp_stapled"
+	MetricProxyError              = "url.proxy_error"
+	MetricRedirects               = "url.redirects"
+	MetricSSLRedirectValid        = "ssl.redirect_valid"
+	MetricSSLRedirectDaysToExpiry = "ssl.redirect_days_until_expiry"
+	MetricSecurityHeadersScore    = "url.security_headers_score"
+	MetricSecurityHeaderFailed    = "url.security_header_failed"
+	MetricResponseBytes           = "url.response_bytes"
+	MetricStatus                  = "url.status"
+	MetricSLOAttainment           = "url.slo.attainment"
+	MetricSLOErrorBudgetRemaining = "url.slo.error_budget_remaining"
+	MetricSLOBurnRate             = "url.slo.burn_rate"
+	SchemeHTTP                    = "http"
+	HealthyStatusMin              = 200
+	HealthyStatusMax              = 300
+	TickInterval                  = 1 * time.Second
 )
 
 // ShouldCheckCertificate determines if a certificate should be checked for a target
 func ShouldCheckCertificate(target config.Target) bool {
-	return strings.HasPrefix(strings.ToLower(target.URL), certcheck.SchemeHTTPS) && 
-		target.CheckCert != nil && *target.CheckCert
+	return certcheck.IsTLSURL(target.URL) && target.CheckCert != nil && *target.CheckCert
+}
+
+// IsHTTPTarget reports whether the target is checked with an HTTP request rather than a TLS handshake
+func IsHTTPTarget(target config.Target) bool {
+	scheme, _, _ := strings.Cut(strings.ToLower(target.URL), "://")
+	return scheme == SchemeHTTP || scheme == certcheck.SchemeHTTPS
+}
+
+// CertificateOptions builds the certificate check options for a target
+func CertificateOptions(target config.Target) (certcheck.Options, error) {
+	opts := certcheck.Options{
+		VerifyChain:      target.VerifyCert != nil && *target.VerifyCert,
+		Timeout:          time.Duration(target.Timeout) * time.Second,
+		Pins:             target.CertFingerprints,
+		CheckRevocation:  target.CheckRevocation != nil && *target.CheckRevocation,
+		ServerName:       target.ServerName,
+		ExpectedDNSNames: target.ExpectedDNSNames,
+	}
+	if dialOpts := DialOptions(target); !dialOpts.IsZero() {
+		opts.Dial = dialOpts.DialContext
+	}
+
+	roots, err := rootCAs(target)
+	if err != nil {
+		return opts, err
+	}
+	opts.RootCAs = roots
+
+	if target.TLSPolicy != nil {
+		policy, err := certcheck.NewTLSPolicy(target.TLSPolicy.MinVersion, target.TLSPolicy.Ciphers, target.TLSPolicy.ProbeLegacy)
+		if err != nil {
+			return opts, fmt.Errorf("invalid TLS policy: %w", err)
+		}
+		opts.TLSPolicy = policy
+	}
+
+	return opts, nil
+}
+
+// rootCAs returns the CA certificates trusted for the target, nil for the system trust store
+func rootCAs(target config.Target) (*x509.CertPool, error) {
+	switch {
+	case len(target.CAData) > 0:
+		return certcheck.NewCertPool(target.CAData)
+	case target.CAFile != "":
+		return certcheck.LoadCertPool(target.CAFile)
+	default:
+		return nil, nil
+	}
+}
+
+// DialOptions returns how the addresses of a target are resolved
+func DialOptions(target config.Target) dialer.Options {
+	return dialer.Options{
+		Resolve:   target.Resolve,
+		DNSServer: target.DNSServer,
+		Timeout:   time.Duration(target.Timeout) * time.Second,
+		Network:   dialer.Network(target.IPFamily),
+		Proxy:     target.Proxy,
+	}
+}
+
+// UsesProxy reports whether the target is configured to connect through a proxy
+func UsesProxy(target config.Target) bool {
+	return target.Proxy != "" && target.Proxy != dialer.ProxyDirect
+}
+
+// IPFamilies returns the target once per IP family it is checked over. Targets checked
+// over both families are split into an IPv4 and an IPv6 target, others are returned as is.
+func IPFamilies(target config.Target) []config.Target {
+	if target.IPFamily != dialer.IPFamilyBoth {
+		return []config.Target{target}
+	}
+
+	ipv4, ipv6 := target, target
+	ipv4.IPFamily = dialer.IPFamilyIPv4
+	ipv6.IPFamily = dialer.IPFamilyIPv6
+	return []config.Target{ipv4, ipv6}
+}
+
+// NewClient creates the HTTP client used to check a target. Targets trusting their own
+// CA certificates, overriding the server name, connecting differently or forcing a
+// protocol get a dedicated transport, the others share the default transport.
+// Callers close the idle connections of h2c and h3 clients once done.
+func NewClient(target config.Target) (*http.Client, error) {
+	client := &http.Client{
+		Timeout: time.Duration(target.Timeout) * time.Second,
+	}
+
+	roots, err := rootCAs(target)
+	if err != nil {
+		return nil, err
+	}
+	dialOpts := DialOptions(target)
+	if roots == nil && target.ServerName == "" && dialOpts.IsZero() && target.Protocol == "" {
+		return client, nil
+	}
+
+	tlsConfig := &tls.Config{
+		RootCAs:    roots,
+		ServerName: target.ServerName,
+	}
+
+	switch target.Protocol {
+	case config.ProtocolH2C:
+		// HTTP/2 with prior knowledge over a plain connection
+		client.Transport = &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, address string, _ *tls.Config) (net.Conn, error) {
+				return dialOpts.DialContext(ctx, network, address)
+			},
+		}
+		return client, nil
+	case config.ProtocolH3:
+		// QUIC runs over UDP, so only resolve overrides apply. Dialing each connection
+		// separately closes its UDP socket along with the connection.
+		client.Transport = &http3.Transport{
+			TLSClientConfig: tlsConfig,
+			Dial: func(ctx context.Context, address string, tlsConf *tls.Config, quicConf *quic.Config) (quic.EarlyConnection, error) {
+				return quic.DialAddrEarly(ctx, dialOpts.Override(address), tlsConf, quicConf)
+			},
+		}
+		return client, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableKeepAlives = true
+	transport.TLSClientConfig = tlsConfig
+	if target.Protocol == config.ProtocolH1 {
+		// A non-nil empty map keeps the transport from negotiating HTTP/2
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	if !dialOpts.IsZero() {
+		if err := dialOpts.ConfigureTransport(transport); err != nil {
+			return nil, err
+		}
+	}
+	client.Transport = transport
+
+	return client, nil
+}
+
+// RevokedValue returns the ssl.revoked gauge value for a certificate and whether
+// its revocation status is known at all
+func RevokedValue(certDetails *certcheck.CertificateDetails) (float64, bool) {
+	switch certDetails.Revocation.Status {
+	case certcheck.RevocationRevoked:
+		return 1.0, true
+	case certcheck.RevocationGood:
+		return 0.0, true
+	default:
+		return 0.0, false
+	}
 }
 
 // MetricsClient represents the interface for sending metrics
@@ -45,42 +229,350 @@ func NopLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(io.Discard, nil))
 }
 
+// Result is the outcome of a single check of a target
+type Result struct {
+	Up       bool
+	Status   int
+	Duration time.Duration
+	Err      error
+	// TLS is the state of the connection that served the check. It is nil for plain
+	// HTTP and when the request was redirected to another host, whose certificate
+	// isn't the one of the target.
+	TLS *tls.ConnectionState
+	// ResolvedIP is the IP address the check connected to, empty when no connection was made
+	ResolvedIP string
+	// ProxyFailed is set when the check failed connecting to the proxy rather than to the target
+	ProxyFailed bool
+	// Hops are the redirect responses followed, in order
+	Hops []Hop
+	// FinalURL is the URL of the response the check evaluated, after redirects
+	FinalURL string
+	// Protocol is the HTTP protocol of the response: h1, h2 or h3
+	Protocol string
+	// HeaderFailures describes the header assertions the response failed
+	HeaderFailures []string
+	// SecurityChecks are the results of the security header audit, when enabled
+	SecurityChecks []SecurityCheck
+	// ResponseBytes is the size of the decoded response body
+	ResponseBytes int64
+	// ContentHash is the hash of the normalized body, only set when detecting changes
+	ContentHash string
+}
+
+// Tags returns the metric tags of a check result
+func Tags(target config.Target, result Result) []string {
+	tags := []string{"url:" + target.URL, "name:" + target.Name}
+	for k, v := range target.Labels {
+		tags = append(tags, k+":"+v)
+	}
+	// The resolved IP is only tagged when resolution is overridden, to keep
+	// round-robin DNS from multiplying the series of every target
+	if result.ResolvedIP != "" && (len(target.Resolve) > 0 || target.DNSServer != "") {
+		tags = append(tags, "resolved_ip:"+result.ResolvedIP)
+	}
+	if target.Protocol != "" && result.Protocol != "" {
+		tags = append(tags, "protocol:"+result.Protocol)
+	}
+	switch target.IPFamily {
+	case dialer.IPFamilyIPv4:
+		tags = append(tags, "ip_family:v4")
+	case dialer.IPFamilyIPv6:
+		tags = append(tags, "ip_family:v6")
+	}
+	return tags
+}
+
 // CheckTarget performs an HTTP request to the target and returns true if the response status is 2xx (OK).
+// Targets using non-HTTP schemes such as smtp:// or tls:// are up when the TLS handshake succeeds.
 func CheckTarget(client *http.Client, target config.Target) (bool, int, time.Duration, error) {
+	result := Probe(client, target)
+	return result.Up, result.Status, result.Duration, result.Err
+}
+
+// Probe checks the target like CheckTarget and also returns the TLS state of the
+// connection, so the certificate can be inspected without connecting again
+func Probe(client *http.Client, target config.Target) Result {
+	var resolvedIP string
+
+	if !IsHTTPTarget(target) {
+		dial := DialOptions(target)
+		dial.Timeout = client.Timeout
+		opts := certcheck.Options{
+			ServerName: target.ServerName,
+			Timeout:    client.Timeout,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				conn, err := dial.DialContext(ctx, network, address)
+				if err == nil {
+					resolvedIP = dialer.RemoteIP(conn)
+				}
+				return conn, err
+			},
+		}
+
+		start := time.Now()
+		state, err := certcheck.Handshake(target.URL, opts)
+		duration := time.Since(start)
+		if err != nil {
+			return Result{Duration: duration, Err: err, ResolvedIP: resolvedIP, ProxyFailed: dialer.IsProxyError(err)}
+		}
+		return Result{Up: true, Duration: duration, TLS: state, ResolvedIP: resolvedIP}
+	}
+
 	req, err := http.NewRequest(target.Method, target.URL, nil)
 	if err != nil {
-		return false, 0, 0, err
+		return Result{Err: err}
 	}
+	if target.Protocol == config.ProtocolH2C || target.Protocol == config.ProtocolH3 {
+		// These transports keep connections open, which would outlive the check
+		defer client.CloseIdleConnections()
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			resolvedIP = dialer.RemoteIP(info.Conn)
+		},
+	}))
 
 	for key, value := range target.Headers {
 		req.Header.Set(key, value)
 	}
 	
+	// Redirects are followed up to the target's limit, after which the last redirect
+	// response is evaluated like any other response
+	var hops []Hop
+	maxRedirects := MaxRedirects(target)
+	redirectClient := *client
+	redirectClient.CheckRedirect = func(next *http.Request, via []*http.Request) error {
+		if len(via) > maxRedirects {
+			return http.ErrUseLastResponse
+		}
+		hops = append(hops, Hop{
+			URL:    next.Response.Request.URL.String(),
+			Status: next.Response.StatusCode,
+			TLS:    next.Response.TLS,
+		})
+		return nil
+	}
+
 	start := time.Now()
-	resp, err := client.Do(req)
+	resp, err := redirectClient.Do(req)
 	duration := time.Since(start)
 	
 	if err != nil {
-		return false, 0, duration, err
+		return Result{Duration: duration, Err: err, ResolvedIP: resolvedIP, ProxyFailed: dialer.IsProxyError(err), Hops: hops}
 	}
 	defer resp.Body.Close()
 	
-	_, _ = io.Copy(io.Discard, resp.Body)
+	responseBytes, contentHash := readBody(target, resp.Body)
 	
-	status := resp.StatusCode
-	if status >= HealthyStatusMin && status < HealthyStatusMax {
-		return true, status, duration, nil
+	result := Result{
+		Up:         IsHealthyStatus(target, resp.StatusCode),
+		Status:     resp.StatusCode,
+		Duration:   duration,
+		ResolvedIP: resolvedIP,
+		Hops:       hops,
+		FinalURL:   resp.Request.URL.String(),
+		Protocol:   fmt.Sprintf("h%d", resp.ProtoMajor),
+
+		ResponseBytes: responseBytes,
+		ContentHash:   contentHash,
+	}
+	result.HeaderFailures = CheckHeaderAssertions(target.HeaderAssertions, resp.Header)
+	if !FinalURLMatches(target, result) || !ProtocolMatches(target, result) || len(result.HeaderFailures) > 0 {
+		result.Up = false
+	}
+	if AuditsSecurityHeaders(target) {
+		result.SecurityChecks = AuditSecurityHeaders(resp)
+	}
+	if resp.TLS != nil && resp.Request.URL.Host == req.URL.Host {
+		result.TLS = resp.TLS
+	}
+	return result
+}
+
+// ProtocolMatches reports whether a check negotiated the protocol the target forces.
+// h2c is negotiated as h2. It is true for targets without a forced protocol.
+func ProtocolMatches(target config.Target, result Result) bool {
+	switch target.Protocol {
+	case "":
+		return true
+	case config.ProtocolH2C:
+		return result.Protocol == config.ProtocolH2
+	default:
+		return result.Protocol == target.Protocol
+	}
+}
+
+// MaxRedirects returns the number of redirects followed for the target
+func MaxRedirects(target config.Target) int {
+	if target.FollowRedirects == nil {
+		return config.DefaultMaxRedirects
+	}
+	return int(*target.FollowRedirects)
+}
+
+// FinalURLMatches reports whether the redirects of a check ended at the expected final URL.
+// It is true for targets without an expected final URL.
+func FinalURLMatches(target config.Target, result Result) bool {
+	return target.ExpectedFinalURL == "" || result.FinalURL == target.ExpectedFinalURL
+}
+
+// RedirectCertificate is the certificate of a host a target redirected through
+type RedirectCertificate struct {
+	// URL is the first URL requested from the host
+	URL     string
+	Host    string
+	Details *certcheck.CertificateDetails
+	Err     error
+}
+
+// CheckRedirectCertificates checks the certificate of every HTTPS host a check was
+// redirected through or to, except the host of the target whose certificate is checked
+// anyway. Pins, expected names and the TLS policy of the target don't apply to them.
+func CheckRedirectCertificates(target config.Target, result Result) ([]RedirectCertificate, error) {
+	if len(result.Hops) == 0 {
+		return nil, nil
+	}
+
+	opts, err := CertificateOptions(target)
+	if err != nil {
+		return nil, err
+	}
+	opts.Pins = nil
+	opts.ServerName = ""
+	opts.ExpectedDNSNames = nil
+	opts.TLSPolicy = nil
+
+	seen := map[string]bool{hostOf(target.URL): true}
+	var certs []RedirectCertificate
+	check := func(rawURL string, state *tls.ConnectionState) {
+		host := hostOf(rawURL)
+		if seen[host] || !strings.HasPrefix(rawURL, certcheck.SchemeHTTPS+"://") {
+			return
+		}
+		seen[host] = true
+
+		details, err := certcheck.CheckConnectionState(rawURL, state, opts)
+		certs = append(certs, RedirectCertificate{URL: rawURL, Host: host, Details: details, Err: err})
+	}
+
+	for _, hop := range result.Hops {
+		check(hop.URL, hop.TLS)
+	}
+	if result.FinalURL != "" {
+		// The final response's connection isn't kept, so its certificate is fetched again
+		check(result.FinalURL, nil)
+	}
+	return certs, nil
+}
+
+// hostOf returns the lower-cased host and port of a URL
+func hostOf(rawURL string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsedURL.Host)
+}
+
+// Hop is a redirect response received while checking a target
+type Hop struct {
+	URL    string
+	Status int
+	// TLS is the state of the connection that served the redirect, nil for plain HTTP
+	TLS *tls.ConnectionState
+}
+
+// IsHealthyStatus reports whether a response status code counts as up for the target.
+// Without explicitly expected status codes any 2xx status is healthy.
+func IsHealthyStatus(target config.Target, status int) bool {
+	if len(target.ExpectedStatus) == 0 {
+		return status >= HealthyStatusMin && status < HealthyStatusMax
+	}
+	for _, expected := range target.ExpectedStatus {
+		if status == expected {
+			return true
+		}
+	}
+	return false
+}
+
+// Runner checks targets and keeps the state carried over between checks of the same target
+type Runner struct {
+	Metrics MetricsClient
+	Logger  *slog.Logger
+	// Statuses records the result of every check when set
+	Statuses *StatusTracker
+	// Notifier is told about every check to send the state transitions of targets when set
+	Notifier *notifier.Dispatcher
+
+	// serials remembers the last seen certificate serial number per target name, or the
+	// name a target is checked under
+	serials *ChangeTracker
+	// contentHashes remembers the last seen content hash per target name
+	contentHashes *ChangeTracker
+	// slos counts good and bad checks per target name for their SLOs
+	slos *slo.Tracker
+}
+
+// NewRunner creates a new Runner reporting to the metrics client
+func NewRunner(metrics MetricsClient, logger *slog.Logger) *Runner {
+	return &Runner{
+		Metrics:       metrics,
+		Logger:        logger,
+		serials:       NewChangeTracker(),
+		contentHashes: NewChangeTracker(),
+		slos:          slo.NewTracker(),
 	}
-	return false, status, duration, nil
 }
 
 // Target checks a single target and reports its status to the metrics client.
+// It keeps no state between calls, use a Runner to detect certificate rotations.
 func Target(client *http.Client, target config.Target, metrics MetricsClient, logger *slog.Logger) {
+	NewRunner(metrics, logger).Check(client, target)
+}
+
+// Report is what a Runner found checking a target, for callers that also report checks
+// elsewhere, like on the status of a URLMonitor
+type Report struct {
+	// CheckedAt is when the probes finished
+	CheckedAt time.Time
+	// Result is the probe of the first IP family, or of the first one that completed a TLS
+	// handshake. The certificate, content and redirects are checked on it.
+	Result Result
+	// Worst is the probe of the IP family in the worst health
+	Worst Result
+	// Health is the health of Worst
+	Health Health
+	// Certificate is the checked certificate, nil when it wasn't checked or couldn't be
+	Certificate *certcheck.CertificateDetails
+	// PreviousSerial is the serial number of the certificate before it rotated, empty
+	// unless it rotated since the last check
+	PreviousSerial string
+	// PreviousContentHash is the hash of the content before it changed, empty unless it
+	// changed since the last check
+	PreviousContentHash string
+	// SLO is the state of the SLO after counting the check, nil for targets without one
+	SLO          *slo.Report
+	SLOObjective slo.Objective
+	// RedirectCertificates are the certificates of the hosts the check redirected through
+	RedirectCertificates []RedirectCertificate
+}
+
+// Check checks a single target and reports its status to the metrics client.
+func (r *Runner) Check(client *http.Client, target config.Target) Report {
+	return r.CheckNamed(target.Name, client, target)
+}
+
+// CheckNamed checks a target like Check, keeping its state, status and notifications under
+// name rather than the target name, for callers whose target names aren't unique
+func (r *Runner) CheckNamed(name string, client *http.Client, target config.Target) Report {
+	metrics, logger := r.Metrics, r.Logger
+
 	// Validate target before proceeding
 	if target.URL == "" {
 		logger.Error("Invalid target: URL is empty", 
 			slog.String("target", target.Name))
-		return
+		return Report{}
 	}
 
 	// Validate method
@@ -91,67 +583,58 @@ func Target(client *http.Client, target config.Target, metrics MetricsClient, lo
 		target.Method = "GET"
 	}
 
-	up, status, duration, err := CheckTarget(client, target)
-	ms := float64(duration.Milliseconds())
-	
-	tags := []string{"url:" + target.URL, "name:" + target.Name}
-	for k, v := range target.Labels {
-		tags = append(tags, k+":"+v)
-	}
+	// Targets checked over both IP families report availability per family, the
+	// certificate is checked once on the first connection that completed a handshake
+	var result, worstResult Result
+	var haveWorst, haveResult bool
+	worst := HealthUp
+	for _, variant := range IPFamilies(target) {
+		variantClient := client
+		if variant.IPFamily != target.IPFamily {
+			var err error
+			if variantClient, err = NewClient(variant); err != nil {
+				logger.Error("Failed to create HTTP client",
+					slog.String("target", target.Name),
+					slog.String("url", target.URL),
+					slog.Any("error", err))
+				worst, worstResult, haveWorst = HealthDown, Result{Err: err}, true
+				continue
+			}
+		}
 
-	val := 0.0
-	if up {
-		val = 1.0
-	}
-	
-	if metrics != nil {
-		if err := metrics.Gauge(MetricURLUp, val, tags); err != nil {
-			logger.Warn("Failed to send url.up metric", 
-				slog.String("target", target.Name), 
-				slog.String("url", target.URL),
-				slog.Any("error", err))
-		} else {
-			logger.Info("Successfully sent url.up metric", 
-				slog.String("target", target.Name), 
-				slog.String("url", target.URL),
-				slog.Float64("value", val))
+		// A variant skipped above doesn't count as the first probe
+		variantResult := r.probe(variantClient, variant)
+		if health := CheckHealth(variant, variantResult); !haveWorst || health < worst {
+			worst, worstResult, haveWorst = health, variantResult, true
 		}
-		
-		if err := metrics.Histogram(MetricResponseTime, ms, tags); err != nil {
-			logger.Warn("Failed to send url.response_time_ms metric", 
-				slog.String("target", target.Name),
-				slog.String("url", target.URL),
-				slog.Any("error", err))
-		} else {
-			logger.Info("Successfully sent url.response_time_ms metric",
-				slog.String("target", target.Name),
-				slog.String("url", target.URL),
-				slog.Float64("value", ms))
+		if !haveResult || (result.TLS == nil && variantResult.TLS != nil) {
+			result, haveResult = variantResult, true
 		}
 	}
+	tags := Tags(target, result)
 
-	logAttrs := []any{
-		slog.String("target", target.Name),
-		slog.String("url", target.URL),
-		slog.Float64("response_time_ms", ms),
+	report := Report{CheckedAt: time.Now(), Result: result, Worst: worstResult, Health: worst}
+	named := target
+	named.Name = name
+	if r.Statuses != nil {
+		r.Statuses.Record(named, report.CheckedAt, worstResult, worst)
 	}
-	
-	for k, v := range target.Labels {
-		logAttrs = append(logAttrs, slog.String("label_"+k, v))
+	if r.Notifier != nil {
+		r.Notifier.ObserveCheck(name, target, NotifierCheck(target, report.CheckedAt, worstResult, worst))
 	}
-	
-	if err != nil {
-		logAttrs = append(logAttrs, slog.Any("error", err))
-		logger.Error("Target check failed", logAttrs...)
-	} else {
-		logAttrs = append(logAttrs, slog.Int("status", status))
-		if !up {
-			logger.Warn("Target is unhealthy", logAttrs...)
-		} else {
-			logger.Info("Target is healthy", logAttrs...)
-		}
+
+	if target.SLO != nil {
+		report.SLOObjective, report.SLO = r.recordSLO(name, target, worst >= HealthDegraded, tags)
 	}
-	
+
+	if result.ContentHash != "" {
+		report.PreviousContentHash = r.observeContent(name, target, result, tags)
+	}
+
+	if target.CheckRedirectCerts {
+		report.RedirectCertificates = r.checkRedirectCertificates(target, result, tags)
+	}
+
 	if ShouldCheckCertificate(target) {
 		// Use a separate try-catch block to prevent crashes during certificate checking
 		func() {
@@ -164,7 +647,16 @@ func Target(client *http.Client, target config.Target, metrics MetricsClient, lo
 				}
 			}()
 			
-			certDetails, certErr := certcheck.CheckCertificate(target.URL, *target.VerifyCert)
+			opts, optsErr := CertificateOptions(target)
+			if optsErr != nil {
+				logger.Error("Failed to check certificate",
+					slog.String("target", target.Name),
+					slog.String("url", target.URL),
+					slog.Any("error", optsErr))
+				return
+			}
+
+			certDetails, certErr := certcheck.CheckConnectionState(target.URL, result.TLS, opts)
 			
 			if certErr != nil && certDetails == nil {
 				logger.Error("Failed to check certificate",
@@ -173,6 +665,14 @@ func Target(client *http.Client, target config.Target, metrics MetricsClient, lo
 					slog.Any("error", certErr))
 			} else if certDetails != nil {
 				certcheck.LogCertificateInfo(logger, target.URL, certDetails)
+				report.Certificate = certDetails
+				report.PreviousSerial = r.observeSerial(name, target, certDetails, tags)
+				if r.Statuses != nil {
+					r.Statuses.ObserveCertificate(named, time.Now(), certDetails)
+				}
+				if r.Notifier != nil {
+					r.Notifier.ObserveCertificate(name, target, NotifierCertificate(time.Now(), certDetails))
+				}
 				
 				daysUntilExpiry := time.Until(certDetails.NotAfter).Hours() / 24
 				
@@ -195,48 +695,330 @@ func Target(client *http.Client, target config.Target, metrics MetricsClient, lo
 							slog.String("url", target.URL),
 							slog.Any("error", err))
 					}
+
+					chainDaysUntilExpiry := time.Until(certDetails.ChainNotAfter()).Hours() / 24
+					if err := metrics.Gauge(MetricSSLChainDaysToExpiry, chainDaysUntilExpiry, tags); err != nil {
+						logger.Warn("Failed to send ssl.chain_min_days_until_expiry metric",
+							slog.String("target", target.Name),
+							slog.String("url", target.URL),
+							slog.Any("error", err))
+					}
+
+					weakVal := 0.0
+					if certDetails.IsWeak() {
+						weakVal = 1.0
+					}
+
+					if err := metrics.Gauge(MetricSSLWeak, weakVal, tags); err != nil {
+						logger.Warn("Failed to send ssl.weak metric",
+							slog.String("target", target.Name),
+							slog.String("url", target.URL),
+							slog.Any("error", err))
+					}
+
+					stapledVal := 0.0
+					if certDetails.OCSPStapled {
+						stapledVal = 1.0
+					}
+
+					if err := metrics.Gauge(MetricSSLOCSPStapled, stapledVal, tags); err != nil {
+						logger.Warn("Failed to send ssl.ocsp_stapled metric",
+							slog.String("target", target.Name),
+							slog.String("url", target.URL),
+							slog.Any("error", err))
+					}
+
+					if revokedVal, known := RevokedValue(certDetails); known {
+						if err := metrics.Gauge(MetricSSLRevoked, revokedVal, tags); err != nil {
+							logger.Warn("Failed to send ssl.revoked metric",
+								slog.String("target", target.Name),
+								slog.String("url", target.URL),
+								slog.Any("error", err))
+						}
+					}
+
+					if len(opts.Pins) > 0 {
+						pinVal := 0.0
+						if certDetails.PinMatch {
+							pinVal = 1.0
+						}
+
+						if err := metrics.Gauge(MetricSSLPinMatch, pinVal, tags); err != nil {
+							logger.Warn("Failed to send ssl.pin_match metric",
+								slog.String("target", target.Name),
+								slog.String("url", target.URL),
+								slog.Any("error", err))
+						}
+					}
+
+					if opts.TLSPolicy != nil {
+						compliantVal := 0.0
+						if len(certDetails.PolicyViolations) == 0 {
+							compliantVal = 1.0
+						}
+
+						if err := metrics.Gauge(MetricSSLPolicyCompliant, compliantVal, tags); err != nil {
+							logger.Warn("Failed to send ssl.policy_compliant metric",
+								slog.String("target", target.Name),
+								slog.String("url", target.URL),
+								slog.Any("error", err))
+						}
+					}
 				}
 			}
 		}()
 	}
+
+	return report
 }
 
-// Targets starts monitoring all targets with their individual intervals.
-// The function will run until the context is canceled.
-func Targets(ctx context.Context, cfg *config.Config, metrics MetricsClient) {
-	logger := NewJSONLogger()
+// probe checks the target over a single IP family and reports its availability
+func (r *Runner) probe(client *http.Client, target config.Target) Result {
+	metrics, logger := r.Metrics, r.Logger
+
+	result := Probe(client, target)
+	up, status, duration, err := result.Up, result.Status, result.Duration, result.Err
+	ms := float64(duration.Milliseconds())
 	
-	logger.Info("Starting target monitoring",
-		slog.Int("target_count", len(cfg.Targets)))
+	tags := Tags(target, result)
+
+	val := 0.0
+	if up {
+		val = 1.0
+	}
 	
-	nextChecks := make(map[string]time.Time)
-	for _, target := range cfg.Targets {
-		nextChecks[target.Name] = time.Now()
+	if metrics != nil {
+		if IsHTTPTarget(target) && (err == nil || len(result.Hops) > 0) {
+			if err := metrics.Gauge(MetricRedirects, float64(len(result.Hops)), tags); err != nil {
+				logger.Warn("Failed to send url.redirects metric",
+					slog.String("target", target.Name),
+					slog.String("url", target.URL),
+					slog.Any("error", err))
+			}
+		}
+		if IsHTTPTarget(target) && err == nil {
+			if err := metrics.Gauge(MetricResponseBytes, float64(result.ResponseBytes), tags); err != nil {
+				logger.Warn("Failed to send url.response_bytes metric",
+					slog.String("target", target.Name),
+					slog.String("url", target.URL),
+					slog.Any("error", err))
+			}
+		}
+
+		r.reportHealth(target, result, tags)
+
+		if err := metrics.Gauge(MetricURLUp, val, tags); err != nil {
+			logger.Warn("Failed to send url.up metric", 
+				slog.String("target", target.Name), 
+				slog.String("url", target.URL),
+				slog.Any("error", err))
+		} else {
+			logger.Info("Successfully sent url.up metric", 
+				slog.String("target", target.Name), 
+				slog.String("url", target.URL),
+				slog.Float64("value", val))
+		}
+		
+		if err := metrics.Histogram(MetricResponseTime, ms, tags); err != nil {
+			logger.Warn("Failed to send url.response_time_ms metric", 
+				slog.String("target", target.Name),
+				slog.String("url", target.URL),
+				slog.Any("error", err))
+		} else {
+			logger.Info("Successfully sent url.response_time_ms metric",
+				slog.String("target", target.Name),
+				slog.String("url", target.URL),
+				slog.Float64("value", ms))
+		}
+
+		if len(result.SecurityChecks) > 0 {
+			r.reportSecurityChecks(target, result, tags)
+		}
+
+		if UsesProxy(target) {
+			proxyVal := 0.0
+			if result.ProxyFailed {
+				proxyVal = 1.0
+			}
+			if err := metrics.Gauge(MetricProxyError, proxyVal, tags); err != nil {
+				logger.Warn("Failed to send url.proxy_error metric",
+					slog.String("target", target.Name),
+					slog.String("url", target.URL),
+					slog.Any("error", err))
+			}
+		}
+	}
+
+	logAttrs := []any{
+		slog.String("target", target.Name),
+		slog.String("url", target.URL),
+		slog.Float64("response_time_ms", ms),
+	}
+	if result.ResolvedIP != "" {
+		logAttrs = append(logAttrs, slog.String("resolved_ip", result.ResolvedIP))
+	}
+	if len(result.Hops) > 0 {
+		logAttrs = append(logAttrs,
+			slog.Int("redirects", len(result.Hops)),
+			slog.String("final_url", result.FinalURL))
 	}
 	
-	ticker := time.NewTicker(TickInterval)
-	defer ticker.Stop()
+	for k, v := range target.Labels {
+		logAttrs = append(logAttrs, slog.String("label_"+k, v))
+	}
 	
-	for {
-		select {
-		case <-ctx.Done():
-			logger.Info("Stopping target monitoring due to context cancellation")
-			return
-			
-		case now := <-ticker.C:
-			for _, target := range cfg.Targets {
-				nextCheck, ok := nextChecks[target.Name]
-				if !ok || now.After(nextCheck) {
-					client := &http.Client{
-						Timeout: time.Duration(target.Timeout) * time.Second,
-					}
-					
-					Target(client, target, metrics, logger)
-					
-					interval := time.Duration(target.Interval) * time.Second
-					nextChecks[target.Name] = now.Add(interval)
-				}
-			}
+	if err != nil && result.ProxyFailed {
+		logAttrs = append(logAttrs, slog.Any("error", err))
+		logger.Error("Proxy connection failed", logAttrs...)
+	} else if err != nil {
+		logAttrs = append(logAttrs, slog.Any("error", err))
+		logger.Error("Target check failed", logAttrs...)
+	} else {
+		logAttrs = append(logAttrs, slog.Int("status", status), slog.Int64("response_bytes", result.ResponseBytes))
+		if result.ContentHash != "" {
+			logAttrs = append(logAttrs, slog.String("content_hash", result.ContentHash))
+		}
+		if !FinalURLMatches(target, result) {
+			logAttrs = append(logAttrs, slog.String("expected_final_url", target.ExpectedFinalURL))
+			logger.Warn("Target redirected to an unexpected URL", logAttrs...)
+		} else if !ProtocolMatches(target, result) {
+			logAttrs = append(logAttrs,
+				slog.String("protocol", result.Protocol),
+				slog.String("expected_protocol", target.Protocol))
+			logger.Warn("Target negotiated an unexpected protocol", logAttrs...)
+		} else if len(result.HeaderFailures) > 0 {
+			logAttrs = append(logAttrs, slog.Any("header_failures", result.HeaderFailures))
+			logger.Warn("Target failed header assertions", logAttrs...)
+		} else if !up {
+			logger.Warn("Target is unhealthy", logAttrs...)
+		} else if health := CheckHealth(target, result); health != HealthUp {
+			logAttrs = append(logAttrs, slog.String("health", health.String()))
+			logger.Warn("Target responded slowly", logAttrs...)
+		} else {
+			logger.Info("Target is healthy", logAttrs...)
 		}
 	}
-}
\ No newline at end of file
+
+	return result
+}
+
+// reportSecurityChecks sends the security header score and a failure gauge per audited header
+func (r *Runner) reportSecurityChecks(target config.Target, result Result, tags []string) {
+	if err := r.Metrics.Gauge(MetricSecurityHeadersScore, SecurityScore(result.SecurityChecks), tags); err != nil {
+		r.Logger.Warn("Failed to send url.security_headers_score metric",
+			slog.String("target", target.Name),
+			slog.String("url", target.URL),
+			slog.Any("error", err))
+	}
+
+	for _, check := range result.SecurityChecks {
+		failed := 0.0
+		if !check.Passed {
+			failed = 1.0
+		}
+		checkTags := append(append([]string{}, tags...), "security_header:"+check.Header)
+		if err := r.Metrics.Gauge(MetricSecurityHeaderFailed, failed, checkTags); err != nil {
+			r.Logger.Warn("Failed to send url.security_header_failed metric",
+				slog.String("target", target.Name),
+				slog.String("url", target.URL),
+				slog.Any("error", err))
+		}
+	}
+
+	if failures := SecurityFailures(result.SecurityChecks); len(failures) > 0 {
+		r.Logger.Warn("Target failed the security header audit",
+			slog.String("target", target.Name),
+			slog.String("url", target.URL),
+			slog.Float64("score", SecurityScore(result.SecurityChecks)),
+			slog.Any("failures", failures))
+	}
+}
+
+// checkRedirectCertificates reports and returns the certificates of the hosts the target
+// redirected through
+func (r *Runner) checkRedirectCertificates(target config.Target, result Result, tags []string) []RedirectCertificate {
+	certs, err := CheckRedirectCertificates(target, result)
+	if err != nil {
+		r.Logger.Error("Failed to check redirect certificates",
+			slog.String("target", target.Name),
+			slog.String("url", target.URL),
+			slog.Any("error", err))
+		return nil
+	}
+
+	for _, cert := range certs {
+		if cert.Details == nil {
+			r.Logger.Error("Failed to check redirect certificate",
+				slog.String("target", target.Name),
+				slog.String("redirect_url", cert.URL),
+				slog.Any("error", cert.Err))
+			continue
+		}
+
+		hopTags := append(append([]string{}, tags...), "redirect_host:"+cert.Host)
+		validVal := 0.0
+		if cert.Details.IsValid {
+			validVal = 1.0
+		} else {
+			r.Logger.Warn("Redirect certificate is invalid",
+				slog.String("target", target.Name),
+				slog.String("redirect_url", cert.URL),
+				slog.Any("error", cert.Err))
+		}
+		if r.Metrics == nil {
+			continue
+		}
+		if err := r.Metrics.Gauge(MetricSSLRedirectValid, validVal, hopTags); err != nil {
+			r.Logger.Warn("Failed to send ssl.redirect_valid metric",
+				slog.String("target", target.Name),
+				slog.String("url", target.URL),
+				slog.Any("error", err))
+		}
+		daysUntilExpiry := time.Until(cert.Details.NotAfter).Hours() / 24
+		if err := r.Metrics.Gauge(MetricSSLRedirectDaysToExpiry, daysUntilExpiry, hopTags); err != nil {
+			r.Logger.Warn("Failed to send ssl.redirect_days_until_expiry metric",
+				slog.String("target", target.Name),
+				slog.String("url", target.URL),
+				slog.Any("error", err))
+		}
+	}
+	return certs
+}
+
+// observeSerial records the leaf serial number of a target and reports certificate rotations.
+// It returns the previous serial number when the certificate rotated.
+func (r *Runner) observeSerial(name string, target config.Target, certDetails *certcheck.CertificateDetails, tags []string) string {
+	previous, rotated := r.serials.Observe(name, certDetails.SerialNumber)
+	if !rotated {
+		return ""
+	}
+
+	r.Logger.Info("Certificate rotated",
+		slog.String("target", target.Name),
+		slog.String("url", target.URL),
+		slog.String("previous_serial", previous),
+		slog.String("serial", certDetails.SerialNumber),
+		slog.Time("expires", certDetails.NotAfter))
+
+	events, ok := r.Metrics.(exporter.EventExporter)
+	if !ok {
+		return previous
+	}
+
+	title := "Certificate rotated for " + target.Name
+	text := fmt.Sprintf("The certificate served by %s changed from serial %s to %s, now valid until %s",
+		target.URL, previous, certDetails.SerialNumber, certDetails.NotAfter.Format(time.RFC3339))
+	if err := events.Event(title, text, exporter.AlertTypeInfo, tags); err != nil {
+		r.Logger.Warn("Failed to send certificate rotation event",
+			slog.String("target", target.Name),
+			slog.String("url", target.URL),
+			slog.Any("error", err))
+	}
+	return previous
+}
+
+// Targets starts monitoring all targets with their individual intervals.
+// The function will run until the context is canceled.
+func Targets(ctx context.Context, cfg *config.Config, metrics MetricsClient) {
+	NewScheduler(cfg, NewRunner(metrics, NewJSONLogger())).Run(ctx)
+}
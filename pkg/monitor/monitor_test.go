@@ -1,12 +1,23 @@
 package monitor
 
 import (
+	"crypto/tls"
+	"encoding/pem"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/kuskoman/url-datadog-monitor/pkg/certcheck"
 	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+	"github.com/kuskoman/url-datadog-monitor/pkg/dialer"
+	"github.com/quic-go/quic-go/http3"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 type mockDatadog struct {
@@ -127,8 +138,9 @@ func TestMonitorTarget(t *testing.T) {
 
 	Target(client, target, mock, logger)
 
-	if mock.gaugesCalled != 1 {
-		t.Errorf("Expected 1 gauge call, got %d", mock.gaugesCalled)
+	// url.redirects, url.response_bytes, url.status and url.up
+	if mock.gaugesCalled != 4 {
+		t.Errorf("Expected 4 gauge calls, got %d", mock.gaugesCalled)
 	}
 	if mock.lastGaugeName != "url.up" {
 		t.Errorf("Expected gauge name 'url.up', got '%s'", mock.lastGaugeName)
@@ -161,3 +173,470 @@ func TestMonitorTarget(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckTarget_ExpectedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	target := config.Target{
+		Name:           "Protected Target",
+		URL:            server.URL,
+		Method:         "GET",
+		ExpectedStatus: []int{http.StatusUnauthorized},
+	}
+
+	client := &http.Client{Timeout: 1 * time.Second}
+
+	up, status, _, err := CheckTarget(client, target)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !up {
+		t.Errorf("Expected up to be true for expected status %d", status)
+	}
+
+	target.ExpectedStatus = []int{http.StatusOK}
+	up, _, _, _ = CheckTarget(client, target)
+	if up {
+		t.Errorf("Expected up to be false for unexpected status")
+	}
+}
+
+type mockEventDatadog struct {
+	mockDatadog
+	eventTitles []string
+}
+
+func (m *mockEventDatadog) Event(title, text, alertType string, tags []string) error {
+	m.eventTitles = append(m.eventTitles, title)
+	return nil
+}
+
+func TestRunner_CertificateRotation(t *testing.T) {
+	mock := &mockEventDatadog{}
+	runner := NewRunner(mock, NopLogger())
+	target := config.Target{Name: "Rotating", URL: "https://rotating.example.com"}
+
+	for _, serial := range []string{"1", "1", "2"} {
+		runner.observeSerial(target.Name, target, &certcheck.CertificateDetails{SerialNumber: serial, NotAfter: time.Now()}, nil)
+	}
+
+	if len(mock.eventTitles) != 1 || mock.eventTitles[0] != "Certificate rotated for Rotating" {
+		t.Errorf("Expected a single rotation event, got %v", mock.eventTitles)
+	}
+}
+
+func TestCheckTarget_TLSEndpoint(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	checkCert := true
+	target := config.Target{
+		Name:      "tls",
+		URL:       strings.Replace(server.URL, "https://", "tls://", 1),
+		CheckCert: &checkCert,
+	}
+
+	if IsHTTPTarget(target) {
+		t.Errorf("Expected %s not to be checked over HTTP", target.URL)
+	}
+	if !ShouldCheckCertificate(target) {
+		t.Errorf("Expected the certificate of %s to be checked", target.URL)
+	}
+
+	up, status, _, err := CheckTarget(&http.Client{Timeout: 5 * time.Second}, target)
+	if err != nil || !up {
+		t.Errorf("Expected the TLS endpoint to be up, got up=%v err=%v", up, err)
+	}
+	if status != 0 {
+		t.Errorf("Expected no status code for a TLS endpoint, got %d", status)
+	}
+
+	server.Close()
+	up, _, _, err = CheckTarget(&http.Client{Timeout: 5 * time.Second}, target)
+	if err == nil || up {
+		t.Errorf("Expected a closed TLS endpoint to be down")
+	}
+}
+
+func TestProbe_TLSState(t *testing.T) {
+	other := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer other.Close()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, other.URL, http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// Both test servers share the httptest certificate, so one client trusts both
+	client := server.Client()
+
+	result := Probe(client, config.Target{Name: "direct", URL: server.URL, Method: "GET"})
+	if !result.Up || result.TLS == nil {
+		t.Fatalf("Expected an up result with the TLS state, got %+v", result)
+	}
+	if len(result.TLS.PeerCertificates) == 0 {
+		t.Errorf("Expected the served certificate in the TLS state")
+	}
+
+	result = Probe(client, config.Target{Name: "redirected", URL: server.URL + "/redirect", Method: "GET"})
+	if !result.Up {
+		t.Fatalf("Expected the redirected request to be up, got %+v", result)
+	}
+	if result.TLS != nil {
+		t.Errorf("Expected no TLS state after a redirect to another host")
+	}
+
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer plain.Close()
+
+	if result := Probe(client, config.Target{Name: "plain", URL: plain.URL, Method: "GET"}); result.TLS != nil {
+		t.Errorf("Expected no TLS state for plain HTTP")
+	}
+}
+
+func TestNewClient_CAFileAndServerName(t *testing.T) {
+	var serverNames []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serverNames = append(serverNames, r.TLS.ServerName)
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, pemData, 0o600); err != nil {
+		t.Fatalf("Failed to write CA file: %v", err)
+	}
+
+	target := config.Target{Name: "internal", URL: server.URL, Method: "GET", Timeout: 5}
+	client, err := NewClient(target)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if result := Probe(client, target); result.Err == nil {
+		t.Errorf("Expected the test certificate to be untrusted without a CA file")
+	}
+
+	target.CAFile = caFile
+	target.ServerName = "example.com"
+	client, err = NewClient(target)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	result := Probe(client, target)
+	if result.Err != nil || !result.Up || result.TLS == nil {
+		t.Fatalf("Expected the custom CA to be trusted, got %+v", result)
+	}
+	if serverNames[len(serverNames)-1] != "example.com" {
+		t.Errorf("Expected SNI example.com, got %q", serverNames[len(serverNames)-1])
+	}
+
+	opts, err := CertificateOptions(target)
+	if err != nil || opts.RootCAs == nil || opts.ServerName != "example.com" {
+		t.Errorf("Expected certificate options with the CA and server name, got %+v (%v)", opts, err)
+	}
+
+	target.CAFile = ""
+	target.CAData = []byte("not a certificate")
+	if _, err := NewClient(target); err == nil {
+		t.Errorf("Expected an error for invalid CA data")
+	}
+}
+
+func TestProbe_Resolve(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Host, "www.example.invalid:") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	target := config.Target{
+		Name:    "resolved",
+		URL:     "http://www.example.invalid:" + port,
+		Method:  "GET",
+		Timeout: 5,
+		Resolve: map[string]string{"www.example.invalid:" + port: "127.0.0.1"},
+	}
+
+	client, err := NewClient(target)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	result := Probe(client, target)
+	if result.Err != nil || !result.Up {
+		t.Fatalf("Expected the overridden address to be checked, got %+v", result)
+	}
+	if result.ResolvedIP != "127.0.0.1" {
+		t.Errorf("Expected resolved IP 127.0.0.1, got %q", result.ResolvedIP)
+	}
+
+	found := false
+	for _, tag := range Tags(target, result) {
+		if tag == "resolved_ip:127.0.0.1" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected a resolved_ip tag, got %v", Tags(target, result))
+	}
+
+	target.Resolve = nil
+	for _, tag := range Tags(target, result) {
+		if strings.HasPrefix(tag, "resolved_ip:") {
+			t.Errorf("Expected no resolved_ip tag without resolution overrides, got %s", tag)
+		}
+	}
+}
+
+// recordingDatadog records the tags of every url.up gauge
+type recordingDatadog struct {
+	mockDatadog
+	upTags map[float64][]string
+	gauges []string
+}
+
+func (m *recordingDatadog) Gauge(name string, value float64, tags []string) error {
+	m.gauges = append(m.gauges, name)
+	if name == MetricURLUp {
+		m.upTags[value] = tags
+	}
+	return m.mockDatadog.Gauge(name, value, tags)
+}
+
+func TestRunner_BothIPFamilies(t *testing.T) {
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	target := config.Target{Name: "dual", URL: server.URL, Method: "GET", Timeout: 5, IPFamily: dialer.IPFamilyBoth}
+	if variants := IPFamilies(target); len(variants) != 2 {
+		t.Fatalf("Expected an IPv4 and an IPv6 check, got %d", len(variants))
+	}
+
+	mock := &recordingDatadog{upTags: make(map[float64][]string)}
+	client, err := NewClient(target)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	NewRunner(mock, NopLogger()).Check(client, target)
+
+	if len(mock.upTags) != 2 {
+		t.Fatalf("Expected a url.up gauge per IP family, got %v", mock.upTags)
+	}
+	// The server only listens on IPv4, so the IPv6 check must fail
+	for value, family := range map[float64]string{1: "ip_family:v4", 0: "ip_family:v6"} {
+		found := false
+		for _, tag := range mock.upTags[value] {
+			if tag == family {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected url.up %v to be tagged %s, got %v", value, family, mock.upTags[value])
+		}
+	}
+}
+
+func TestProbe_Proxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Plain HTTP requests reach the proxy with an absolute URL
+		if r.URL.Host != "target.invalid" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	target := config.Target{Name: "proxied", URL: "http://target.invalid/", Method: "GET", Timeout: 5, Proxy: proxy.URL}
+	client, err := NewClient(target)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if result := Probe(client, target); !result.Up || result.ProxyFailed {
+		t.Errorf("Expected the target to be checked through the proxy, got %+v", result)
+	}
+
+	proxy.Close()
+	client, err = NewClient(target)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	mock := &recordingDatadog{upTags: make(map[float64][]string)}
+	NewRunner(mock, NopLogger()).Check(client, target)
+	if mock.lastGaugeName != MetricProxyError || mock.lastGaugeValue != 1 {
+		t.Errorf("Expected url.proxy_error 1 for an unreachable proxy, got %s %v", mock.lastGaugeName, mock.lastGaugeValue)
+	}
+	if tags, ok := mock.upTags[0]; !ok || len(tags) == 0 {
+		t.Errorf("Expected the target to be reported down")
+	}
+}
+
+func TestProbe_Redirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			http.Redirect(w, r, "/login", http.StatusFound)
+		case "/login":
+			http.Redirect(w, r, "/login/form", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	target := config.Target{Name: "redirecting", URL: server.URL + "/", Method: "GET"}
+
+	result := Probe(client, target)
+	if !result.Up || len(result.Hops) != 2 || result.FinalURL != server.URL+"/login/form" {
+		t.Fatalf("Expected 2 redirects ending at the form, got %+v", result)
+	}
+	if result.Hops[0].URL != server.URL+"/" || result.Hops[0].Status != http.StatusFound {
+		t.Errorf("Expected the first hop to be the target's redirect, got %+v", result.Hops[0])
+	}
+
+	limit := config.RedirectLimit(1)
+	target.FollowRedirects = &limit
+	result = Probe(client, target)
+	if result.Up || result.Status != http.StatusFound || len(result.Hops) != 1 {
+		t.Errorf("Expected the second redirect to be evaluated and fail, got %+v", result)
+	}
+
+	limit = 0
+	target.ExpectedStatus = []int{http.StatusFound}
+	if result = Probe(client, target); !result.Up || len(result.Hops) != 0 || result.FinalURL != target.URL {
+		t.Errorf("Expected the first response to be evaluated without following, got %+v", result)
+	}
+
+	target.FollowRedirects = nil
+	target.ExpectedStatus = nil
+	target.ExpectedFinalURL = server.URL + "/dashboard"
+	if result = Probe(client, target); result.Up || FinalURLMatches(target, result) {
+		t.Errorf("Expected a redirect to the login form to be down, got %+v", result)
+	}
+}
+
+func TestProbe_Protocol(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	h1Server := httptest.NewTLSServer(handler)
+	defer h1Server.Close()
+	h2Server := httptest.NewUnstartedServer(handler)
+	h2Server.EnableHTTP2 = true
+	h2Server.StartTLS()
+	defer h2Server.Close()
+	h2cServer := httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+	defer h2cServer.Close()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	h3Server := &http3.Server{
+		Handler:   handler,
+		TLSConfig: http3.ConfigureTLSConfig(&tls.Config{Certificates: h2Server.TLS.Certificates}),
+	}
+	go func() { _ = h3Server.Serve(conn) }()
+	defer h3Server.Close()
+	h3URL := "https://" + conn.LocalAddr().String()
+
+	// The test servers share a certificate
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: h1Server.Certificate().Raw})
+
+	for name, tc := range map[string]struct {
+		protocol   string
+		url        string
+		up         bool
+		negotiated string
+	}{
+		"h1":               {protocol: config.ProtocolH1, url: h1Server.URL, up: true, negotiated: "h1"},
+		"h1 on h2 server":  {protocol: config.ProtocolH1, url: h2Server.URL, up: true, negotiated: "h1"},
+		"h2":               {protocol: config.ProtocolH2, url: h2Server.URL, up: true, negotiated: "h2"},
+		"h2 on h1 server":  {protocol: config.ProtocolH2, url: h1Server.URL, negotiated: "h1"},
+		"h2c":              {protocol: config.ProtocolH2C, url: h2cServer.URL, up: true, negotiated: "h2"},
+		"h2c on h1 server": {protocol: config.ProtocolH2C, url: strings.Replace(h1Server.URL, "https", "http", 1)},
+		"h3":               {protocol: config.ProtocolH3, url: h3URL, up: true, negotiated: "h3"},
+		"negotiated":       {url: h2Server.URL, up: true, negotiated: "h2"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			target := config.Target{Name: name, URL: tc.url, Method: "GET", Timeout: 5, CAData: caData, Protocol: tc.protocol}
+			client, err := NewClient(target)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			result := Probe(client, target)
+			if result.Up != tc.up || result.Protocol != tc.negotiated {
+				t.Fatalf("Expected up=%v over %q, got %+v", tc.up, tc.negotiated, result)
+			}
+			if !tc.up && result.Err == nil && ProtocolMatches(target, result) {
+				t.Errorf("Expected the protocol mismatch to be reported")
+			}
+
+			hasTag := false
+			for _, tag := range Tags(target, result) {
+				hasTag = hasTag || tag == "protocol:"+tc.negotiated
+			}
+			if hasTag != (tc.protocol != "" && tc.negotiated != "") {
+				t.Errorf("Expected the protocol tag only for forced protocols, got %v", Tags(target, result))
+			}
+		})
+	}
+}
+
+func TestCheckRedirectCertificates(t *testing.T) {
+	destination := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer destination.Close()
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, destination.URL+"/home", http.StatusMovedPermanently)
+	}))
+	defer origin.Close()
+
+	target := config.Target{Name: "origin", URL: origin.URL, Method: "GET", Timeout: 5, CheckRedirectCerts: true}
+	result := Probe(destination.Client(), target)
+	if !result.Up || len(result.Hops) != 1 {
+		t.Fatalf("Expected a single redirect to the TLS server, got %+v", result)
+	}
+
+	certs, err := CheckRedirectCertificates(target, result)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(certs) != 1 || certs[0].Details == nil {
+		t.Fatalf("Expected the certificate of the destination, got %+v", certs)
+	}
+	if certs[0].Host != strings.TrimPrefix(destination.URL, "https://") {
+		t.Errorf("Expected the destination host, got %q", certs[0].Host)
+	}
+}
@@ -0,0 +1,37 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/certcheck"
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+	"github.com/kuskoman/url-datadog-monitor/pkg/notifier"
+)
+
+// NotifierCheck converts a check into what notifiers are told about. Checks slower than the
+// critical latency threshold count as down, like in SLOs.
+func NotifierCheck(target config.Target, at time.Time, result Result, health Health) notifier.Check {
+	check := notifier.Check{
+		Time:       at,
+		Down:       health < HealthDegraded,
+		StatusCode: result.Status,
+		Message:    HealthMessage(target, result, health),
+	}
+	if result.Err != nil {
+		check.Error = result.Err.Error()
+	}
+	return check
+}
+
+// NotifierCertificate converts a certificate check into what notifiers are told about
+func NotifierCertificate(at time.Time, details *certcheck.CertificateDetails) notifier.Certificate {
+	cert := notifier.Certificate{
+		Time:     at,
+		Valid:    details.IsValid,
+		NotAfter: details.NotAfter,
+	}
+	if details.Error != nil {
+		cert.Error = details.Error.Error()
+	}
+	return cert
+}
@@ -0,0 +1,59 @@
+package monitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+	"github.com/kuskoman/url-datadog-monitor/pkg/notifier"
+)
+
+func TestRunner_Notifier(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	webhook := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		var transition notifier.Transition
+		_ = json.NewDecoder(r.Body).Decode(&transition)
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, transition.Event+" "+transition.Target)
+	}))
+	defer webhook.Close()
+
+	healthy := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	dispatcher, err := notifier.NewDispatcher([]config.Notifier{{URL: webhook.URL, Timeout: 5}}, NopLogger())
+	if err != nil {
+		t.Fatalf("Failed to create dispatcher: %v", err)
+	}
+	defer dispatcher.Close()
+
+	runner := NewRunner(&mockDatadog{}, NopLogger())
+	runner.Notifier = dispatcher
+	target := config.Target{Name: "api", URL: server.URL, Method: "GET"}
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	runner.Check(client, target)
+	healthy = false
+	runner.Check(client, target)
+	runner.Check(client, target)
+	healthy = true
+	runner.Check(client, target)
+	dispatcher.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 || events[0] != "down api" || events[1] != "recovered api" {
+		t.Errorf("Expected a single down and recovered notification, got %v", events)
+	}
+}
@@ -0,0 +1,188 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+	"github.com/kuskoman/url-datadog-monitor/pkg/state"
+)
+
+// ErrUnknownTarget is returned for checks of targets that aren't configured
+var ErrUnknownTarget = errors.New("unknown target")
+
+// checkRequest asks the scheduler to check a target right away
+type checkRequest struct {
+	target config.Target
+	done   chan struct{}
+}
+
+// Scheduler checks the targets of a config at their intervals with a Runner, and on demand
+type Scheduler struct {
+	cfg    *config.Config
+	runner *Runner
+
+	requests chan checkRequest
+	running  atomic.Bool
+}
+
+// NewScheduler creates a scheduler checking the targets of cfg with runner
+func NewScheduler(cfg *config.Config, runner *Runner) *Scheduler {
+	return &Scheduler{
+		cfg:      cfg,
+		runner:   runner,
+		requests: make(chan checkRequest),
+	}
+}
+
+// Ready reports whether the scheduler is running and checking targets
+func (s *Scheduler) Ready() bool {
+	return s.running.Load()
+}
+
+// Target returns the configured target with the given name
+func (s *Scheduler) Target(name string) (config.Target, bool) {
+	for _, target := range s.cfg.Targets {
+		if target.Name == name {
+			return target, true
+		}
+	}
+	return config.Target{}, false
+}
+
+// CheckNow checks a target right away and reschedules its next check. It returns once the
+// check is done, or with the context error.
+func (s *Scheduler) CheckNow(ctx context.Context, name string) error {
+	target, ok := s.Target(name)
+	if !ok {
+		return ErrUnknownTarget
+	}
+
+	request := checkRequest{target: target, done: make(chan struct{})}
+	select {
+	case s.requests <- request:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-request.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run checks the targets until the context is canceled
+func (s *Scheduler) Run(ctx context.Context) {
+	cfg, runner, logger := s.cfg, s.runner, s.runner.Logger
+
+	logger.Info("Starting target monitoring",
+		slog.Int("target_count", len(cfg.Targets)))
+
+	var store state.Store
+	savedChecks := make(map[string]time.Time)
+	if cfg.StateFile != "" {
+		store = state.NewFileStore(cfg.StateFile)
+		if err := runner.LoadState(ctx, store); err != nil {
+			logger.Error("Failed to load state file",
+				slog.String("path", cfg.StateFile),
+				slog.Any("error", err))
+		}
+		if err := store.Load(ctx, state.NextChecks, &savedChecks); err != nil {
+			logger.Error("Failed to load state file",
+				slog.String("path", cfg.StateFile),
+				slog.Any("error", err))
+		}
+	}
+
+	// Targets checked shortly before a restart keep their schedule, unless their interval
+	// was shortened since
+	nextChecks := make(map[string]time.Time)
+	for _, target := range cfg.Targets {
+		nextChecks[target.Name] = time.Now()
+		interval := time.Duration(target.Interval) * time.Second
+		if saved, ok := savedChecks[target.Name]; ok && saved.Before(time.Now().Add(interval)) {
+			nextChecks[target.Name] = saved
+		}
+		if runner.Statuses != nil {
+			runner.Statuses.Register(target)
+			runner.Statuses.Schedule(target, nextChecks[target.Name])
+		}
+	}
+
+	check := func(target config.Target, now time.Time) {
+		next := now.Add(time.Duration(target.Interval) * time.Second)
+		nextChecks[target.Name] = next
+		if runner.Statuses != nil {
+			runner.Statuses.Schedule(target, next)
+		}
+
+		client, err := NewClient(target)
+		if err != nil {
+			logger.Error("Failed to create HTTP client",
+				slog.String("target", target.Name),
+				slog.String("url", target.URL),
+				slog.Any("error", err))
+			return
+		}
+
+		runner.Check(client, target)
+	}
+
+	save := func() {
+		if store == nil {
+			return
+		}
+		if err := saveState(ctx, store, runner, nextChecks); err != nil {
+			logger.Warn("Failed to save state file",
+				slog.String("path", cfg.StateFile),
+				slog.Any("error", err))
+		}
+	}
+
+	ticker := time.NewTicker(TickInterval)
+	defer ticker.Stop()
+
+	s.running.Store(true)
+	defer s.running.Store(false)
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Stopping target monitoring due to context cancellation")
+			return
+
+		case request := <-s.requests:
+			logger.Info("Checking target on demand",
+				slog.String("target", request.target.Name))
+			check(request.target, time.Now())
+			close(request.done)
+			save()
+
+		case now := <-ticker.C:
+			checked := false
+			for _, target := range cfg.Targets {
+				nextCheck, ok := nextChecks[target.Name]
+				if !ok || now.After(nextCheck) {
+					check(target, now)
+					checked = true
+				}
+			}
+
+			if checked {
+				save()
+			}
+		}
+	}
+}
+
+// saveState saves the state of the runner and the schedule of the next checks in a single write
+func saveState(ctx context.Context, store state.Store, runner *Runner, nextChecks map[string]time.Time) error {
+	snapshots := runner.Trackers().Snapshots("")
+	snapshots[state.NextChecks] = nextChecks
+	return store.Save(ctx, snapshots)
+}
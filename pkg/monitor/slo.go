@@ -0,0 +1,117 @@
+package monitor
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+	"github.com/kuskoman/url-datadog-monitor/pkg/exporter"
+	"github.com/kuskoman/url-datadog-monitor/pkg/slo"
+)
+
+// SLOWindowTag returns the tag identifying the window of a url.slo.burn_rate metric
+func SLOWindowTag(window time.Duration) string {
+	return "window:" + slo.FormatWindow(window)
+}
+
+// SLOAlertTitle returns the event title of a burn rate alert that fired or resolved
+func SLOAlertTitle(target config.Target, alert slo.Alert, fired bool) string {
+	if fired {
+		return fmt.Sprintf("SLO burn rate alert for %s (%s)", target.Name, alert.Name())
+	}
+	return fmt.Sprintf("SLO burn rate recovered for %s (%s)", target.Name, alert.Name())
+}
+
+// SLOAlertText describes the burn rates of an alert that fired or resolved
+func SLOAlertText(target config.Target, objective slo.Objective, alert slo.Alert, report slo.Report) string {
+	return fmt.Sprintf("The %.3g%% objective of %s burns its error budget at %.1fx over %s and %.1fx over %s (threshold %.1fx), %.1f%% of the budget remains",
+		objective.Objective, target.URL,
+		report.BurnRates[alert.LongWindow], slo.FormatWindow(alert.LongWindow),
+		report.BurnRates[alert.ShortWindow], slo.FormatWindow(alert.ShortWindow),
+		alert.Threshold, report.ErrorBudgetRemaining)
+}
+
+// recordSLO counts a check against the SLO of the target, sends the SLO metrics and an event
+// when a burn rate alert fires or resolves. It returns the objective and its report, nil
+// when the SLO is invalid.
+func (r *Runner) recordSLO(name string, target config.Target, good bool, tags []string) (slo.Objective, *slo.Report) {
+	objective, err := slo.ParseObjective(*target.SLO)
+	if err != nil {
+		r.Logger.Error("Failed to parse SLO",
+			slog.String("target", target.Name),
+			slog.Any("error", err))
+		return objective, nil
+	}
+
+	report := r.slos.Record(name, objective, time.Now(), good)
+	r.sendSLOMetrics(target, objective, report, tags)
+
+	for _, alert := range report.Fired {
+		r.Logger.Warn("Target is burning its error budget too fast",
+			slog.String("target", target.Name),
+			slog.String("url", target.URL),
+			slog.String("alert", alert.Name()),
+			slog.Float64("burn_rate", report.BurnRates[alert.LongWindow]),
+			slog.Float64("error_budget_remaining", report.ErrorBudgetRemaining))
+		r.sendSLOEvent(target, objective, alert, report, true, tags)
+	}
+	for _, alert := range report.Resolved {
+		r.Logger.Info("Target error budget burn rate recovered",
+			slog.String("target", target.Name),
+			slog.String("url", target.URL),
+			slog.String("alert", alert.Name()))
+		r.sendSLOEvent(target, objective, alert, report, false, tags)
+	}
+	return objective, &report
+}
+
+// sendSLOMetrics sends the attainment, remaining error budget and burn rates of an SLO
+func (r *Runner) sendSLOMetrics(target config.Target, objective slo.Objective, report slo.Report, tags []string) {
+	if r.Metrics == nil {
+		return
+	}
+
+	gauges := map[string]float64{
+		MetricSLOAttainment:           report.Attainment,
+		MetricSLOErrorBudgetRemaining: report.ErrorBudgetRemaining,
+	}
+	for _, name := range []string{MetricSLOAttainment, MetricSLOErrorBudgetRemaining} {
+		if err := r.Metrics.Gauge(name, gauges[name], tags); err != nil {
+			r.Logger.Warn("Failed to send "+name+" metric",
+				slog.String("target", target.Name),
+				slog.String("url", target.URL),
+				slog.Any("error", err))
+		}
+	}
+
+	for _, window := range objective.BurnRateWindows() {
+		windowTags := append(append([]string(nil), tags...), SLOWindowTag(window))
+		if err := r.Metrics.Gauge(MetricSLOBurnRate, report.BurnRates[window], windowTags); err != nil {
+			r.Logger.Warn("Failed to send url.slo.burn_rate metric",
+				slog.String("target", target.Name),
+				slog.String("url", target.URL),
+				slog.Any("error", err))
+		}
+	}
+}
+
+// sendSLOEvent sends a Datadog event for a burn rate alert that fired or resolved
+func (r *Runner) sendSLOEvent(target config.Target, objective slo.Objective, alert slo.Alert, report slo.Report, fired bool, tags []string) {
+	events, ok := r.Metrics.(exporter.EventExporter)
+	if !ok {
+		return
+	}
+
+	alertType := exporter.AlertTypeSuccess
+	if fired {
+		alertType = exporter.AlertTypeError
+	}
+	text := SLOAlertText(target, objective, alert, report)
+	if err := events.Event(SLOAlertTitle(target, alert, fired), text, alertType, tags); err != nil {
+		r.Logger.Warn("Failed to send SLO burn rate event",
+			slog.String("target", target.Name),
+			slog.String("url", target.URL),
+			slog.Any("error", err))
+	}
+}
@@ -0,0 +1,69 @@
+package monitor
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+)
+
+type sloDatadog struct {
+	mockEventDatadog
+	gauges     map[string]float64
+	burnWindow map[string]float64
+}
+
+func (m *sloDatadog) Gauge(name string, value float64, tags []string) error {
+	m.gauges[name] = value
+	if name == MetricSLOBurnRate {
+		for _, tag := range tags {
+			if strings.HasPrefix(tag, "window:") {
+				m.burnWindow[tag] = value
+			}
+		}
+	}
+	return m.mockDatadog.Gauge(name, value, tags)
+}
+
+func TestRunner_SLO(t *testing.T) {
+	healthy := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	target := config.Target{
+		Name:   "api",
+		URL:    server.URL,
+		Method: "GET",
+		SLO:    &config.SLO{Objective: 99, Window: "30d", BurnRateAlerts: config.DefaultBurnRateAlerts},
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	mock := &sloDatadog{gauges: make(map[string]float64), burnWindow: make(map[string]float64)}
+	runner := NewRunner(mock, NopLogger())
+	runner.Check(client, target)
+	healthy = false
+	runner.Check(client, target)
+
+	if mock.gauges[MetricSLOAttainment] != 50 {
+		t.Errorf("Expected an attainment of 50%%, got %v", mock.gauges[MetricSLOAttainment])
+	}
+	if mock.gauges[MetricSLOErrorBudgetRemaining] >= 0 {
+		t.Errorf("Expected an exhausted error budget, got %v", mock.gauges[MetricSLOErrorBudgetRemaining])
+	}
+	for _, window := range []string{"window:5m", "window:30m", "window:1h", "window:6h"} {
+		if math.Abs(mock.burnWindow[window]-50) > 1e-9 {
+			t.Errorf("Expected a burn rate of 50 over %s, got %v", window, mock.burnWindow[window])
+		}
+	}
+	if len(mock.eventTitles) != 2 || mock.eventTitles[0] != "SLO burn rate alert for api (1h/5m)" {
+		t.Errorf("Expected both burn rate alerts to fire, got %v", mock.eventTitles)
+	}
+}
@@ -0,0 +1,38 @@
+package monitor
+
+import (
+	"context"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/state"
+)
+
+// Trackers returns the state of the runner carried over between checks
+func (r *Runner) Trackers() state.Trackers {
+	return state.Trackers{Serials: r.serials, ContentHashes: r.contentHashes, SLOs: r.slos}
+}
+
+// LoadState restores the certificate serials, content hashes and SLO counts saved by SaveState.
+// State recorded since the runner was created is kept.
+func (r *Runner) LoadState(ctx context.Context, store state.Store) error {
+	return r.Trackers().Load(ctx, store, "")
+}
+
+// SaveState saves the certificate serials, content hashes and SLO counts of all targets
+func (r *Runner) SaveState(ctx context.Context, store state.Store) error {
+	_, err := r.Trackers().Save(ctx, store, "")
+	return err
+}
+
+// Seed records the certificate serial and content hash last reported for a target that
+// hasn't been checked yet, so a change while nothing checked it is still noticed
+func (r *Runner) Seed(name, serial, contentHash string) {
+	r.serials.Seed(name, serial)
+	r.contentHashes.Seed(name, contentHash)
+}
+
+// Forget drops the state kept for a target
+func (r *Runner) Forget(name string) {
+	r.serials.Forget(name)
+	r.contentHashes.Forget(name)
+	r.slos.Forget(name)
+}
@@ -0,0 +1,36 @@
+package monitor
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/certcheck"
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+	"github.com/kuskoman/url-datadog-monitor/pkg/state"
+)
+
+func TestRunner_StateAcrossRestarts(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state.json")
+	target := config.Target{Name: "Rotating", URL: "https://rotating.example.com"}
+
+	before := NewRunner(&mockEventDatadog{}, NopLogger())
+	before.observeSerial(target.Name, target, &certcheck.CertificateDetails{SerialNumber: "1", NotAfter: time.Now()}, nil)
+	if err := before.SaveState(ctx, state.NewFileStore(path)); err != nil {
+		t.Fatalf("Failed to save state: %v", err)
+	}
+
+	// The certificate is rotated while the service restarts
+	mock := &mockEventDatadog{}
+	after := NewRunner(mock, NopLogger())
+	if err := after.LoadState(ctx, state.NewFileStore(path)); err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+	after.observeSerial(target.Name, target, &certcheck.CertificateDetails{SerialNumber: "2", NotAfter: time.Now()}, nil)
+
+	if len(mock.eventTitles) != 1 || mock.eventTitles[0] != "Certificate rotated for Rotating" {
+		t.Errorf("Expected the rotation during the restart to be reported, got %v", mock.eventTitles)
+	}
+}
@@ -0,0 +1,215 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/certcheck"
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+)
+
+// StatusPending is the status of a target that wasn't checked yet
+const StatusPending = "pending"
+
+// CheckRecord summarizes a check of a target
+type CheckRecord struct {
+	Time time.Time `json:"time"`
+	// Status is the health of the check: up, degraded, critical or down
+	Status         string `json:"status"`
+	Up             bool   `json:"up"`
+	StatusCode     int    `json:"status_code,omitempty"`
+	ResponseTimeMs int64  `json:"response_time_ms"`
+	Error          string `json:"error,omitempty"`
+	FinalURL       string `json:"final_url,omitempty"`
+	Protocol       string `json:"protocol,omitempty"`
+	ResponseBytes  int64  `json:"response_bytes,omitempty"`
+}
+
+// CertificateRecord summarizes the last certificate check of a target
+type CertificateRecord struct {
+	CheckedAt       time.Time `json:"checked_at"`
+	Subject         string    `json:"subject"`
+	Issuer          string    `json:"issuer"`
+	SerialNumber    string    `json:"serial_number"`
+	NotAfter        time.Time `json:"not_after"`
+	DaysUntilExpiry float64   `json:"days_until_expiry"`
+	Valid           bool      `json:"valid"`
+	Weak            bool      `json:"weak"`
+	TLSVersion      string    `json:"tls_version,omitempty"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// TargetStatus is the current state of a target
+type TargetStatus struct {
+	Name   string            `json:"name"`
+	URL    string            `json:"url"`
+	Labels map[string]string `json:"labels,omitempty"`
+	// Status is the health of the last check, StatusPending before the first one
+	Status      string             `json:"status"`
+	LastCheck   *CheckRecord       `json:"last_check,omitempty"`
+	Certificate *CertificateRecord `json:"certificate,omitempty"`
+	NextCheck   time.Time          `json:"next_check,omitempty"`
+}
+
+// targetStatus is the state and the recent checks of a target
+type targetStatus struct {
+	status  TargetStatus
+	history []CheckRecord
+}
+
+// StatusTracker keeps the current state and the recent checks of every target. It is safe for
+// concurrent use.
+type StatusTracker struct {
+	historySize int
+
+	mu      sync.RWMutex
+	order   []string
+	targets map[string]*targetStatus
+}
+
+// NewStatusTracker creates a tracker keeping the last historySize checks of every target
+func NewStatusTracker(historySize int) *StatusTracker {
+	return &StatusTracker{
+		historySize: historySize,
+		targets:     make(map[string]*targetStatus),
+	}
+}
+
+// Register adds a target, listed in registration order, or updates the URL and labels of a
+// registered one
+func (s *StatusTracker) Register(target config.Target) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ts := s.get(target)
+	ts.status.URL = target.URL
+	ts.status.Labels = target.Labels
+}
+
+// get returns the state of a target, registering it when needed. The lock must be held.
+func (s *StatusTracker) get(target config.Target) *targetStatus {
+	ts, ok := s.targets[target.Name]
+	if !ok {
+		ts = &targetStatus{status: TargetStatus{
+			Name:   target.Name,
+			URL:    target.URL,
+			Labels: target.Labels,
+			Status: StatusPending,
+		}}
+		s.targets[target.Name] = ts
+		s.order = append(s.order, target.Name)
+	}
+	return ts
+}
+
+// Record adds a check of a target to its history
+func (s *StatusTracker) Record(target config.Target, at time.Time, result Result, health Health) {
+	record := CheckRecord{
+		Time:           at,
+		Status:         health.String(),
+		Up:             result.Up,
+		StatusCode:     result.Status,
+		ResponseTimeMs: result.Duration.Milliseconds(),
+		FinalURL:       result.FinalURL,
+		Protocol:       result.Protocol,
+		ResponseBytes:  result.ResponseBytes,
+	}
+	if result.Err != nil {
+		record.Error = result.Err.Error()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ts := s.get(target)
+	ts.status.Status = record.Status
+	ts.status.LastCheck = &record
+	ts.history = append(ts.history, record)
+	if len(ts.history) > s.historySize {
+		ts.history = ts.history[len(ts.history)-s.historySize:]
+	}
+}
+
+// ObserveCertificate records the last certificate check of a target
+func (s *StatusTracker) ObserveCertificate(target config.Target, at time.Time, details *certcheck.CertificateDetails) {
+	record := &CertificateRecord{
+		CheckedAt:       at,
+		Subject:         details.Subject,
+		Issuer:          details.Issuer,
+		SerialNumber:    details.SerialNumber,
+		NotAfter:        details.NotAfter,
+		DaysUntilExpiry: details.NotAfter.Sub(at).Hours() / 24,
+		Valid:           details.IsValid,
+		Weak:            details.IsWeak(),
+		TLSVersion:      details.TLSVersion,
+	}
+	if details.Error != nil {
+		record.Error = details.Error.Error()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.get(target).status.Certificate = record
+}
+
+// Schedule records when a target is checked next
+func (s *StatusTracker) Schedule(target config.Target, next time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.get(target).status.NextCheck = next
+}
+
+// Forget removes a target
+func (s *StatusTracker) Forget(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.targets[name]; !ok {
+		return
+	}
+	delete(s.targets, name)
+	for i, registered := range s.order {
+		if registered == name {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// Status returns the state of a target
+func (s *StatusTracker) Status(name string) (TargetStatus, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ts, ok := s.targets[name]
+	if !ok {
+		return TargetStatus{}, false
+	}
+	return ts.status, true
+}
+
+// Statuses returns the state of every target in registration order
+func (s *StatusTracker) Statuses() []TargetStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]TargetStatus, 0, len(s.order))
+	for _, name := range s.order {
+		statuses = append(statuses, s.targets[name].status)
+	}
+	return statuses
+}
+
+// History returns the recent checks of a target, oldest first
+func (s *StatusTracker) History(name string) ([]CheckRecord, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ts, ok := s.targets[name]
+	if !ok {
+		return nil, false
+	}
+	return append([]CheckRecord(nil), ts.history...), true
+}
@@ -0,0 +1,104 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+)
+
+func TestStatusTracker_History(t *testing.T) {
+	tracker := NewStatusTracker(2)
+	target := config.Target{Name: "Example", URL: "https://example.com"}
+	tracker.Register(target)
+
+	status, ok := tracker.Status("Example")
+	if !ok || status.Status != StatusPending || status.LastCheck != nil {
+		t.Errorf("Expected a pending target before its first check, got %+v", status)
+	}
+
+	start := time.Now()
+	for i, code := range []int{200, 500, 200} {
+		result := Result{Up: code == 200, Status: code}
+		health := HealthUp
+		if code != 200 {
+			health = HealthDown
+		}
+		tracker.Record(target, start.Add(time.Duration(i)*time.Minute), result, health)
+	}
+
+	history, _ := tracker.History("Example")
+	if len(history) != 2 {
+		t.Fatalf("Expected the history to keep 2 checks, got %d", len(history))
+	}
+	if history[0].StatusCode != 500 || history[1].StatusCode != 200 {
+		t.Errorf("Expected the last 2 checks oldest first, got %+v", history)
+	}
+	status, _ = tracker.Status("Example")
+	if status.Status != "up" || status.LastCheck.StatusCode != 200 {
+		t.Errorf("Expected the last check to be up, got %+v", status)
+	}
+	if _, ok := tracker.History("Unknown"); ok {
+		t.Error("Expected no history for an unknown target")
+	}
+}
+
+func TestScheduler_CheckNow(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// A long interval so that only the triggered check runs after the first one
+	target := config.Target{Name: "Server", URL: server.URL, Method: "GET", Timeout: 5, Interval: 3600}
+	cfg := &config.Config{Targets: []config.Target{target}}
+
+	runner := NewRunner(&mockDatadog{}, NopLogger())
+	runner.Statuses = NewStatusTracker(config.DefaultHistorySize)
+	scheduler := NewScheduler(cfg, runner)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go scheduler.Run(ctx)
+
+	checkCtx, checkCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer checkCancel()
+	if err := scheduler.CheckNow(checkCtx, "Unknown"); !errors.Is(err, ErrUnknownTarget) {
+		t.Errorf("Expected ErrUnknownTarget, got %v", err)
+	}
+	if err := scheduler.CheckNow(checkCtx, "Server"); err != nil {
+		t.Fatalf("Failed to check target: %v", err)
+	}
+
+	status, _ := runner.Statuses.Status("Server")
+	if status.LastCheck == nil || status.LastCheck.StatusCode != http.StatusOK {
+		t.Errorf("Expected the triggered check to be recorded, got %+v", status)
+	}
+	if !status.NextCheck.After(time.Now().Add(time.Hour - time.Minute)) {
+		t.Errorf("Expected the next check to be rescheduled an interval later, got %v", status.NextCheck)
+	}
+	if !scheduler.Ready() {
+		t.Error("Expected the scheduler to be ready while running")
+	}
+}
+
+func TestStatusTracker_Forget(t *testing.T) {
+	tracker := NewStatusTracker(10)
+	for _, name := range []string{"a", "b", "c"} {
+		tracker.Register(config.Target{Name: name})
+	}
+	tracker.Forget("b")
+	tracker.Forget("unknown")
+
+	statuses := tracker.Statuses()
+	if len(statuses) != 2 || statuses[0].Name != "a" || statuses[1].Name != "c" {
+		t.Errorf("Expected a and c to remain in order, got %+v", statuses)
+	}
+	if _, ok := tracker.History("b"); ok {
+		t.Error("Expected the history of a forgotten target to be dropped")
+	}
+}
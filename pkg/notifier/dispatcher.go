@@ -0,0 +1,301 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+)
+
+// CertificateExpiringDays is how many days before expiry a certificate is reported as expiring
+const CertificateExpiringDays = 14
+
+// Check is the outcome of a check of a target
+type Check struct {
+	Time time.Time
+	// Down is set for failed checks and checks slower than the critical latency threshold
+	Down       bool
+	StatusCode int
+	Error      string
+	// Message describes the check, like the url.status service check
+	Message string
+}
+
+// Certificate is the outcome of a certificate check of a target
+type Certificate struct {
+	Time     time.Time
+	Valid    bool
+	NotAfter time.Time
+	Error    string
+}
+
+// certState is the state of the certificate of a target
+type certState int
+
+const (
+	certUnknown certState = iota
+	certOK
+	certExpiring
+	certInvalid
+)
+
+// targetState is what the dispatcher remembers of a target to detect transitions
+type targetState struct {
+	checked   bool
+	down      bool
+	downSince time.Time
+	cert      certState
+}
+
+const (
+	// QueueSize is how many transitions may wait for delivery per notifier before new ones are dropped
+	QueueSize = 100
+	// DrainTimeout is how long Close waits for queued transitions to be delivered before
+	// abandoning the deliveries in progress
+	DrainTimeout = 10 * time.Second
+)
+
+// Dispatcher detects the state transitions of targets and sends them to the matching notifiers.
+// Every notifier delivers its transitions in order, in the background so slow webhooks don't
+// delay checks. It is safe for concurrent use.
+type Dispatcher struct {
+	notifiers []*Notifier
+	queues    []chan Transition
+	logger    *slog.Logger
+
+	mu     sync.Mutex
+	states map[string]*targetState
+
+	// closeMu guards closed, so transitions are never queued once the queues are closed
+	closeMu sync.RWMutex
+	closed  bool
+
+	// pending counts the queued transitions not yet delivered
+	pending sync.WaitGroup
+	// workers counts the running delivery goroutines
+	workers sync.WaitGroup
+
+	// ctx bounds deliveries, canceled by Close once drainTimeout has passed
+	ctx    context.Context
+	cancel context.CancelFunc
+	// drainTimeout is how long Close waits for queued transitions
+	drainTimeout time.Duration
+}
+
+// NewDispatcher creates a dispatcher for the configured notifiers and starts their delivery.
+// Close stops it.
+func NewDispatcher(cfgs []config.Notifier, logger *slog.Logger) (*Dispatcher, error) {
+	d := &Dispatcher{logger: logger, states: make(map[string]*targetState), drainTimeout: DrainTimeout}
+	for _, cfg := range cfgs {
+		n, err := New(cfg)
+		if err != nil {
+			return nil, err
+		}
+		d.notifiers = append(d.notifiers, n)
+	}
+
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+
+	for _, n := range d.notifiers {
+		queue := make(chan Transition, QueueSize)
+		d.queues = append(d.queues, queue)
+		d.workers.Add(1)
+		go d.deliver(n, queue)
+	}
+	return d, nil
+}
+
+// state returns the state of a target, creating it when needed. The lock must be held.
+func (d *Dispatcher) state(name string) *targetState {
+	s, ok := d.states[name]
+	if !ok {
+		s = &targetState{}
+		d.states[name] = s
+	}
+	return s
+}
+
+// ObserveCheck notifies a down transition when a target goes down, including on its first
+// check, and a recovered transition when it comes back up
+func (d *Dispatcher) ObserveCheck(name string, target config.Target, check Check) {
+	d.mu.Lock()
+	s := d.state(name)
+	wasChecked, wasDown, downSince := s.checked, s.down, s.downSince
+	s.checked, s.down = true, check.Down
+	if check.Down && !wasDown {
+		s.downSince = check.Time
+	}
+	d.mu.Unlock()
+
+	t := Transition{
+		Target:     name,
+		URL:        target.URL,
+		Labels:     target.Labels,
+		Time:       check.Time,
+		StatusCode: check.StatusCode,
+		Error:      check.Error,
+	}
+	switch {
+	case check.Down && (!wasChecked || !wasDown):
+		t.Event, t.Message = config.NotifierEventDown, check.Message
+	case !check.Down && wasDown:
+		downtime := check.Time.Sub(downSince).Round(time.Second)
+		t.Event, t.Downtime = config.NotifierEventRecovered, downtime.String()
+		t.Message = fmt.Sprintf("%s recovered after %s", target.URL, downtime)
+	default:
+		return
+	}
+	d.Notify(t)
+}
+
+// ObserveCertificate notifies a cert_invalid transition when the certificate of a target
+// becomes invalid or expires, and a cert_expiring one when it gets within
+// CertificateExpiringDays of expiry
+func (d *Dispatcher) ObserveCertificate(name string, target config.Target, cert Certificate) {
+	daysUntilExpiry := cert.NotAfter.Sub(cert.Time).Hours() / 24
+	state := certOK
+	switch {
+	case !cert.Valid || daysUntilExpiry <= 0:
+		state = certInvalid
+	case daysUntilExpiry < CertificateExpiringDays:
+		state = certExpiring
+	}
+
+	d.mu.Lock()
+	s := d.state(name)
+	previous := s.cert
+	s.cert = state
+	d.mu.Unlock()
+
+	if state == previous || state == certOK {
+		return
+	}
+
+	t := Transition{
+		Target: name,
+		URL:    target.URL,
+		Labels: target.Labels,
+		Time:   cert.Time,
+		Error:  cert.Error,
+	}
+	if !cert.NotAfter.IsZero() {
+		notAfter := cert.NotAfter
+		t.NotAfter, t.DaysUntilExpiry = &notAfter, &daysUntilExpiry
+	}
+	if state == certExpiring {
+		t.Event = config.NotifierEventCertExpiring
+		t.Message = fmt.Sprintf("Certificate for %s expires in %.1f days", target.URL, daysUntilExpiry)
+	} else {
+		t.Event = config.NotifierEventCertInvalid
+		t.Message = fmt.Sprintf("Certificate for %s is invalid", target.URL)
+		if cert.Error != "" {
+			t.Message += ": " + cert.Error
+		} else if daysUntilExpiry <= 0 {
+			t.Message = fmt.Sprintf("Certificate for %s expired on %s", target.URL, cert.NotAfter.UTC().Format(time.RFC3339))
+		}
+	}
+	d.Notify(t)
+}
+
+// Forget drops the state of a target
+func (d *Dispatcher) Forget(name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.states, name)
+}
+
+// Notify queues a transition for the matching notifiers. Transitions notified after Close
+// are dropped.
+func (d *Dispatcher) Notify(t Transition) {
+	d.closeMu.RLock()
+	defer d.closeMu.RUnlock()
+	if d.closed {
+		return
+	}
+
+	for i, n := range d.notifiers {
+		if !n.Matches(t) {
+			continue
+		}
+
+		d.pending.Add(1)
+		select {
+		case d.queues[i] <- t:
+		default:
+			d.pending.Done()
+			d.logger.Error("Dropped notification, too many are waiting for delivery",
+				slog.String("notifier", n.Name()),
+				slog.String("event", t.Event),
+				slog.String("target", t.Target))
+		}
+	}
+}
+
+// deliver sends the transitions of a queue to its notifier until the queue is closed
+func (d *Dispatcher) deliver(n *Notifier, queue chan Transition) {
+	defer d.workers.Done()
+
+	for t := range queue {
+		if d.ctx.Err() != nil {
+			d.logger.Warn("Dropped notification, the dispatcher was closed",
+				slog.String("notifier", n.Name()),
+				slog.String("event", t.Event),
+				slog.String("target", t.Target))
+			d.pending.Done()
+			continue
+		}
+
+		if err := n.Send(d.ctx, t); err != nil {
+			d.logger.Error("Failed to send notification",
+				slog.String("notifier", n.Name()),
+				slog.String("event", t.Event),
+				slog.String("target", t.Target),
+				slog.Any("error", err))
+		} else {
+			d.logger.Info("Sent notification",
+				slog.String("notifier", n.Name()),
+				slog.String("event", t.Event),
+				slog.String("target", t.Target))
+		}
+		d.pending.Done()
+	}
+}
+
+// Wait waits for the queued transitions to be delivered
+func (d *Dispatcher) Wait() {
+	d.pending.Wait()
+}
+
+// Close delivers the queued transitions and stops the dispatcher. Deliveries still in progress
+// after DrainTimeout, retries included, are canceled and the remaining transitions dropped.
+func (d *Dispatcher) Close() {
+	d.closeMu.Lock()
+	if !d.closed {
+		d.closed = true
+		for _, queue := range d.queues {
+			close(queue)
+		}
+	}
+	d.closeMu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		d.workers.Wait()
+		close(drained)
+	}()
+
+	timer := time.NewTimer(d.drainTimeout)
+	defer timer.Stop()
+	select {
+	case <-drained:
+	case <-timer.C:
+		d.logger.Warn("Notifications weren't delivered in time, canceling them",
+			slog.Duration("timeout", d.drainTimeout))
+	}
+	d.cancel()
+	<-drained
+}
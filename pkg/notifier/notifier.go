@@ -0,0 +1,198 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"text/template"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+)
+
+const (
+	// SignatureHeader holds the hex HMAC-SHA256 of the payload, prefixed with "sha256="
+	SignatureHeader = "X-Signature-256"
+	// EventHeader holds the event of the transition
+	EventHeader = "X-URL-Monitor-Event"
+	// RetryBackoff is the delay before the first retry, doubled after every attempt
+	RetryBackoff = time.Second
+)
+
+// errPermanent marks failures that retrying won't fix
+var errPermanent = errors.New("permanent failure")
+
+// Transition is a change of state of a target, the default payload of notifications
+type Transition struct {
+	Event  string            `json:"event"`
+	Target string            `json:"target"`
+	URL    string            `json:"url"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Time   time.Time         `json:"time"`
+	// Message describes the transition for humans
+	Message    string `json:"message"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// Downtime is how long the target was down, only set when it recovered
+	Downtime string `json:"downtime,omitempty"`
+	// NotAfter is the expiry of the certificate, only set for certificate events
+	NotAfter        *time.Time `json:"not_after,omitempty"`
+	DaysUntilExpiry *float64   `json:"days_until_expiry,omitempty"`
+}
+
+// Notifier posts the transitions it is configured for to a webhook
+type Notifier struct {
+	config   config.Notifier
+	secret   string
+	template *template.Template
+	client   *http.Client
+	// backoff is the delay before the first retry
+	backoff time.Duration
+}
+
+// New creates a notifier, checking that its template renders valid JSON
+func New(cfg config.Notifier) (*Notifier, error) {
+	n := &Notifier{
+		config:  cfg,
+		secret:  cfg.Secret,
+		client:  &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second},
+		backoff: RetryBackoff,
+	}
+	if cfg.SecretEnv != "" {
+		n.secret = os.Getenv(cfg.SecretEnv)
+		if n.secret == "" {
+			return nil, fmt.Errorf("notifier %s: environment variable %s is empty", cfg.Name, cfg.SecretEnv)
+		}
+	}
+
+	if cfg.Template != "" {
+		tmpl, err := template.New(cfg.Name).Funcs(template.FuncMap{"json": toJSON}).Parse(cfg.Template)
+		if err != nil {
+			return nil, fmt.Errorf("notifier %s: invalid template: %w", cfg.Name, err)
+		}
+		n.template = tmpl
+
+		sample := Transition{Event: config.NotifierEventDown, Target: "example", URL: "https://example.com", Time: time.Now()}
+		if _, err := n.payload(sample); err != nil {
+			return nil, fmt.Errorf("notifier %s: %w", cfg.Name, err)
+		}
+	}
+	return n, nil
+}
+
+// Name returns the name of the notifier
+func (n *Notifier) Name() string {
+	return n.config.Name
+}
+
+// Matches reports whether the notifier is configured for the event and the labels of a transition
+func (n *Notifier) Matches(t Transition) bool {
+	if len(n.config.Events) > 0 && !slices.Contains(n.config.Events, t.Event) {
+		return false
+	}
+	for k, v := range n.config.Labels {
+		if t.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Send posts a transition to the webhook, retrying failed attempts with an exponential backoff.
+// Responses other than 2xx fail, and only 408, 429 and 5xx responses are retried.
+func (n *Notifier) Send(ctx context.Context, t Transition) error {
+	body, err := n.payload(t)
+	if err != nil {
+		return err
+	}
+
+	backoff := n.backoff
+	retries := 0
+	if n.config.Retries != nil {
+		retries = *n.config.Retries
+	}
+	for attempt := 0; ; attempt++ {
+		err = n.post(ctx, t.Event, body)
+		if err == nil || errors.Is(err, errPermanent) || attempt >= retries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// payload renders the body of a notification
+func (n *Notifier) payload(t Transition) ([]byte, error) {
+	if n.template == nil {
+		return json.Marshal(t)
+	}
+
+	var buf bytes.Buffer
+	if err := n.template.Execute(&buf, t); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("template doesn't render valid JSON: %s", buf.String())
+	}
+	return buf.Bytes(), nil
+}
+
+// post makes a single attempt to deliver a payload
+func (n *Notifier) post(ctx context.Context, event string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %v", errPermanent, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "url-datadog-monitor")
+	req.Header.Set(EventHeader, event)
+	for k, v := range n.config.Headers {
+		req.Header.Set(k, v)
+	}
+	if n.secret != "" {
+		req.Header.Set(SignatureHeader, Sign(n.secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("webhook responded with status code %d", resp.StatusCode)
+	default:
+		return fmt.Errorf("%w: webhook responded with status code %d", errPermanent, resp.StatusCode)
+	}
+}
+
+// Sign returns the signature of a payload: "sha256=" followed by its hex HMAC-SHA256
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// toJSON encodes a value for templates, so strings are quoted and escaped
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
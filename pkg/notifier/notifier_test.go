@@ -0,0 +1,264 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+)
+
+// receiver is a webhook recording the notifications it receives
+type receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	bodies   [][]byte
+	requests []*http.Request
+	// codes are the status codes of the next responses, 200 once exhausted
+	codes []int
+}
+
+func newReceiver(t *testing.T, codes ...int) *receiver {
+	t.Helper()
+
+	r := &receiver{codes: codes}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.bodies = append(r.bodies, body)
+		r.requests = append(r.requests, req)
+		code := http.StatusOK
+		if len(r.codes) > 0 {
+			code, r.codes = r.codes[0], r.codes[1:]
+		}
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// transitions decodes the default payloads received
+func (r *receiver) transitions(t *testing.T) []Transition {
+	t.Helper()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var transitions []Transition
+	for _, body := range r.bodies {
+		var transition Transition
+		if err := json.Unmarshal(body, &transition); err != nil {
+			t.Fatalf("Failed to decode payload %s: %v", body, err)
+		}
+		transitions = append(transitions, transition)
+	}
+	return transitions
+}
+
+// newTestDispatcher creates a dispatcher for the notifiers, retrying without delay
+func newTestDispatcher(t *testing.T, notifiers ...config.Notifier) *Dispatcher {
+	t.Helper()
+
+	for i := range notifiers {
+		if notifiers[i].Timeout == 0 {
+			notifiers[i].Timeout = 5
+		}
+	}
+	d, err := NewDispatcher(notifiers, slog.New(slog.NewJSONHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("Failed to create dispatcher: %v", err)
+	}
+	t.Cleanup(d.Close)
+	for _, n := range d.notifiers {
+		n.backoff = time.Millisecond
+	}
+	return d
+}
+
+func retries(n int) *int {
+	return &n
+}
+
+func TestDispatcher_CheckTransitions(t *testing.T) {
+	webhook := newReceiver(t)
+	d := newTestDispatcher(t, config.Notifier{Name: "ops", URL: webhook.URL})
+	target := config.Target{Name: "API", URL: "https://api.example.com", Labels: map[string]string{"env": "prod"}}
+
+	start := time.Now()
+	d.ObserveCheck("API", target, Check{Time: start, Down: true, Error: "connection refused", Message: "https://api.example.com is down: connection refused"})
+	d.Wait()
+	d.ObserveCheck("API", target, Check{Time: start.Add(time.Minute), Down: true})
+	d.ObserveCheck("API", target, Check{Time: start.Add(3 * time.Minute), StatusCode: 200})
+	d.ObserveCheck("API", target, Check{Time: start.Add(4 * time.Minute), StatusCode: 200})
+	d.Wait()
+
+	transitions := webhook.transitions(t)
+	if len(transitions) != 2 {
+		t.Fatalf("Expected a down and a recovered notification, got %+v", transitions)
+	}
+	down, recovered := transitions[0], transitions[1]
+	if down.Event != config.NotifierEventDown || down.Target != "API" || down.Error != "connection refused" || down.Labels["env"] != "prod" {
+		t.Errorf("Expected a down notification for API, got %+v", down)
+	}
+	if recovered.Event != config.NotifierEventRecovered || recovered.Downtime != "3m0s" {
+		t.Errorf("Expected a recovered notification after 3m0s, got %+v", recovered)
+	}
+	if event := webhook.requests[0].Header.Get(EventHeader); event != config.NotifierEventDown {
+		t.Errorf("Expected the event header to be down, got %q", event)
+	}
+}
+
+func TestDispatcher_CertificateTransitions(t *testing.T) {
+	webhook := newReceiver(t)
+	d := newTestDispatcher(t, config.Notifier{URL: webhook.URL})
+	target := config.Target{Name: "API", URL: "https://api.example.com"}
+
+	now := time.Now()
+	d.ObserveCertificate("API", target, Certificate{Time: now, Valid: true, NotAfter: now.Add(60 * 24 * time.Hour)})
+	d.ObserveCertificate("API", target, Certificate{Time: now, Valid: true, NotAfter: now.Add(10 * 24 * time.Hour)})
+	d.ObserveCertificate("API", target, Certificate{Time: now, Valid: true, NotAfter: now.Add(9 * 24 * time.Hour)})
+	d.ObserveCertificate("API", target, Certificate{Time: now, Valid: false, Error: "unknown authority"})
+	d.Wait()
+
+	transitions := webhook.transitions(t)
+	if len(transitions) != 2 {
+		t.Fatalf("Expected an expiring and an invalid notification, got %+v", transitions)
+	}
+	if transitions[0].Event != config.NotifierEventCertExpiring || transitions[0].DaysUntilExpiry == nil {
+		t.Errorf("Expected a cert_expiring notification with the days until expiry, got %+v", transitions[0])
+	}
+	if transitions[1].Event != config.NotifierEventCertInvalid || transitions[1].Message != "Certificate for https://api.example.com is invalid: unknown authority" {
+		t.Errorf("Expected a cert_invalid notification, got %+v", transitions[1])
+	}
+}
+
+func TestDispatcher_Filters(t *testing.T) {
+	prod := newReceiver(t)
+	recoveries := newReceiver(t)
+	d := newTestDispatcher(t,
+		config.Notifier{Name: "prod", URL: prod.URL, Labels: map[string]string{"env": "prod"}},
+		config.Notifier{Name: "recoveries", URL: recoveries.URL, Events: []string{config.NotifierEventRecovered}},
+	)
+
+	now := time.Now()
+	staging := config.Target{Name: "Staging", URL: "https://staging.example.com", Labels: map[string]string{"env": "staging"}}
+	d.ObserveCheck("Staging", staging, Check{Time: now, Down: true})
+	d.ObserveCheck("Staging", staging, Check{Time: now.Add(time.Minute)})
+	d.Wait()
+
+	if got := len(prod.transitions(t)); got != 0 {
+		t.Errorf("Expected no notification for a staging target, got %d", got)
+	}
+	if got := recoveries.transitions(t); len(got) != 1 || got[0].Event != config.NotifierEventRecovered {
+		t.Errorf("Expected only the recovery, got %+v", got)
+	}
+}
+
+func TestNotifier_SignedTemplate(t *testing.T) {
+	webhook := newReceiver(t)
+	d := newTestDispatcher(t, config.Notifier{
+		URL:      webhook.URL,
+		Secret:   "s3cret",
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+		Template: `{"text": {{json .Message}}, "event": {{json .Event}}}`,
+	})
+
+	d.Notify(Transition{Event: config.NotifierEventDown, Target: "API", Message: `API is "down"`})
+	d.Wait()
+
+	body, req := webhook.bodies[0], webhook.requests[0]
+	var payload map[string]string
+	if err := json.Unmarshal(body, &payload); err != nil || payload["text"] != `API is "down"` || payload["event"] != "down" {
+		t.Errorf("Expected the rendered template, got %s", body)
+	}
+	if signature := req.Header.Get(SignatureHeader); signature != Sign("s3cret", body) {
+		t.Errorf("Expected the payload to be signed, got %q", signature)
+	}
+	if req.Header.Get("Authorization") != "Bearer token" {
+		t.Errorf("Expected the configured headers, got %v", req.Header)
+	}
+}
+
+func TestNotifier_Retries(t *testing.T) {
+	for name, tc := range map[string]struct {
+		codes    []int
+		retries  int
+		attempts int
+		failed   bool
+	}{
+		"recovers after errors":   {codes: []int{500, 503}, retries: 3, attempts: 3},
+		"gives up after retries":  {codes: []int{500, 500, 500}, retries: 1, attempts: 2, failed: true},
+		"rate limited":            {codes: []int{429}, retries: 1, attempts: 2},
+		"client errors are final": {codes: []int{400}, retries: 3, attempts: 1, failed: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			webhook := newReceiver(t, tc.codes...)
+			n, err := New(config.Notifier{URL: webhook.URL, Retries: retries(tc.retries), Timeout: 5})
+			if err != nil {
+				t.Fatalf("Failed to create notifier: %v", err)
+			}
+			n.backoff = time.Millisecond
+
+			err = n.Send(context.Background(), Transition{Event: config.NotifierEventDown})
+			if (err != nil) != tc.failed {
+				t.Errorf("Expected failure %v, got %v", tc.failed, err)
+			}
+			if got := len(webhook.bodies); got != tc.attempts {
+				t.Errorf("Expected %d attempts, got %d", tc.attempts, got)
+			}
+		})
+	}
+}
+
+func TestNew_InvalidTemplate(t *testing.T) {
+	for name, tmpl := range map[string]string{
+		"syntax":   `{"text": {{.Message}`,
+		"not JSON": `text: {{.Message}}`,
+	} {
+		if _, err := New(config.Notifier{Name: name, URL: "https://hooks.example.com", Template: tmpl}); err == nil {
+			t.Errorf("Expected an error for a %s error", name)
+		}
+	}
+}
+
+func TestDispatcher_Close(t *testing.T) {
+	webhook := newReceiver(t)
+	d := newTestDispatcher(t, config.Notifier{URL: webhook.URL})
+
+	d.Notify(Transition{Event: config.NotifierEventDown, Target: "API"})
+	d.Close()
+	if got := len(webhook.transitions(t)); got != 1 {
+		t.Errorf("Expected the queued notification to be delivered on close, got %d", got)
+	}
+
+	// Checks still running during shutdown must not panic
+	d.Notify(Transition{Event: config.NotifierEventRecovered, Target: "API"})
+	d.Close()
+}
+
+func TestDispatcher_CloseDeadline(t *testing.T) {
+	webhook := newReceiver(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	d := newTestDispatcher(t, config.Notifier{URL: webhook.URL, Retries: retries(3)})
+	d.notifiers[0].backoff = time.Hour
+	d.drainTimeout = 50 * time.Millisecond
+
+	d.Notify(Transition{Event: config.NotifierEventDown, Target: "API"})
+	d.Notify(Transition{Event: config.NotifierEventRecovered, Target: "API"})
+
+	start := time.Now()
+	d.Close()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected Close to cancel the retries after the drain timeout, took %s", elapsed)
+	}
+	if got := len(webhook.transitions(t)); got != 1 {
+		t.Errorf("Expected a single attempt before the retry was canceled, got %d", got)
+	}
+}
@@ -0,0 +1,342 @@
+package slo
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+)
+
+const (
+	// MinuteRetention is how long per-minute counts are kept, covering the burn rate windows.
+	// Longer windows are counted from hourly buckets.
+	MinuteRetention = 6 * time.Hour
+
+	minute = int64(time.Minute / time.Second)
+	hour   = int64(time.Hour / time.Second)
+)
+
+// Counts are the good and total checks over a window
+type Counts struct {
+	Good  int64
+	Total int64
+}
+
+// Attainment returns the percentage of good checks, 100 without checks
+func (c Counts) Attainment() float64 {
+	if c.Total == 0 {
+		return 100
+	}
+	return float64(c.Good) * 100 / float64(c.Total)
+}
+
+// BurnRate returns how fast the error budget of the objective is spent: 1 spends exactly
+// the budget over the SLO window, 0 without checks
+func (c Counts) BurnRate(objective float64) float64 {
+	if c.Total == 0 {
+		return 0
+	}
+	errorRate := float64(c.Total-c.Good) / float64(c.Total)
+	return errorRate / (1 - objective/100)
+}
+
+// ErrorBudgetRemaining returns the percentage of the error budget of the objective left,
+// negative once it is exhausted
+func (c Counts) ErrorBudgetRemaining(objective float64) float64 {
+	return (1 - c.BurnRate(objective)) * 100
+}
+
+// Buckets count checks in consecutive buckets of the same length, the first starting at Start
+type Buckets struct {
+	// Start is the unix time of the first bucket
+	Start int64   `json:"start"`
+	Good  []int64 `json:"good"`
+	Total []int64 `json:"total"`
+}
+
+// add counts a check in the bucket of the given unix time
+func (b *Buckets) add(at, size int64, good bool) {
+	start := at - at%size
+	if len(b.Total) == 0 || start < b.Start {
+		// Checks older than the first bucket only happen when the clock goes back
+		b.Start, b.Good, b.Total = start, nil, nil
+	}
+
+	i := int((start - b.Start) / size)
+	for len(b.Total) <= i {
+		b.Good = append(b.Good, 0)
+		b.Total = append(b.Total, 0)
+	}
+	b.Total[i]++
+	if good {
+		b.Good[i]++
+	}
+}
+
+// prune drops the buckets that ended before the cutoff
+func (b *Buckets) prune(cutoff, size int64) {
+	drop := 0
+	for drop < len(b.Total) && b.Start+int64(drop+1)*size <= cutoff {
+		drop++
+	}
+	b.Start += int64(drop) * size
+	b.Good, b.Total = b.Good[drop:], b.Total[drop:]
+}
+
+// count sums the buckets ending after the given unix time
+func (b Buckets) count(since, size int64) Counts {
+	var counts Counts
+	for i := range b.Total {
+		if b.Start+int64(i+1)*size > since {
+			counts.Good += b.Good[i]
+			counts.Total += b.Total[i]
+		}
+	}
+	return counts
+}
+
+// Series holds the check counts of one target, per minute for the recent past and per hour
+// over the whole SLO window, and the burn rate alerts firing after the last check
+type Series struct {
+	Minutes Buckets  `json:"minutes"`
+	Hours   Buckets  `json:"hours"`
+	Firing  []string `json:"firing,omitempty"`
+}
+
+// isFiring reports whether the named alert was firing after the last check
+func (s Series) isFiring(name string) bool {
+	for _, firing := range s.Firing {
+		if firing == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Record counts a check and drops the counts older than the retention
+func (s *Series) Record(at time.Time, good bool, retention time.Duration) {
+	unix := at.Unix()
+
+	// Pruning first keeps a check long after the last one, like after restoring an old
+	// snapshot, from filling the gap with empty buckets only to drop them again
+	s.Minutes.prune(unix-int64(MinuteRetention/time.Second), minute)
+	s.Hours.prune(unix-int64(retention/time.Second), hour)
+
+	s.Minutes.add(unix, minute, good)
+	s.Hours.add(unix, hour, good)
+}
+
+// Counts returns the checks over the window before now. Windows up to MinuteRetention are
+// counted per minute, longer ones per hour, and the bucket the window starts in is counted
+// whole, so they may include up to a minute or an hour more.
+func (s Series) Counts(now time.Time, window time.Duration) Counts {
+	since := now.Add(-window).Unix()
+	if window <= MinuteRetention {
+		return s.Minutes.count(since, minute)
+	}
+	return s.Hours.count(since, hour)
+}
+
+// Objective is a parsed SLO
+type Objective struct {
+	Objective float64
+	Window    time.Duration
+	Alerts    []Alert
+}
+
+// Alert is a parsed burn rate alert
+type Alert struct {
+	LongWindow  time.Duration
+	ShortWindow time.Duration
+	Threshold   float64
+}
+
+// Name identifies the alert by its windows, like "1h/5m"
+func (a Alert) Name() string {
+	return FormatWindow(a.LongWindow) + "/" + FormatWindow(a.ShortWindow)
+}
+
+// ParseObjective parses a validated SLO
+func ParseObjective(slo config.SLO) (Objective, error) {
+	window, err := config.ParseWindow(slo.Window)
+	if err != nil {
+		return Objective{}, err
+	}
+
+	objective := Objective{Objective: slo.Objective, Window: window}
+	for _, alert := range slo.BurnRateAlerts {
+		long, err := config.ParseWindow(alert.LongWindow)
+		if err != nil {
+			return Objective{}, err
+		}
+		short, err := config.ParseWindow(alert.ShortWindow)
+		if err != nil {
+			return Objective{}, err
+		}
+		objective.Alerts = append(objective.Alerts, Alert{LongWindow: long, ShortWindow: short, Threshold: alert.Threshold})
+	}
+	return objective, nil
+}
+
+// BurnRateWindows returns the distinct windows of the alerts, shortest first
+func (o Objective) BurnRateWindows() []time.Duration {
+	seen := make(map[time.Duration]bool)
+	var windows []time.Duration
+	for _, alert := range o.Alerts {
+		for _, window := range []time.Duration{alert.ShortWindow, alert.LongWindow} {
+			if !seen[window] {
+				seen[window] = true
+				windows = append(windows, window)
+			}
+		}
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i] < windows[j] })
+	return windows
+}
+
+// FormatWindow formats a window in days when it is a whole number of days, as a Go
+// duration without zero units otherwise
+func FormatWindow(window time.Duration) string {
+	if window >= 24*time.Hour && window%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", window/(24*time.Hour))
+	}
+	switch {
+	case window%time.Hour == 0:
+		return fmt.Sprintf("%dh", window/time.Hour)
+	case window%time.Minute == 0:
+		return fmt.Sprintf("%dm", window/time.Minute)
+	default:
+		return window.String()
+	}
+}
+
+// Report is the state of an objective after a check
+type Report struct {
+	Counts               Counts
+	Attainment           float64
+	ErrorBudgetRemaining float64
+	// BurnRates are the burn rates over the alert windows
+	BurnRates map[time.Duration]float64
+	// Firing lists the alerts firing after this check, Fired and Resolved the alerts that
+	// started and stopped firing with it
+	Firing   []Alert
+	Fired    []Alert
+	Resolved []Alert
+}
+
+// Tracker records the checks of targets and evaluates their objectives. It is safe for
+// concurrent use.
+type Tracker struct {
+	mu     sync.Mutex
+	series map[string]*Series
+}
+
+// NewTracker creates a new empty Tracker
+func NewTracker() *Tracker {
+	return &Tracker{
+		series: make(map[string]*Series),
+	}
+}
+
+// Record counts a check of the target identified by key and evaluates its objective
+func (t *Tracker) Record(key string, objective Objective, at time.Time, good bool) Report {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	series, ok := t.series[key]
+	if !ok {
+		series = &Series{}
+		t.series[key] = series
+	}
+	series.Record(at, good, objective.Window)
+
+	counts := series.Counts(at, objective.Window)
+	report := Report{
+		Counts:               counts,
+		Attainment:           counts.Attainment(),
+		ErrorBudgetRemaining: counts.ErrorBudgetRemaining(objective.Objective),
+		BurnRates:            make(map[time.Duration]float64),
+	}
+	for _, window := range objective.BurnRateWindows() {
+		report.BurnRates[window] = series.Counts(at, window).BurnRate(objective.Objective)
+	}
+
+	var firing []string
+	for _, alert := range objective.Alerts {
+		wasFiring := series.isFiring(alert.Name())
+		if report.BurnRates[alert.LongWindow] > alert.Threshold && report.BurnRates[alert.ShortWindow] > alert.Threshold {
+			firing = append(firing, alert.Name())
+			report.Firing = append(report.Firing, alert)
+			if !wasFiring {
+				report.Fired = append(report.Fired, alert)
+			}
+		} else if wasFiring {
+			report.Resolved = append(report.Resolved, alert)
+		}
+	}
+	series.Firing = firing
+	return report
+}
+
+// Series returns a copy of the counts recorded for a target
+func (t *Tracker) Series(key string) (Series, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	series, ok := t.series[key]
+	if !ok {
+		return Series{}, false
+	}
+	copied := *series
+	copied.Minutes.Good = append([]int64(nil), series.Minutes.Good...)
+	copied.Minutes.Total = append([]int64(nil), series.Minutes.Total...)
+	copied.Hours.Good = append([]int64(nil), series.Hours.Good...)
+	copied.Hours.Total = append([]int64(nil), series.Hours.Total...)
+	copied.Firing = append([]string(nil), series.Firing...)
+	return copied, true
+}
+
+// Seed restores the counts of a target that hasn't been recorded yet
+func (t *Tracker) Seed(key string, series Series) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.series[key]; !ok && len(series.Hours.Total) > 0 {
+		t.series[key] = &series
+	}
+}
+
+// Forget drops the counts and alert states of a target
+func (t *Tracker) Forget(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.series, key)
+}
+
+// Snapshot returns a copy of the counts and alert states of all targets
+func (t *Tracker) Snapshot() map[string]Series {
+	t.mu.Lock()
+	keys := make([]string, 0, len(t.series))
+	for key := range t.series {
+		keys = append(keys, key)
+	}
+	t.mu.Unlock()
+
+	snapshot := make(map[string]Series, len(keys))
+	for _, key := range keys {
+		if series, ok := t.Series(key); ok {
+			snapshot[key] = series
+		}
+	}
+	return snapshot
+}
+
+// Restore seeds the counts of a snapshot, keeping those of targets recorded since
+func (t *Tracker) Restore(snapshot map[string]Series) {
+	for key, series := range snapshot {
+		t.Seed(key, series)
+	}
+}
@@ -0,0 +1,148 @@
+package slo
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+)
+
+func TestCounts(t *testing.T) {
+	counts := Counts{Good: 995, Total: 1000}
+	if counts.Attainment() != 99.5 {
+		t.Errorf("Expected an attainment of 99.5, got %v", counts.Attainment())
+	}
+	if burnRate := counts.BurnRate(99); math.Abs(burnRate-0.5) > 1e-9 {
+		t.Errorf("Expected a burn rate of 0.5, got %v", burnRate)
+	}
+	if remaining := counts.ErrorBudgetRemaining(99); math.Abs(remaining-50) > 1e-9 {
+		t.Errorf("Expected 50%% of the budget left, got %v", remaining)
+	}
+	if remaining := (Counts{Good: 90, Total: 100}).ErrorBudgetRemaining(99); remaining >= 0 {
+		t.Errorf("Expected an exhausted budget to be negative, got %v", remaining)
+	}
+	if (Counts{}).Attainment() != 100 || (Counts{}).BurnRate(99) != 0 {
+		t.Errorf("Expected no checks to count as attained without burn")
+	}
+}
+
+func TestSeries_Counts(t *testing.T) {
+	start := time.Unix(1700000000, 0).Truncate(time.Hour)
+	var series Series
+	for i := 0; i < 48*60; i++ {
+		series.Record(start.Add(time.Duration(i)*time.Minute), i%10 != 0, 30*24*time.Hour)
+	}
+	now := start.Add(48*time.Hour - time.Minute)
+
+	// The bucket the window starts in is counted whole
+	if counts := series.Counts(now, 5*time.Minute); counts.Total != 6 {
+		t.Errorf("Expected 6 checks over 5 minutes, got %+v", counts)
+	}
+	if counts := series.Counts(now, 24*time.Hour); counts.Total < 24*60 || counts.Total > 25*60 {
+		t.Errorf("Expected about a day of checks, got %+v", counts)
+	}
+	if counts := series.Counts(now, 30*24*time.Hour); counts.Total != 48*60 || counts.Good != 48*54 {
+		t.Errorf("Expected all checks in the SLO window, got %+v", counts)
+	}
+	if len(series.Minutes.Total) > int(MinuteRetention/time.Minute)+1 {
+		t.Errorf("Expected minute buckets to be pruned after %s, got %d", MinuteRetention, len(series.Minutes.Total))
+	}
+
+	series.Record(now.Add(3*time.Hour), true, 2*time.Hour)
+	if len(series.Hours.Total) > 3 {
+		t.Errorf("Expected hour buckets beyond the retention to be pruned, got %d", len(series.Hours.Total))
+	}
+}
+
+func TestSeries_RecordAfterGap(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	var series Series
+	series.Record(start, false, 30*24*time.Hour)
+
+	// A check long after the last one starts the series over without filling the gap
+	series.Record(start.Add(365*24*time.Hour), true, 30*24*time.Hour)
+	if cap(series.Minutes.Total) > 2 || cap(series.Hours.Total) > 2 {
+		t.Errorf("Expected the gap not to be filled, got capacities %d and %d",
+			cap(series.Minutes.Total), cap(series.Hours.Total))
+	}
+	if counts := series.Counts(start.Add(365*24*time.Hour), 30*24*time.Hour); counts.Total != 1 || counts.Good != 1 {
+		t.Errorf("Expected only the last check, got %+v", counts)
+	}
+}
+
+func TestTracker_BurnRateAlerts(t *testing.T) {
+	objective, err := ParseObjective(config.SLO{Objective: 99, Window: "30d", BurnRateAlerts: config.DefaultBurnRateAlerts})
+	if err != nil {
+		t.Fatalf("Failed to parse objective: %v", err)
+	}
+
+	tracker := NewTracker()
+	start := time.Unix(1700000000, 0)
+	var fired, resolved []string
+	var last Report
+	for i := 0; i < 120; i++ {
+		// An hour of good checks, a 15 minute outage and recovery
+		good := i < 60 || i >= 75
+		last = tracker.Record("api", objective, start.Add(time.Duration(i)*time.Minute), good)
+		for _, alert := range last.Fired {
+			fired = append(fired, alert.Name())
+		}
+		for _, alert := range last.Resolved {
+			resolved = append(resolved, alert.Name())
+		}
+	}
+
+	// The outage burns 20x the budget over 6h and 100x over 1h, both alerts fire
+	if len(fired) != 2 || fired[0] != "6h/30m" || fired[1] != "1h/5m" {
+		t.Errorf("Expected the slow then the fast burn alert to fire once, got %v", fired)
+	}
+	if len(resolved) != 2 || resolved[0] != "1h/5m" || resolved[1] != "6h/30m" {
+		t.Errorf("Expected the fast then the slow burn alert to resolve once, got %v", resolved)
+	}
+	if len(last.Firing) != 0 || last.Counts.Total != 120 || last.Counts.Good != 105 {
+		t.Errorf("Expected 105 good checks of 120 and no alert firing, got %+v", last)
+	}
+	if len(last.BurnRates) != 4 {
+		t.Errorf("Expected burn rates over 4 windows, got %v", last.BurnRates)
+	}
+
+	tracker.Forget("api")
+	if _, ok := tracker.Series("api"); ok {
+		t.Errorf("Expected the series to be forgotten")
+	}
+}
+
+func TestTracker_Restore(t *testing.T) {
+	objective := Objective{Objective: 99.9, Window: 24 * time.Hour}
+	tracker := NewTracker()
+	now := time.Now()
+	tracker.Record("api", objective, now, true)
+	tracker.Record("api", objective, now, false)
+
+	restored := NewTracker()
+	restored.Record("web", objective, now, true)
+	restored.Restore(map[string]Series{"web": {}})
+	restored.Restore(tracker.Snapshot())
+
+	if report := restored.Record("api", objective, now, true); report.Counts.Total != 3 || report.Counts.Good != 2 {
+		t.Errorf("Expected the snapshot checks to be restored, got %+v", report.Counts)
+	}
+	if series, _ := restored.Series("web"); series.Hours.Total[0] != 1 {
+		t.Errorf("Expected checks recorded before the restore to be kept, got %+v", series)
+	}
+}
+
+func TestFormatWindow(t *testing.T) {
+	for window, expected := range map[time.Duration]string{
+		30 * 24 * time.Hour: "30d",
+		36 * time.Hour:      "36h",
+		time.Hour:           "1h",
+		5 * time.Minute:     "5m",
+		90 * time.Second:    "1m30s",
+	} {
+		if formatted := FormatWindow(window); formatted != expected {
+			t.Errorf("Expected %s to be formatted as %s, got %s", window, expected, formatted)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package state
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// MaxConfigMapSize is the most data the API server accepts in a ConfigMap
+const MaxConfigMapSize = 1 << 20
+
+// ErrTooLarge is returned when snapshots don't fit in the store
+var ErrTooLarge = errors.New("state too large for the store")
+
+// ConfigMapStore keeps each snapshot as a gzip compressed JSON entry of a ConfigMap, created
+// by the first save. ConfigMaps hold at most MaxConfigMapSize. A monitor takes about 100 bytes,
+// or 350 with a 30 day SLO, so the state of a few thousand monitors fits.
+type ConfigMapStore struct {
+	// Client writes the ConfigMap
+	Client client.Client
+	// Reader reads the ConfigMap, the client when nil. Pass an uncached reader to avoid
+	// caching every ConfigMap of the cluster.
+	Reader client.Reader
+
+	Namespace string
+	Name      string
+}
+
+// NewConfigMapStore creates a store backed by the ConfigMap namespace/name
+func NewConfigMapStore(c client.Client, reader client.Reader, namespace, name string) *ConfigMapStore {
+	return &ConfigMapStore{Client: c, Reader: reader, Namespace: namespace, Name: name}
+}
+
+// Load decodes the snapshot saved under name into value
+func (s *ConfigMapStore) Load(ctx context.Context, name string, value any) error {
+	configMap, err := s.get(ctx)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read state ConfigMap: %w", err)
+	}
+
+	data, err := snapshotData(configMap, name)
+	if err != nil {
+		return fmt.Errorf("failed to decompress %s from ConfigMap %s/%s: %w", name, s.Namespace, s.Name, err)
+	}
+	if data == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("failed to decode %s from ConfigMap %s/%s: %w", name, s.Namespace, s.Name, err)
+	}
+	return nil
+}
+
+// Save replaces the snapshots saved under the names of snapshots in a single update, creating
+// the ConfigMap when needed. Updates are retried on conflicts, since several reconcilers save
+// to the same ConfigMap. ErrTooLarge is returned when the ConfigMap would exceed
+// MaxConfigMapSize, leaving it unchanged.
+func (s *ConfigMapStore) Save(ctx context.Context, snapshots map[string]any) error {
+	encoded := make(map[string][]byte, len(snapshots))
+	for name, value := range snapshots {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", name, err)
+		}
+		if encoded[name], err = compress(data); err != nil {
+			return fmt.Errorf("failed to compress %s: %w", name, err)
+		}
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := s.get(ctx)
+		if apierrors.IsNotFound(err) {
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: s.Namespace, Name: s.Name},
+				BinaryData: encoded,
+			}
+			if err := checkSize(configMap); err != nil {
+				return err
+			}
+			if err := s.Client.Create(ctx, configMap); err != nil {
+				if apierrors.IsAlreadyExists(err) {
+					// Created by another reconciler in the meantime, retry as an update
+					return apierrors.NewConflict(corev1.Resource("configmaps"), s.Name, err)
+				}
+				return fmt.Errorf("failed to create state ConfigMap: %w", err)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read state ConfigMap: %w", err)
+		}
+
+		if configMap.BinaryData == nil {
+			configMap.BinaryData = make(map[string][]byte, len(encoded))
+		}
+		for name, data := range encoded {
+			// Drop the uncompressed entry written by earlier versions
+			delete(configMap.Data, name)
+			configMap.BinaryData[name] = data
+		}
+		if err := checkSize(configMap); err != nil {
+			return err
+		}
+		return s.Client.Update(ctx, configMap)
+	})
+}
+
+// get reads the ConfigMap
+func (s *ConfigMapStore) get(ctx context.Context) (*corev1.ConfigMap, error) {
+	reader := s.Reader
+	if reader == nil {
+		reader = s.Client
+	}
+
+	configMap := &corev1.ConfigMap{}
+	err := reader.Get(ctx, client.ObjectKey{Namespace: s.Namespace, Name: s.Name}, configMap)
+	return configMap, err
+}
+
+// snapshotData returns the JSON saved under name, nil when nothing was saved. Entries saved
+// uncompressed by earlier versions are read from the data of the ConfigMap.
+func snapshotData(configMap *corev1.ConfigMap, name string) ([]byte, error) {
+	if data, ok := configMap.BinaryData[name]; ok {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return io.ReadAll(reader)
+	}
+	if data, ok := configMap.Data[name]; ok {
+		return []byte(data), nil
+	}
+	return nil, nil
+}
+
+// compress gzip compresses data
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// checkSize returns ErrTooLarge when the data of a ConfigMap exceeds MaxConfigMapSize
+func checkSize(configMap *corev1.ConfigMap) error {
+	size := 0
+	for key, value := range configMap.Data {
+		size += len(key) + len(value)
+	}
+	for key, value := range configMap.BinaryData {
+		size += len(key) + len(value)
+	}
+	if size > MaxConfigMapSize {
+		return fmt.Errorf("%w: ConfigMap %s/%s would hold %d bytes, more than %d", ErrTooLarge, configMap.Namespace, configMap.Name, size, MaxConfigMapSize)
+	}
+	return nil
+}
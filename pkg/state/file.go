@@ -0,0 +1,117 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileStore keeps all snapshots in a single JSON file, rewritten atomically on every save
+type FileStore struct {
+	path string
+
+	mu        sync.Mutex
+	snapshots map[string]json.RawMessage
+}
+
+// NewFileStore creates a store backed by the file at path, read on first use. A missing file
+// is created by the first save.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Load decodes the snapshot saved under name into value
+func (s *FileStore) Load(_ context.Context, name string, value any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.read(); err != nil {
+		return err
+	}
+	data, ok := s.snapshots[name]
+	if !ok {
+		return nil
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("failed to decode %s from %s: %w", name, s.path, err)
+	}
+	return nil
+}
+
+// Save replaces the snapshots saved under the names of snapshots and rewrites the file once
+func (s *FileStore) Save(_ context.Context, snapshots map[string]any) error {
+	encoded := make(map[string]json.RawMessage, len(snapshots))
+	for name, value := range snapshots {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", name, err)
+		}
+		encoded[name] = data
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.read(); err != nil {
+		return err
+	}
+	for name, data := range encoded {
+		s.snapshots[name] = data
+	}
+	return s.write()
+}
+
+// read loads the file unless it was already loaded
+func (s *FileStore) read() error {
+	if s.snapshots != nil {
+		return nil
+	}
+
+	snapshots := make(map[string]json.RawMessage)
+	data, err := os.ReadFile(s.path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return fmt.Errorf("failed to read state file: %w", err)
+	default:
+		if err := json.Unmarshal(data, &snapshots); err != nil {
+			return fmt.Errorf("failed to decode state file %s: %w", s.path, err)
+		}
+	}
+	s.snapshots = snapshots
+	return nil
+}
+
+// write replaces the file with the current snapshots through a temporary file, synced before
+// it is renamed over the file, so a crash never leaves it half written
+func (s *FileStore) write() error {
+	data, err := json.Marshal(s.snapshots)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
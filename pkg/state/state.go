@@ -0,0 +1,21 @@
+package state
+
+import "context"
+
+// Names of the snapshots of the state carried over between checks
+const (
+	NextChecks    = "next_checks"
+	Serials       = "serials"
+	ContentHashes = "content_hashes"
+	SLOs          = "slos"
+)
+
+// Store persists named snapshots of monitor state, encoded as JSON
+type Store interface {
+	// Load decodes the snapshot saved under name into value, leaving it untouched when
+	// nothing was saved yet
+	Load(ctx context.Context, name string, value any) error
+	// Save replaces the snapshots saved under the names of snapshots in a single write,
+	// keeping the others
+	Save(ctx context.Context, snapshots map[string]any) error
+}
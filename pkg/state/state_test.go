@@ -0,0 +1,265 @@
+package state
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+	"github.com/kuskoman/url-datadog-monitor/pkg/slo"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	store := NewFileStore(path)
+	serials := map[string]string{"api": "01"}
+	if err := store.Load(ctx, Serials, &serials); err != nil || serials["api"] != "01" {
+		t.Fatalf("Expected a missing file to leave the value untouched, got %v and %v", serials, err)
+	}
+	err := store.Save(ctx, map[string]any{
+		Serials:       map[string]string{"api": "01"},
+		ContentHashes: map[string]string{"status": "abc"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+	// Saving some snapshots keeps the others
+	if err := store.Save(ctx, map[string]any{Serials: map[string]string{"api": "02"}}); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("Expected only the state file to be left, got %d files", len(entries))
+	}
+
+	// A new store reads what the previous process saved
+	reopened := NewFileStore(path)
+	if err := reopened.Load(ctx, Serials, &serials); err != nil || serials["api"] != "02" {
+		t.Errorf("Expected the saved serial, got %v and %v", serials, err)
+	}
+	hashes := make(map[string]string)
+	if err := reopened.Load(ctx, ContentHashes, &hashes); err != nil || hashes["status"] != "abc" {
+		t.Errorf("Expected the saved content hash, got %v and %v", hashes, err)
+	}
+
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := NewFileStore(path).Load(ctx, Serials, &serials); err == nil {
+		t.Errorf("Expected an error for a corrupt state file")
+	}
+}
+
+func TestConfigMapStore(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	store := NewConfigMapStore(c, nil, "monitoring", "url-monitor-state")
+	serials := make(map[string]string)
+	if err := store.Load(ctx, Serials, &serials); err != nil || len(serials) != 0 {
+		t.Fatalf("Expected a missing ConfigMap to leave the value untouched, got %v and %v", serials, err)
+	}
+
+	err := store.Save(ctx, map[string]any{
+		Serials: map[string]string{"default/api": "01"},
+		SLOs:    map[string]int{"default/api": 1},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+	if err := store.Save(ctx, map[string]any{Serials: map[string]string{"default/api": "02"}}); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "monitoring", Name: "url-monitor-state"}, configMap); err != nil {
+		t.Fatalf("Expected the ConfigMap to be created: %v", err)
+	}
+	if len(configMap.BinaryData) != 2 || len(configMap.Data) != 0 {
+		t.Errorf("Expected a compressed entry per snapshot, got %v and %v", configMap.BinaryData, configMap.Data)
+	}
+
+	if err := store.Load(ctx, Serials, &serials); err != nil || serials["default/api"] != "02" {
+		t.Errorf("Expected the saved serial, got %v and %v", serials, err)
+	}
+}
+
+func TestConfigMapStore_Uncompressed(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "monitoring", Name: "url-monitor-state"},
+		Data:       map[string]string{Serials: `{"default/api":"01"}`},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(configMap).Build()
+	store := NewConfigMapStore(c, nil, "monitoring", "url-monitor-state")
+
+	// Entries saved uncompressed by earlier versions are still read, and replaced on save
+	serials := make(map[string]string)
+	if err := store.Load(ctx, Serials, &serials); err != nil || serials["default/api"] != "01" {
+		t.Fatalf("Expected the uncompressed serial, got %v and %v", serials, err)
+	}
+	if err := store.Save(ctx, map[string]any{Serials: map[string]string{"default/api": "02"}}); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(configMap), configMap); err != nil {
+		t.Fatalf("Failed to get ConfigMap: %v", err)
+	}
+	if _, ok := configMap.Data[Serials]; ok {
+		t.Errorf("Expected the uncompressed entry to be dropped, got %v", configMap.Data)
+	}
+	if err := store.Load(ctx, Serials, &serials); err != nil || serials["default/api"] != "02" {
+		t.Errorf("Expected the saved serial, got %v and %v", serials, err)
+	}
+}
+
+func TestConfigMapStore_TooLarge(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	store := NewConfigMapStore(c, nil, "monitoring", "url-monitor-state")
+
+	// Random values don't compress
+	random := make([]byte, MaxConfigMapSize)
+	_, _ = rand.Read(random)
+	err := store.Save(ctx, map[string]any{ContentHashes: map[string][]byte{"default/api": random}})
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Expected ErrTooLarge, got %v", err)
+	}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "monitoring", Name: "url-monitor-state"}, &corev1.ConfigMap{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected no ConfigMap to be created, got %v", err)
+	}
+}
+
+// mapTracker is a Tracker keeping the values of a map
+type mapTracker map[string]string
+
+func (m mapTracker) Snapshot() map[string]string {
+	snapshot := make(map[string]string, len(m))
+	for key, value := range m {
+		snapshot[key] = value
+	}
+	return snapshot
+}
+
+func (m mapTracker) Restore(snapshot map[string]string) {
+	for key, value := range snapshot {
+		if _, ok := m[key]; !ok {
+			m[key] = value
+		}
+	}
+}
+
+func TestTrackers(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileStore(filepath.Join(t.TempDir(), "state.json"))
+
+	saved := Trackers{
+		Serials:       mapTracker{"api": "01"},
+		ContentHashes: mapTracker{"status": "abc"},
+		SLOs:          slo.NewTracker(),
+	}
+	if _, err := saved.Save(ctx, store, "urlmonitor."); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+
+	other := Trackers{Serials: mapTracker{}, ContentHashes: mapTracker{}, SLOs: slo.NewTracker()}
+	if err := other.Load(ctx, store, "clusterurlmonitor."); err != nil || len(other.Serials.Snapshot()) != 0 {
+		t.Errorf("Expected another prefix not to restore anything, got %v and %v", other.Serials.Snapshot(), err)
+	}
+
+	restored := Trackers{Serials: mapTracker{}, ContentHashes: mapTracker{"status": "def"}, SLOs: slo.NewTracker()}
+	if err := restored.Load(ctx, store, "urlmonitor."); err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if serials := restored.Serials.Snapshot(); serials["api"] != "01" {
+		t.Errorf("Expected the saved serial, got %v", serials)
+	}
+	if hashes := restored.ContentHashes.Snapshot(); hashes["status"] != "def" {
+		t.Errorf("Expected state recorded since to be kept, got %v", hashes)
+	}
+}
+
+// limitedStore is a FileStore rejecting saves with more than limit bytes of JSON
+type limitedStore struct {
+	*FileStore
+	limit int
+}
+
+func (s limitedStore) Save(ctx context.Context, snapshots map[string]any) error {
+	data, err := json.Marshal(snapshots)
+	if err != nil {
+		return err
+	}
+	if len(data) > s.limit {
+		return ErrTooLarge
+	}
+	return s.FileStore.Save(ctx, snapshots)
+}
+
+func TestTrackers_Trimmed(t *testing.T) {
+	ctx := context.Background()
+	slos := slo.NewTracker()
+	objective, err := slo.ParseObjective(config.SLO{Objective: 99, Window: "30d", BurnRateAlerts: config.DefaultBurnRateAlerts})
+	if err != nil {
+		t.Fatalf("Failed to parse objective: %v", err)
+	}
+	start := time.Unix(1700000000, 0)
+	for i := 0; i < 6*60; i++ {
+		slos.Record("api", objective, start.Add(time.Duration(i)*time.Minute), true)
+	}
+	trackers := Trackers{Serials: mapTracker{"api": "01"}, ContentHashes: mapTracker{}, SLOs: slos}
+
+	tests := map[string]struct {
+		limit   int
+		minutes bool
+		hours   bool
+	}{
+		"fits":            {limit: 1 << 20, minutes: true, hours: true},
+		"without minutes": {limit: 1000, hours: true},
+		"without slos":    {limit: 100},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := limitedStore{FileStore: NewFileStore(filepath.Join(t.TempDir(), "state.json")), limit: tt.limit}
+			trimmed, err := trackers.Save(ctx, store, "")
+			if err != nil {
+				t.Fatalf("Failed to save: %v", err)
+			}
+			if trimmed != !tt.minutes {
+				t.Errorf("Expected trimmed to be %v, got %v", !tt.minutes, trimmed)
+			}
+
+			saved := make(map[string]slo.Series)
+			if err := store.Load(ctx, SLOs, &saved); err != nil {
+				t.Fatalf("Failed to load: %v", err)
+			}
+			series, ok := saved["api"]
+			if ok != tt.hours || (len(series.Minutes.Total) > 0) != tt.minutes {
+				t.Errorf("Expected minute counts %v and hour counts %v, got %+v", tt.minutes, tt.hours, saved)
+			}
+
+			serials := make(map[string]string)
+			if err := store.Load(ctx, Serials, &serials); err != nil || serials["api"] != "01" {
+				t.Errorf("Expected the serial to be saved, got %v and %v", serials, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package state
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/slo"
+)
+
+// Tracker is in-memory state kept per target that can be snapshotted and restored
+type Tracker[T any] interface {
+	// Snapshot returns a copy of the state by target
+	Snapshot() map[string]T
+	// Restore seeds the state of a snapshot, keeping state recorded since
+	Restore(snapshot map[string]T)
+}
+
+// Trackers are the certificate serials, content hashes and SLO counts carried over between
+// checks
+type Trackers struct {
+	Serials       Tracker[string]
+	ContentHashes Tracker[string]
+	SLOs          Tracker[slo.Series]
+}
+
+// Snapshots returns the snapshots of the trackers, named after their kind with a prefix like
+// "urlmonitor."
+func (t Trackers) Snapshots(prefix string) map[string]any {
+	return map[string]any{
+		prefix + Serials:       t.Serials.Snapshot(),
+		prefix + ContentHashes: t.ContentHashes.Snapshot(),
+		prefix + SLOs:          t.SLOs.Snapshot(),
+	}
+}
+
+// Load restores the trackers from the snapshots saved under prefix
+func (t Trackers) Load(ctx context.Context, store Store, prefix string) error {
+	serials := make(map[string]string)
+	if err := store.Load(ctx, prefix+Serials, &serials); err != nil {
+		return err
+	}
+	contentHashes := make(map[string]string)
+	if err := store.Load(ctx, prefix+ContentHashes, &contentHashes); err != nil {
+		return err
+	}
+	slos := make(map[string]slo.Series)
+	if err := store.Load(ctx, prefix+SLOs, &slos); err != nil {
+		return err
+	}
+
+	t.Serials.Restore(serials)
+	t.ContentHashes.Restore(contentHashes)
+	t.SLOs.Restore(slos)
+	return nil
+}
+
+// Save saves the snapshots of the trackers under prefix in a single write. When they don't fit
+// in the store, the per-minute SLO counts are left out, then all SLO counts, and trimmed
+// reports that some were.
+func (t Trackers) Save(ctx context.Context, store Store, prefix string) (trimmed bool, err error) {
+	snapshots := t.Snapshots(prefix)
+	err = store.Save(ctx, snapshots)
+	if !errors.Is(err, ErrTooLarge) {
+		return false, err
+	}
+
+	slos := t.SLOs.Snapshot()
+	for key, series := range slos {
+		series.Minutes = slo.Buckets{}
+		slos[key] = series
+	}
+	snapshots[prefix+SLOs] = slos
+	if err = store.Save(ctx, snapshots); !errors.Is(err, ErrTooLarge) {
+		return err == nil, err
+	}
+
+	snapshots[prefix+SLOs] = map[string]slo.Series{}
+	err = store.Save(ctx, snapshots)
+	return err == nil, err
+}
@@ -0,0 +1,141 @@
+package statusapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/monitor"
+)
+
+const (
+	// CheckTimeout bounds how long a check triggered through the API may take, queueing included
+	CheckTimeout = 2 * time.Minute
+	// ShutdownTimeout is how long in-flight requests get to complete on shutdown
+	ShutdownTimeout = 5 * time.Second
+)
+
+// Server serves the state of the targets checked by a scheduler over HTTP
+type Server struct {
+	// StatusPage is served at /status when set
+	StatusPage http.Handler
+
+	scheduler *monitor.Scheduler
+	statuses  *monitor.StatusTracker
+	logger    *slog.Logger
+}
+
+// NewServer creates a server for the targets of scheduler, whose runner records their
+// checks in statuses
+func NewServer(scheduler *monitor.Scheduler, statuses *monitor.StatusTracker, logger *slog.Logger) *Server {
+	return &Server{scheduler: scheduler, statuses: statuses, logger: logger}
+}
+
+// Handler returns the routes of the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.healthz)
+	mux.HandleFunc("GET /readyz", s.readyz)
+	mux.HandleFunc("GET /api/v1/targets", s.targets)
+	mux.HandleFunc("GET /api/v1/targets/{name}/history", s.history)
+	mux.HandleFunc("POST /api/v1/targets/{name}/check", s.check)
+	if s.StatusPage != nil {
+		mux.Handle("GET /status", s.StatusPage)
+	}
+	return mux
+}
+
+// ListenAndServe serves the API on addr until the context is canceled
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	s.logger.Info("Starting status API", slog.String("address", addr))
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// healthz reports that the process is alive
+func (s *Server) healthz(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// readyz reports whether targets are being checked
+func (s *Server) readyz(w http.ResponseWriter, _ *http.Request) {
+	if !s.scheduler.Ready() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "not ready"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
+
+// targets lists the current state of every target
+func (s *Server) targets(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"targets": s.statuses.Statuses()})
+}
+
+// history lists the recent checks of a target, oldest first
+func (s *Server) history(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	checks, ok := s.statuses.History(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown target "+name)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"name": name, "checks": checks})
+}
+
+// check checks a target right away and returns its new state
+func (s *Server) check(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !s.scheduler.Ready() {
+		writeError(w, http.StatusServiceUnavailable, "targets aren't being checked")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), CheckTimeout)
+	defer cancel()
+
+	err := s.scheduler.CheckNow(ctx, name)
+	switch {
+	case errors.Is(err, monitor.ErrUnknownTarget):
+		writeError(w, http.StatusNotFound, "unknown target "+name)
+		return
+	case errors.Is(err, context.DeadlineExceeded):
+		writeError(w, http.StatusGatewayTimeout, "the check didn't complete in time")
+		return
+	case err != nil:
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	status, _ := s.statuses.Status(name)
+	writeJSON(w, http.StatusOK, status)
+}
+
+// writeError writes an error response
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]string{"error": message})
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
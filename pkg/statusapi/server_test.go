@@ -0,0 +1,175 @@
+package statusapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+	"github.com/kuskoman/url-datadog-monitor/pkg/monitor"
+)
+
+type nopDatadog struct{}
+
+func (nopDatadog) Gauge(string, float64, []string) error     { return nil }
+func (nopDatadog) Histogram(string, float64, []string) error { return nil }
+
+// newTestServer serves the API for a scheduler checking a target named Server
+func newTestServer(t *testing.T) (*httptest.Server, *monitor.Scheduler) {
+	t.Helper()
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(target.Close)
+
+	cfg := &config.Config{Targets: []config.Target{
+		{Name: "Server", URL: target.URL, Method: "GET", Timeout: 5, Interval: 3600},
+	}}
+	runner := monitor.NewRunner(nopDatadog{}, monitor.NopLogger())
+	runner.Statuses = monitor.NewStatusTracker(config.DefaultHistorySize)
+	scheduler := monitor.NewScheduler(cfg, runner)
+
+	server := httptest.NewServer(NewServer(scheduler, runner.Statuses, monitor.NopLogger()).Handler())
+	t.Cleanup(server.Close)
+	return server, scheduler
+}
+
+// runScheduler runs the scheduler until the test ends and waits for it to be ready
+func runScheduler(t *testing.T, scheduler *monitor.Scheduler) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go scheduler.Run(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !scheduler.Ready() {
+		if time.Now().After(deadline) {
+			t.Fatal("Scheduler didn't become ready")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServer_Readiness(t *testing.T) {
+	server, scheduler := newTestServer(t)
+
+	for path, code := range map[string]int{"/healthz": http.StatusOK, "/readyz": http.StatusServiceUnavailable} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Failed to request %s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != code {
+			t.Errorf("Expected %s to return %d before the scheduler runs, got %d", path, code, resp.StatusCode)
+		}
+	}
+
+	runScheduler(t, scheduler)
+	resp, err := http.Get(server.URL + "/readyz")
+	if err != nil {
+		t.Fatalf("Failed to request /readyz: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected /readyz to return 200 while the scheduler runs, got %d", resp.StatusCode)
+	}
+}
+
+func TestServer_CheckAndHistory(t *testing.T) {
+	server, scheduler := newTestServer(t)
+	runScheduler(t, scheduler)
+
+	resp, err := http.Post(server.URL+"/api/v1/targets/Server/check", "", nil)
+	if err != nil {
+		t.Fatalf("Failed to trigger a check: %v", err)
+	}
+	var status monitor.TargetStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode the target status: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || status.LastCheck == nil || status.LastCheck.StatusCode != http.StatusOK {
+		t.Errorf("Expected the triggered check in the response, got %d %+v", resp.StatusCode, status)
+	}
+
+	resp, err = http.Get(server.URL + "/api/v1/targets/Server/history")
+	if err != nil {
+		t.Fatalf("Failed to request the history: %v", err)
+	}
+	var history struct {
+		Name   string                `json:"name"`
+		Checks []monitor.CheckRecord `json:"checks"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&history); err != nil {
+		t.Fatalf("Failed to decode the history: %v", err)
+	}
+	resp.Body.Close()
+	if history.Name != "Server" || len(history.Checks) == 0 {
+		t.Errorf("Expected the checks of Server, got %+v", history)
+	}
+
+	resp, err = http.Get(server.URL + "/api/v1/targets")
+	if err != nil {
+		t.Fatalf("Failed to list targets: %v", err)
+	}
+	var list struct {
+		Targets []monitor.TargetStatus `json:"targets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatalf("Failed to decode the targets: %v", err)
+	}
+	resp.Body.Close()
+	if len(list.Targets) != 1 || list.Targets[0].Status != "up" {
+		t.Errorf("Expected Server to be listed as up, got %+v", list.Targets)
+	}
+}
+
+func TestServer_UnknownTarget(t *testing.T) {
+	server, scheduler := newTestServer(t)
+	runScheduler(t, scheduler)
+
+	resp, err := http.Post(server.URL+"/api/v1/targets/Unknown/check", "", nil)
+	if err != nil {
+		t.Fatalf("Failed to trigger a check: %v", err)
+	}
+	var body map[string]string
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound || body["error"] == "" {
+		t.Errorf("Expected a 404 with an error, got %d %v", resp.StatusCode, body)
+	}
+
+	resp, err = http.Get(server.URL + "/api/v1/targets/Unknown/history")
+	if err != nil {
+		t.Fatalf("Failed to request the history: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected a 404 for the history of an unknown target, got %d", resp.StatusCode)
+	}
+}
+
+func TestServer_StatusPage(t *testing.T) {
+	page := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("page"))
+	})
+	api := NewServer(nil, monitor.NewStatusTracker(1), monitor.NopLogger())
+
+	rec := httptest.NewRecorder()
+	api.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected no status page by default, got %d", rec.Code)
+	}
+
+	api.StatusPage = page
+	rec = httptest.NewRecorder()
+	api.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "page" {
+		t.Errorf("Expected the status page to be served, got %d %q", rec.Code, rec.Body.String())
+	}
+}
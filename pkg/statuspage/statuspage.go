@@ -0,0 +1,389 @@
+package statuspage
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/monitor"
+)
+
+const (
+	// MaxBars is the number of recent checks shown as uptime bars
+	MaxBars = 60
+	// CertificateWarningDays is how many days before expiry a certificate is highlighted
+	CertificateWarningDays = 14
+	// UngroupedName is the group of targets without the label they are grouped by
+	UngroupedName = "Other"
+
+	sparklineWidth  = 120
+	sparklineHeight = 24
+	timeFormat      = "2006-01-02 15:04 MST"
+
+	// refreshSeconds is how often browsers reload the page, and standbyRefreshSeconds how
+	// often they retry a standby replica hoping to reach the one checking the targets
+	refreshSeconds        = 60
+	standbyRefreshSeconds = 5
+)
+
+//go:embed page.html.tmpl
+var pageTemplate string
+
+var tmpl = template.Must(template.New("page").Parse(pageTemplate))
+
+// Options configures how a status page looks
+type Options struct {
+	Title string
+	// GroupBy is the label targets are grouped by, all targets are listed together when empty
+	GroupBy string
+	// ShowURLs lists the URL of every target
+	ShowURLs bool
+	// Standby reports whether the targets are checked elsewhere, like on an operator replica
+	// that isn't the leader. The page then says so instead of listing the targets.
+	Standby func() bool
+}
+
+// Generator renders the targets of a status tracker as an HTML page
+type Generator struct {
+	statuses *monitor.StatusTracker
+	options  Options
+}
+
+// NewGenerator creates a generator for the targets of statuses
+func NewGenerator(statuses *monitor.StatusTracker, options Options) *Generator {
+	return &Generator{statuses: statuses, options: options}
+}
+
+// Render writes the page as of now to w
+func (g *Generator) Render(w io.Writer, now time.Time) error {
+	return tmpl.Execute(w, g.page(now))
+}
+
+// ServeHTTP serves the page. Standby replicas answer with 503 Service Unavailable, so clients
+// and probes can tell their page is stale.
+func (g *Generator) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	var buf bytes.Buffer
+	if err := g.Render(&buf, time.Now()); err != nil {
+		http.Error(w, "failed to render the status page", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if g.standby() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_, _ = buf.WriteTo(w)
+}
+
+// standby reports whether the targets are checked elsewhere
+func (g *Generator) standby() bool {
+	return g.options.Standby != nil && g.options.Standby()
+}
+
+// WriteFile writes the page to path through a temporary file, so readers never see a partial page
+func (g *Generator) WriteFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create status page file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := g.Render(tmp, time.Now()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to render status page: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write status page file: %w", err)
+	}
+	// Temporary files are only readable by their owner, the page is meant to be served
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to write status page file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write status page file: %w", err)
+	}
+	return nil
+}
+
+// Run writes the page to path every interval until the context is canceled
+func (g *Generator) Run(ctx context.Context, path string, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := g.WriteFile(path); err != nil {
+			logger.Warn("Failed to write status page",
+				slog.String("path", path),
+				slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ListenAndServe serves the page on addr until the context is canceled
+func (g *Generator) ListenAndServe(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("GET /{$}", g)
+	mux.Handle("GET /status", g)
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// page is the data the page template is rendered from
+type page struct {
+	Title       string
+	GeneratedAt string
+	Refresh     int
+	Status      string
+	Summary     string
+	Groups      []*group
+}
+
+type group struct {
+	Name    string
+	Status  string
+	Targets []target
+}
+
+type target struct {
+	Name        string
+	URL         string
+	Status      string
+	StatusText  string
+	Uptime      string
+	Bars        []bar
+	Sparkline   string
+	LatencyMs   int64
+	Certificate *certificate
+}
+
+type bar struct {
+	Status string
+	Title  string
+}
+
+type certificate struct {
+	Status string
+	Text   string
+}
+
+// page builds the template data from the current state of the targets
+func (g *Generator) page(now time.Time) page {
+	p := page{
+		Title:       g.options.Title,
+		GeneratedAt: now.UTC().Format(timeFormat),
+		Refresh:     refreshSeconds,
+	}
+	if g.standby() {
+		p.Refresh = standbyRefreshSeconds
+		p.Status = monitor.StatusPending
+		p.Summary = "This replica is on standby and doesn't check the monitors, reloading to reach the one that does"
+		return p
+	}
+
+	groups := make(map[string]*group)
+	var ungrouped *group
+	for _, status := range g.statuses.Statuses() {
+		history, _ := g.statuses.History(status.Name)
+		t := g.target(status, history, now)
+
+		name, ok := status.Labels[g.options.GroupBy], true
+		if g.options.GroupBy != "" {
+			ok = name != ""
+		}
+		grp := groups[name]
+		if !ok {
+			if ungrouped == nil {
+				ungrouped = &group{Name: UngroupedName}
+			}
+			grp = ungrouped
+		} else if grp == nil {
+			grp = &group{Name: name}
+			groups[name] = grp
+			p.Groups = append(p.Groups, grp)
+		}
+		grp.Targets = append(grp.Targets, t)
+	}
+
+	sort.Slice(p.Groups, func(i, j int) bool { return p.Groups[i].Name < p.Groups[j].Name })
+	if ungrouped != nil {
+		p.Groups = append(p.Groups, ungrouped)
+	}
+
+	var all []target
+	for _, grp := range p.Groups {
+		grp.Status = worstStatus(grp.Targets)
+		all = append(all, grp.Targets...)
+	}
+	p.Status = worstStatus(all)
+	switch p.Status {
+	case "up":
+		p.Summary = "All systems operational"
+	case "degraded":
+		p.Summary = "Some systems are degraded"
+	case "down":
+		p.Summary = "Some systems are down"
+	default:
+		p.Summary = "Waiting for the first checks"
+	}
+	return p
+}
+
+// target builds the template data of a target
+func (g *Generator) target(status monitor.TargetStatus, history []monitor.CheckRecord, now time.Time) target {
+	t := target{
+		Name:   status.Name,
+		Status: displayStatus(status.Status),
+		Uptime: "-",
+	}
+	t.StatusText = statusText(status.Status)
+	if g.options.ShowURLs {
+		t.URL = status.URL
+	}
+	if status.LastCheck != nil {
+		t.LatencyMs = status.LastCheck.ResponseTimeMs
+	}
+
+	if len(history) > 0 {
+		up := 0
+		for _, check := range history {
+			if check.Up {
+				up++
+			}
+		}
+		t.Uptime = strconv.FormatFloat(float64(up)*100/float64(len(history)), 'f', 2, 64) + "%"
+	}
+
+	recent := history
+	if len(recent) > MaxBars {
+		recent = recent[len(recent)-MaxBars:]
+	}
+	for _, check := range recent {
+		title := check.Time.UTC().Format(timeFormat) + ": " + check.Status
+		if check.Error != "" {
+			title += ", " + check.Error
+		} else {
+			title += fmt.Sprintf(", %dms", check.ResponseTimeMs)
+		}
+		t.Bars = append(t.Bars, bar{Status: displayStatus(check.Status), Title: title})
+	}
+	t.Sparkline = sparkline(recent)
+
+	if cert := status.Certificate; cert != nil {
+		days := int(cert.NotAfter.Sub(now).Hours() / 24)
+		c := &certificate{Status: "up", Text: fmt.Sprintf("Certificate expires in %d days", days)}
+		switch {
+		case !cert.Valid || !cert.NotAfter.After(now):
+			c.Status, c.Text = "down", "Certificate invalid"
+			if !cert.NotAfter.IsZero() && !cert.NotAfter.After(now) {
+				c.Text = "Certificate expired on " + cert.NotAfter.UTC().Format("2006-01-02")
+			}
+		case days < CertificateWarningDays:
+			c.Status = "degraded"
+		}
+		t.Certificate = c
+	}
+	return t
+}
+
+// sparkline returns the SVG polyline points of the response times of successful checks
+func sparkline(checks []monitor.CheckRecord) string {
+	var times []int64
+	var highest int64
+	for _, check := range checks {
+		if !check.Up {
+			continue
+		}
+		times = append(times, check.ResponseTimeMs)
+		if check.ResponseTimeMs > highest {
+			highest = check.ResponseTimeMs
+		}
+	}
+	if len(times) < 2 {
+		return ""
+	}
+	if highest == 0 {
+		highest = 1
+	}
+
+	points := make([]string, len(times))
+	for i, ms := range times {
+		x := float64(i) * sparklineWidth / float64(len(times)-1)
+		// Leave a pixel of margin so the line isn't clipped at the top and bottom
+		y := 1 + (sparklineHeight-2)*(1-float64(ms)/float64(highest))
+		points[i] = strconv.FormatFloat(x, 'f', 1, 64) + "," + strconv.FormatFloat(y, 'f', 1, 64)
+	}
+	return strings.Join(points, " ")
+}
+
+// displayStatus maps the health of a check to the colors of the page. Critical checks are shown
+// as down, as in the url.status gauge.
+func displayStatus(status string) string {
+	switch status {
+	case "critical":
+		return "down"
+	case "up", "degraded", "down":
+		return status
+	default:
+		return monitor.StatusPending
+	}
+}
+
+// statusText describes the health of a target
+func statusText(status string) string {
+	switch status {
+	case "up":
+		return "Operational"
+	case "degraded":
+		return "Degraded performance"
+	case "critical":
+		return "Severely degraded"
+	case "down":
+		return "Outage"
+	default:
+		return "Pending"
+	}
+}
+
+// worstStatus returns the worst display status of targets, pending only when none was checked
+func worstStatus(targets []target) string {
+	rank := map[string]int{"down": 3, "degraded": 2, "up": 1}
+	worst := monitor.StatusPending
+	for _, t := range targets {
+		if rank[t.Status] > rank[worst] {
+			worst = t.Status
+		}
+	}
+	return worst
+}
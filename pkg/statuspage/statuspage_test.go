@@ -0,0 +1,125 @@
+package statuspage
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuskoman/url-datadog-monitor/pkg/certcheck"
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+	"github.com/kuskoman/url-datadog-monitor/pkg/monitor"
+)
+
+// newTestStatuses returns a tracker with an up api and a down web target of the shop
+// service, and a pending target without a service
+func newTestStatuses(now time.Time) *monitor.StatusTracker {
+	statuses := monitor.NewStatusTracker(config.DefaultHistorySize)
+	api := config.Target{Name: "api", URL: "https://api.example.com", Labels: map[string]string{"service": "shop"}}
+	web := config.Target{Name: "web", URL: "https://www.example.com", Labels: map[string]string{"service": "shop"}}
+	statuses.Register(config.Target{Name: "batch", URL: "https://batch.example.com"})
+
+	for i, ms := range []time.Duration{100, 200, 150} {
+		statuses.Record(api, now.Add(time.Duration(i-3)*time.Minute), monitor.Result{Up: true, Status: 200, Duration: ms * time.Millisecond}, monitor.HealthUp)
+	}
+	statuses.ObserveCertificate(api, now, &certcheck.CertificateDetails{IsValid: true, NotAfter: now.Add(10 * 24 * time.Hour)})
+	statuses.Record(web, now, monitor.Result{Err: errors.New("connection refused")}, monitor.HealthDown)
+	return statuses
+}
+
+func TestGenerator_Page(t *testing.T) {
+	now := time.Now()
+	generator := NewGenerator(newTestStatuses(now), Options{Title: "Status", GroupBy: "service"})
+
+	p := generator.page(now)
+	if p.Status != "down" || p.Summary != "Some systems are down" {
+		t.Errorf("Expected the page to report an outage, got %q %q", p.Status, p.Summary)
+	}
+	if len(p.Groups) != 2 || p.Groups[0].Name != "shop" || p.Groups[1].Name != UngroupedName {
+		t.Fatalf("Expected the shop group followed by the ungrouped targets, got %+v", p.Groups)
+	}
+
+	shop := p.Groups[0]
+	if shop.Status != "down" || len(shop.Targets) != 2 {
+		t.Fatalf("Expected the shop group to hold both of its targets and be down, got %+v", shop)
+	}
+	api := shop.Targets[0]
+	if api.Uptime != "100.00%" || len(api.Bars) != 3 || api.Sparkline == "" || api.LatencyMs != 150 {
+		t.Errorf("Expected the uptime, bars, sparkline and latency of api, got %+v", api)
+	}
+	if api.Certificate == nil || api.Certificate.Status != "degraded" {
+		t.Errorf("Expected the certificate expiring soon to be highlighted, got %+v", api.Certificate)
+	}
+	if api.URL != "" {
+		t.Errorf("Expected URLs to be hidden by default, got %q", api.URL)
+	}
+	if web := shop.Targets[1]; web.Status != "down" || web.Uptime != "0.00%" || web.Sparkline != "" {
+		t.Errorf("Expected web to be down without a sparkline, got %+v", web)
+	}
+	if batch := p.Groups[1].Targets[0]; batch.Status != monitor.StatusPending || len(batch.Bars) != 0 {
+		t.Errorf("Expected batch to be pending, got %+v", batch)
+	}
+}
+
+func TestGenerator_ServeHTTP(t *testing.T) {
+	generator := NewGenerator(newTestStatuses(time.Now()), Options{Title: "Example <Status>", ShowURLs: true})
+
+	rec := httptest.NewRecorder()
+	generator.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("Expected an HTML page, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	for _, want := range []string{"Example &lt;Status&gt;", "https://api.example.com", "Certificate expires in", "connection refused"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected the page to contain %q", want)
+		}
+	}
+}
+
+func TestGenerator_Standby(t *testing.T) {
+	standby := true
+	generator := NewGenerator(newTestStatuses(time.Now()), Options{Title: "Status", Standby: func() bool { return standby }})
+
+	rec := httptest.NewRecorder()
+	generator.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	body := rec.Body.String()
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(body, "on standby") {
+		t.Errorf("Expected a standby notice with 503, got %d", rec.Code)
+	}
+	if strings.Contains(body, "api") || !strings.Contains(body, `content="5"`) {
+		t.Errorf("Expected the targets to be left out and the page to reload quickly")
+	}
+
+	standby = false
+	rec = httptest.NewRecorder()
+	generator.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "on standby") {
+		t.Errorf("Expected the page of the leader, got %d", rec.Code)
+	}
+}
+
+func TestGenerator_WriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.html")
+	generator := NewGenerator(newTestStatuses(time.Now()), Options{Title: "Status"})
+
+	if err := generator.WriteFile(path); err != nil {
+		t.Fatalf("Failed to write the status page: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read the status page: %v", err)
+	}
+	if !strings.Contains(string(data), "Some systems are down") {
+		t.Errorf("Expected the written page to hold the summary, got %s", data)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary file to be left behind, got %d entries", len(entries))
+	}
+}
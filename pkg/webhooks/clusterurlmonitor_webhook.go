@@ -0,0 +1,104 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	urlmonitorv1 "github.com/kuskoman/url-datadog-monitor/pkg/api/v1"
+)
+
+// ClusterURLMonitorWebhook defaults and validates ClusterURLMonitor resources on admission
+// with the same rules as URLMonitors. Namespace policies don't apply to them, only the
+// operator-wide minimum interval does.
+type ClusterURLMonitorWebhook struct {
+	Client client.Client
+	// APIReader reads referenced CA Secrets directly from the API server, so the manager
+	// doesn't need to cache every Secret of the cluster. The client is used when nil.
+	APIReader client.Reader
+	Policy    Policy
+}
+
+// NewClusterURLMonitorWebhook creates a new admission webhook for ClusterURLMonitor resources
+func NewClusterURLMonitorWebhook(client client.Client, policy Policy) *ClusterURLMonitorWebhook {
+	return &ClusterURLMonitorWebhook{
+		Client: client,
+		Policy: policy,
+	}
+}
+
+// +kubebuilder:webhook:path=/mutate-url-datadog-monitor-kuskoman-github-com-v1-clusterurlmonitor,mutating=true,failurePolicy=fail,sideEffects=None,groups=url-datadog-monitor.kuskoman.github.com,resources=clusterurlmonitors,verbs=create;update,versions=v1,name=mclusterurlmonitor.kuskoman.github.com,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate-url-datadog-monitor-kuskoman-github-com-v1-clusterurlmonitor,mutating=false,failurePolicy=fail,sideEffects=None,groups=url-datadog-monitor.kuskoman.github.com,resources=clusterurlmonitors,verbs=create;update,versions=v1,name=vclusterurlmonitor.kuskoman.github.com,admissionReviewVersions=v1
+
+// SetupWithManager registers the webhook with the Manager's webhook server
+func (w *ClusterURLMonitorWebhook) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&urlmonitorv1.ClusterURLMonitor{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+// Default fills in unset fields of a ClusterURLMonitor
+func (w *ClusterURLMonitorWebhook) Default(_ context.Context, obj runtime.Object) error {
+	urlMonitor, ok := obj.(*urlmonitorv1.ClusterURLMonitor)
+	if !ok {
+		return fmt.Errorf("expected a ClusterURLMonitor but got %T", obj)
+	}
+	DefaultSpec(&urlMonitor.Spec)
+	return nil
+}
+
+// ValidateCreate validates a new ClusterURLMonitor
+func (w *ClusterURLMonitorWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return w.validate(ctx, obj)
+}
+
+// ValidateUpdate validates an updated ClusterURLMonitor
+func (w *ClusterURLMonitorWebhook) ValidateUpdate(ctx context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	return w.validate(ctx, newObj)
+}
+
+// ValidateDelete allows every deletion
+func (w *ClusterURLMonitorWebhook) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate checks a ClusterURLMonitor against the spec rules and the operator policy
+func (w *ClusterURLMonitorWebhook) validate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	urlMonitor, ok := obj.(*urlmonitorv1.ClusterURLMonitor)
+	if !ok {
+		return nil, fmt.Errorf("expected a ClusterURLMonitor but got %T", obj)
+	}
+
+	allErrs := ValidateSpec(&urlMonitor.Spec, field.NewPath("spec"))
+
+	if ref := urlMonitor.Spec.CASecret; ref != nil {
+		if ref.Namespace == "" {
+			allErrs = append(allErrs, field.Required(field.NewPath("spec", "caSecret", "namespace"),
+				"ClusterURLMonitors must set the namespace of their Secret"))
+		} else {
+			caErrs, err := validateCASecret(ctx, reader(w.Client, w.APIReader), ref, ref.Namespace, field.NewPath("spec", "caSecret"))
+			if err != nil {
+				return nil, err
+			}
+			allErrs = append(allErrs, caErrs...)
+		}
+	}
+
+	if w.Policy.MinInterval > 0 && urlMonitor.Spec.Interval < w.Policy.MinInterval {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "interval"), urlMonitor.Spec.Interval,
+			fmt.Sprintf("must be at least %d seconds", w.Policy.MinInterval)))
+	}
+
+	if len(allErrs) > 0 {
+		return nil, apierrors.NewInvalid(urlmonitorv1.GroupVersion.WithKind("ClusterURLMonitor").GroupKind(), urlMonitor.Name, allErrs)
+	}
+	return nil, nil
+}
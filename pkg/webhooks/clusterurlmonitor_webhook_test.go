@@ -0,0 +1,78 @@
+package webhooks
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	urlmonitorv1 "github.com/kuskoman/url-datadog-monitor/pkg/api/v1"
+)
+
+func newTestClusterMonitor(name string) *urlmonitorv1.ClusterURLMonitor {
+	return &urlmonitorv1.ClusterURLMonitor{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       newTestMonitor(name).Spec,
+	}
+}
+
+func TestClusterDefault(t *testing.T) {
+	w := NewClusterURLMonitorWebhook(newTestClient(t), Policy{})
+
+	m := &urlmonitorv1.ClusterURLMonitor{Spec: urlmonitorv1.URLMonitorSpec{URL: "https://example.com", Method: "post"}}
+	if err := w.Default(context.Background(), m); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if m.Spec.Method != "POST" {
+		t.Errorf("Expected method 'POST', got '%s'", m.Spec.Method)
+	}
+	if m.Spec.Interval != 60 || m.Spec.Timeout != 10 {
+		t.Errorf("Expected default interval and timeout, got %d and %d", m.Spec.Interval, m.Spec.Timeout)
+	}
+}
+
+func TestClusterValidateCreate(t *testing.T) {
+	w := NewClusterURLMonitorWebhook(newTestClient(t), Policy{MinInterval: 30, MaxMonitorsPerNamespace: 1})
+
+	if _, err := w.ValidateCreate(context.Background(), newTestClusterMonitor("platform")); err != nil {
+		t.Errorf("Expected a valid monitor to be accepted, got %v", err)
+	}
+
+	m := newTestClusterMonitor("platform")
+	m.Spec.Interval = 10
+	m.Spec.Headers = map[string]string{"Bad Header": "value"}
+	m.Spec.SLO = &urlmonitorv1.SLO{Objective: "99.9", Window: "0d"}
+	m.Spec.CASecret = &urlmonitorv1.SecretKeyReference{Name: "internal-ca"}
+	_, err := w.ValidateCreate(context.Background(), m)
+	if err == nil {
+		t.Fatalf("Expected validation error")
+	}
+	for _, path := range []string{"spec.interval", "spec.headers", "spec.slo", "spec.caSecret.namespace"} {
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("Expected error to mention %s, got %v", path, err)
+		}
+	}
+}
+
+func TestClusterValidateCreate_CASecret(t *testing.T) {
+	w := NewClusterURLMonitorWebhook(newTestClient(t, newTestCASecret(t, "platform", "internal-ca")), Policy{})
+
+	m := newTestClusterMonitor("internal")
+	m.Spec.CASecret = &urlmonitorv1.SecretKeyReference{Name: "internal-ca", Namespace: "platform"}
+	if _, err := w.ValidateCreate(context.Background(), m); err != nil {
+		t.Errorf("Expected a valid CA secret to be accepted, got %v", err)
+	}
+
+	m.Spec.CASecret = &urlmonitorv1.SecretKeyReference{Name: "internal-ca", Namespace: "platform", Key: "invalid.pem"}
+	_, err := w.ValidateCreate(context.Background(), m)
+	if err == nil || !strings.Contains(err.Error(), "spec.caSecret.key") {
+		t.Errorf("Expected an error for a bundle without certificates, got %v", err)
+	}
+
+	m.Spec.CASecret = &urlmonitorv1.SecretKeyReference{Name: "internal-ca", Namespace: "team-a"}
+	_, err = w.ValidateCreate(context.Background(), m)
+	if err == nil || !strings.Contains(err.Error(), "spec.caSecret.name") {
+		t.Errorf("Expected an error for a missing secret, got %v", err)
+	}
+}
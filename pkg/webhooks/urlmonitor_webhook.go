@@ -0,0 +1,392 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	urlmonitorv1 "github.com/kuskoman/url-datadog-monitor/pkg/api/v1"
+	"github.com/kuskoman/url-datadog-monitor/pkg/certcheck"
+	"github.com/kuskoman/url-datadog-monitor/pkg/config"
+	"github.com/kuskoman/url-datadog-monitor/pkg/dialer"
+)
+
+const (
+	// AnnotationMaxMonitors on a Namespace caps the number of URLMonitors it may contain
+	AnnotationMaxMonitors = "url-monitor.kuskoman.github.com/max-monitors"
+	// AnnotationMinInterval on a Namespace sets the minimum check interval in seconds
+	AnnotationMinInterval = "url-monitor.kuskoman.github.com/min-interval"
+
+	// forbiddenTagChars can't appear in labels because they are sent as DogStatsD tags
+	forbiddenTagChars = ",|#"
+)
+
+// Policy holds the operator-wide limits used when a namespace doesn't annotate its own
+type Policy struct {
+	// MaxMonitorsPerNamespace caps the number of URLMonitors per namespace, 0 means unlimited
+	MaxMonitorsPerNamespace int
+	// MinInterval is the minimum check interval in seconds, 0 means no minimum beyond the CRD's
+	MinInterval int
+}
+
+// URLMonitorWebhook defaults and validates URLMonitor resources on admission.
+// It covers the checks that can't be expressed with OpenAPI or CEL markers.
+type URLMonitorWebhook struct {
+	Client client.Client
+	// APIReader reads referenced CA Secrets directly from the API server, so the manager
+	// doesn't need to cache every Secret of the cluster. The client is used when nil.
+	APIReader client.Reader
+	Policy    Policy
+}
+
+// NewURLMonitorWebhook creates a new admission webhook for URLMonitor resources
+func NewURLMonitorWebhook(client client.Client, policy Policy) *URLMonitorWebhook {
+	return &URLMonitorWebhook{
+		Client: client,
+		Policy: policy,
+	}
+}
+
+// +kubebuilder:webhook:path=/mutate-url-datadog-monitor-kuskoman-github-com-v1-urlmonitor,mutating=true,failurePolicy=fail,sideEffects=None,groups=url-datadog-monitor.kuskoman.github.com,resources=urlmonitors,verbs=create;update,versions=v1,name=murlmonitor.kuskoman.github.com,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate-url-datadog-monitor-kuskoman-github-com-v1-urlmonitor,mutating=false,failurePolicy=fail,sideEffects=None,groups=url-datadog-monitor.kuskoman.github.com,resources=urlmonitors,verbs=create;update,versions=v1,name=vurlmonitor.kuskoman.github.com,admissionReviewVersions=v1
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+// SetupWithManager registers the webhook with the Manager's webhook server
+func (w *URLMonitorWebhook) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&urlmonitorv1.URLMonitor{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+// Default fills in unset fields of a URLMonitor
+func (w *URLMonitorWebhook) Default(_ context.Context, obj runtime.Object) error {
+	urlMonitor, ok := obj.(*urlmonitorv1.URLMonitor)
+	if !ok {
+		return fmt.Errorf("expected a URLMonitor but got %T", obj)
+	}
+	DefaultSpec(&urlMonitor.Spec)
+	return nil
+}
+
+// DefaultSpec fills in unset fields of a URLMonitorSpec.
+// Certificate settings are dropped for non-HTTPS URLs where they don't apply.
+func DefaultSpec(spec *urlmonitorv1.URLMonitorSpec) {
+	spec.Method = strings.ToUpper(spec.Method)
+	if spec.Method == "" {
+		spec.Method = config.DefaultMethod
+	}
+	if spec.Interval == 0 {
+		spec.Interval = config.DefaultInterval
+	}
+	if spec.Timeout == 0 {
+		spec.Timeout = config.DefaultTimeout
+	}
+
+	if certcheck.IsTLSURL(spec.URL) {
+		if spec.CheckCert == nil {
+			checkCert := true
+			spec.CheckCert = &checkCert
+		}
+		if spec.VerifyCert == nil {
+			verifyCert := false
+			spec.VerifyCert = &verifyCert
+		}
+	} else {
+		spec.CheckCert = nil
+		spec.VerifyCert = nil
+	}
+
+	if spec.SLO != nil && spec.SLO.Window == "" {
+		spec.SLO.Window = config.DefaultSLOWindow
+	}
+}
+
+// ValidateCreate validates a new URLMonitor, including the namespace monitor cap
+func (w *URLMonitorWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return w.validate(ctx, obj, true)
+}
+
+// ValidateUpdate validates an updated URLMonitor
+func (w *URLMonitorWebhook) ValidateUpdate(ctx context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	return w.validate(ctx, newObj, false)
+}
+
+// ValidateDelete allows every deletion
+func (w *URLMonitorWebhook) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate checks a URLMonitor against the spec rules and the policy of its namespace
+func (w *URLMonitorWebhook) validate(ctx context.Context, obj runtime.Object, creating bool) (admission.Warnings, error) {
+	urlMonitor, ok := obj.(*urlmonitorv1.URLMonitor)
+	if !ok {
+		return nil, fmt.Errorf("expected a URLMonitor but got %T", obj)
+	}
+
+	allErrs := ValidateSpec(&urlMonitor.Spec, field.NewPath("spec"))
+
+	policy, err := w.namespacePolicy(ctx, urlMonitor.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if ref := urlMonitor.Spec.CASecret; ref != nil {
+		if ref.Namespace != "" && ref.Namespace != urlMonitor.Namespace {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "caSecret", "namespace"),
+				"URLMonitors may only reference Secrets in their own namespace"))
+		} else {
+			caErrs, err := validateCASecret(ctx, reader(w.Client, w.APIReader), ref, urlMonitor.Namespace, field.NewPath("spec", "caSecret"))
+			if err != nil {
+				return nil, err
+			}
+			allErrs = append(allErrs, caErrs...)
+		}
+	}
+
+	if policy.MinInterval > 0 && urlMonitor.Spec.Interval < policy.MinInterval {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "interval"), urlMonitor.Spec.Interval,
+			fmt.Sprintf("must be at least %d seconds in namespace %s", policy.MinInterval, urlMonitor.Namespace)))
+	}
+
+	if creating && policy.MaxMonitorsPerNamespace > 0 {
+		existing := &urlmonitorv1.URLMonitorList{}
+		if err := w.Client.List(ctx, existing, client.InNamespace(urlMonitor.Namespace)); err != nil {
+			return nil, fmt.Errorf("failed to count URLMonitors: %w", err)
+		}
+		if len(existing.Items) >= policy.MaxMonitorsPerNamespace {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("metadata", "namespace"),
+				fmt.Sprintf("namespace %s already has the maximum of %d URLMonitors", urlMonitor.Namespace, policy.MaxMonitorsPerNamespace)))
+		}
+	}
+
+	if len(allErrs) > 0 {
+		return nil, apierrors.NewInvalid(urlmonitorv1.GroupVersion.WithKind("URLMonitor").GroupKind(), urlMonitor.Name, allErrs)
+	}
+	return nil, nil
+}
+
+// ValidateSpec checks the parts of a URLMonitorSpec that the CRD schema can't express
+func ValidateSpec(spec *urlmonitorv1.URLMonitorSpec, specPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if u, err := url.Parse(spec.URL); err != nil {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("url"), spec.URL, err.Error()))
+	} else if u.Host == "" {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("url"), spec.URL, "must include a host"))
+	}
+
+	for name, value := range spec.Headers {
+		if !httpguts.ValidHeaderFieldName(name) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("headers").Key(name), name, "invalid HTTP header name"))
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("headers").Key(name), value, "invalid HTTP header value"))
+		}
+	}
+
+	for key, value := range spec.Labels {
+		if key == "" || strings.ContainsAny(key, forbiddenTagChars+":") {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("labels").Key(key), key,
+				"label keys must be non-empty and can't contain ',', '|', '#' or ':'"))
+		}
+		if strings.ContainsAny(value, forbiddenTagChars) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("labels").Key(key), value,
+				"label values can't contain ',', '|' or '#'"))
+		}
+	}
+
+	if spec.ServerName != "" && strings.ContainsAny(spec.ServerName, ":/") {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("serverName"), spec.ServerName,
+			"must be a host name without scheme or port"))
+	}
+
+	if err := dialer.Validate(spec.Resolve, ""); err != nil {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("resolve"), spec.Resolve, err.Error()))
+	}
+	if err := dialer.Validate(nil, spec.DNSServer); err != nil {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("dnsServer"), spec.DNSServer, err.Error()))
+	}
+
+	if spec.Proxy != "" {
+		// Specs are readable by anyone allowed to list monitors, so credentials don't
+		// belong there and aren't repeated in errors
+		proxyURL, parseErr := url.Parse(spec.Proxy)
+		redacted := spec.Proxy
+		if parseErr == nil {
+			redacted = proxyURL.Redacted()
+		}
+		if err := dialer.ValidateProxy(spec.Proxy); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("proxy"), redacted, err.Error()))
+		} else if parseErr == nil && proxyURL.User != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("proxy"), redacted,
+				"must not contain credentials, set HTTPS_PROXY on the operator and use env instead"))
+		}
+	}
+
+	fingerprintsPath := specPath.Child("certFingerprints")
+	for i, fingerprint := range spec.CertFingerprints {
+		if err := certcheck.ValidateFingerprint(fingerprint); err != nil {
+			allErrs = append(allErrs, field.Invalid(fingerprintsPath.Index(i), fingerprint, err.Error()))
+		}
+	}
+
+	if err := config.ValidateFinalURL(spec.ExpectedFinalURL); err != nil {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("expectedFinalURL"), spec.ExpectedFinalURL, err.Error()))
+	}
+
+	if err := config.ValidateProtocol(spec.Protocol, spec.URL); err != nil {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("protocol"), spec.Protocol, err.Error()))
+	}
+
+	assertionsPath := specPath.Child("headerAssertions")
+	for i, assertion := range spec.HeaderAssertions {
+		if err := config.ValidateHeaderAssertion(config.HeaderAssertion{
+			Header:  assertion.Header,
+			Present: assertion.Present,
+			Absent:  assertion.Absent,
+			Equals:  assertion.Equals,
+			Regex:   assertion.Regex,
+		}); err != nil {
+			allErrs = append(allErrs, field.Invalid(assertionsPath.Index(i), assertion.Header, err.Error()))
+		}
+	}
+
+	if err := config.ValidateLatencyThresholds(spec.LatencyWarningMs, spec.LatencyCriticalMs); err != nil {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("latencyWarningMs"), spec.LatencyWarningMs, err.Error()))
+	}
+
+	if spec.SLO != nil {
+		allErrs = append(allErrs, validateSLO(spec.SLO, specPath.Child("slo"))...)
+	}
+
+	ignorePath := specPath.Child("changeIgnoreRegexes")
+	for i, pattern := range spec.ChangeIgnoreRegexes {
+		if err := config.ValidateRegexes([]string{pattern}); err != nil {
+			allErrs = append(allErrs, field.Invalid(ignorePath.Index(i), pattern, err.Error()))
+		}
+	}
+
+	if spec.TLSPolicy != nil {
+		ciphersPath := specPath.Child("tlsPolicy", "ciphers")
+		for i, cipher := range spec.TLSPolicy.Ciphers {
+			if _, err := certcheck.ParseCipherSuites([]string{cipher}); err != nil {
+				allErrs = append(allErrs, field.Invalid(ciphersPath.Index(i), cipher, err.Error()))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// validateSLO checks an SLO with the rules applied to the standalone config
+func validateSLO(spec *urlmonitorv1.SLO, sloPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	objective, err := strconv.ParseFloat(spec.Objective, 64)
+	if err != nil {
+		return append(allErrs, field.Invalid(sloPath.Child("objective"), spec.Objective, "must be a number"))
+	}
+	target := config.SLO{Objective: objective, Window: spec.Window}
+	for i, alert := range spec.BurnRateAlerts {
+		threshold, err := strconv.ParseFloat(alert.Threshold, 64)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(sloPath.Child("burnRateAlerts").Index(i).Child("threshold"), alert.Threshold, "must be a number"))
+			continue
+		}
+		target.BurnRateAlerts = append(target.BurnRateAlerts, config.BurnRateAlert{
+			LongWindow:  alert.LongWindow,
+			ShortWindow: alert.ShortWindow,
+			Threshold:   threshold,
+		})
+	}
+
+	if len(allErrs) > 0 {
+		return allErrs
+	}
+
+	config.SetSLODefaults(&target)
+	if err := config.ValidateSLO(target); err != nil {
+		allErrs = append(allErrs, field.Invalid(sloPath, spec.Objective, err.Error()))
+	}
+	return allErrs
+}
+
+// validateCASecret checks that a referenced Secret exists and holds PEM encoded certificates
+// under its key. Errors other than a missing Secret are returned, so the request is retried.
+func validateCASecret(ctx context.Context, reader client.Reader, ref *urlmonitorv1.SecretKeyReference, namespace string, refPath *field.Path) (field.ErrorList, error) {
+	key := ref.Key
+	if key == "" {
+		key = urlmonitorv1.DefaultCASecretKey
+	}
+
+	secret := &corev1.Secret{}
+	if err := reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return field.ErrorList{field.NotFound(refPath.Child("name"), fmt.Sprintf("%s/%s", namespace, ref.Name))}, nil
+		}
+		return nil, fmt.Errorf("failed to get Secret %s/%s: %w", namespace, ref.Name, err)
+	}
+
+	data, ok := secret.Data[key]
+	if !ok {
+		return field.ErrorList{field.Invalid(refPath.Child("key"), key,
+			fmt.Sprintf("Secret %s/%s has no such key", namespace, ref.Name))}, nil
+	}
+	if _, err := certcheck.NewCertPool(data); err != nil {
+		return field.ErrorList{field.Invalid(refPath.Child("key"), key,
+			fmt.Sprintf("Secret %s/%s: %v", namespace, ref.Name, err))}, nil
+	}
+	return nil, nil
+}
+
+// reader returns the reader to get Secrets with, the client unless an API reader is set
+func reader(c client.Client, apiReader client.Reader) client.Reader {
+	if apiReader != nil {
+		return apiReader
+	}
+	return c
+}
+
+// namespacePolicy returns the operator policy overridden by the annotations of a namespace
+func (w *URLMonitorWebhook) namespacePolicy(ctx context.Context, namespace string) (Policy, error) {
+	policy := w.Policy
+
+	ns := &corev1.Namespace{}
+	if err := w.Client.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		if apierrors.IsNotFound(err) {
+			return policy, nil
+		}
+		return policy, fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+
+	if value, ok := ns.Annotations[AnnotationMaxMonitors]; ok {
+		maxMonitors, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return policy, fmt.Errorf("invalid %s annotation on namespace %s: %w", AnnotationMaxMonitors, namespace, err)
+		}
+		policy.MaxMonitorsPerNamespace = maxMonitors
+	}
+
+	if value, ok := ns.Annotations[AnnotationMinInterval]; ok {
+		minInterval, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return policy, fmt.Errorf("invalid %s annotation on namespace %s: %w", AnnotationMinInterval, namespace, err)
+		}
+		policy.MinInterval = minInterval
+	}
+
+	return policy, nil
+}
@@ -0,0 +1,145 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	urlmonitorv1 "github.com/kuskoman/url-datadog-monitor/pkg/api/v1"
+)
+
+// TestWebhookWithEnvtest runs the webhook against a real API server.
+// It requires the envtest binaries, see https://book.kubebuilder.io/reference/envtest
+// (e.g. KUBEBUILDER_ASSETS="$(setup-envtest use -p path)" go test ./pkg/webhooks/...).
+func TestWebhookWithEnvtest(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS is not set, skipping envtest-based webhook test")
+	}
+
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "config", "crd", "bases")},
+		ErrorIfCRDPathMissing: true,
+		WebhookInstallOptions: envtest.WebhookInstallOptions{
+			Paths: []string{filepath.Join("..", "..", "config", "webhook")},
+		},
+	}
+
+	cfg, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("Failed to start envtest: %v", err)
+	}
+	defer func() {
+		if err := testEnv.Stop(); err != nil {
+			t.Logf("Failed to stop envtest: %v", err)
+		}
+	}()
+
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = urlmonitorv1.AddToScheme(scheme)
+
+	webhookOpts := testEnv.WebhookInstallOptions
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:  scheme,
+		Metrics: metricsserver.Options{BindAddress: "0"},
+		WebhookServer: webhook.NewServer(webhook.Options{
+			Host:    webhookOpts.LocalServingHost,
+			Port:    webhookOpts.LocalServingPort,
+			CertDir: webhookOpts.LocalServingCertDir,
+		}),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	if err := NewURLMonitorWebhook(mgr.GetClient(), Policy{MinInterval: 30}).SetupWithManager(mgr); err != nil {
+		t.Fatalf("Failed to set up webhook: %v", err)
+	}
+	if err := NewClusterURLMonitorWebhook(mgr.GetClient(), Policy{MinInterval: 30}).SetupWithManager(mgr); err != nil {
+		t.Fatalf("Failed to set up webhook: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		if err := mgr.Start(ctx); err != nil {
+			t.Errorf("Manager failed: %v", err)
+		}
+	}()
+
+	addr := net.JoinHostPort(webhookOpts.LocalServingHost, fmt.Sprint(webhookOpts.LocalServingPort))
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
+		if err == nil {
+			conn.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Webhook server didn't start: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "team-a",
+		Annotations: map[string]string{AnnotationMaxMonitors: "1"},
+	}}
+	if err := c.Create(ctx, ns); err != nil {
+		t.Fatalf("Failed to create namespace: %v", err)
+	}
+
+	m := &urlmonitorv1.URLMonitor{
+		ObjectMeta: metav1.ObjectMeta{Name: "http-monitor", Namespace: "team-a"},
+		Spec: urlmonitorv1.URLMonitorSpec{
+			URL:      "http://example.com",
+			Method:   "get",
+			Interval: 60,
+			Timeout:  10,
+		},
+	}
+	if err := c.Create(ctx, m); err != nil {
+		t.Fatalf("Expected valid URLMonitor to be admitted, got %v", err)
+	}
+	if m.Spec.Method != "GET" || m.Spec.CheckCert != nil {
+		t.Errorf("Expected URLMonitor to be defaulted, got method '%s' and checkCert %v", m.Spec.Method, m.Spec.CheckCert)
+	}
+
+	tooFast := &urlmonitorv1.URLMonitor{
+		ObjectMeta: metav1.ObjectMeta{Name: "too-fast", Namespace: "default"},
+		Spec: urlmonitorv1.URLMonitorSpec{
+			URL:      "https://example.com",
+			Interval: 10,
+			Timeout:  5,
+		},
+	}
+	if err := c.Create(ctx, tooFast); err == nil {
+		t.Errorf("Expected URLMonitor below the minimum interval to be rejected")
+	}
+
+	overCap := m.DeepCopy()
+	overCap.ObjectMeta = metav1.ObjectMeta{Name: "over-cap", Namespace: "team-a"}
+	if err := c.Create(ctx, overCap); err == nil {
+		t.Errorf("Expected URLMonitor over the namespace cap to be rejected")
+	}
+}